@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/github"
+	"github.com/thatsneat-dev/nprt/internal/logging"
+	"github.com/thatsneat-dev/nprt/internal/render"
+)
+
+const authUsage = `Usage: nprt auth status [options]
+
+Show whether a GitHub token is configured, which login it belongs to, its
+scopes and the remaining rate limit budget.
+
+Exits 0 if the status could be determined, 3 if the token was rejected, and
+1 otherwise.
+
+Options:
+  --api-url          GitHub API base URL (default: https://api.github.com, or
+                     derived from --web-url)
+  --web-url          GitHub web base URL, e.g. https://github.example.com for a
+                     GitHub Enterprise Server (default: https://github.com)
+  --color            Color output mode: auto, always, never (default: auto)
+  --config           Config file to read (default: $XDG_CONFIG_HOME/nprt/config.toml)
+  --json             Output the status as JSON
+  --verbose          Show detailed progress and debug information
+  -h, --help         Show this help message
+`
+
+func runAuth(args []string) int {
+	var (
+		hostOpts   hostOptions
+		colorMode  string
+		configPath string
+		jsonOutput bool
+		verbose    bool
+	)
+
+	fs := flag.NewFlagSet("nprt auth", flag.ContinueOnError)
+	hostOpts.register(fs)
+	fs.StringVar(&colorMode, "color", "auto", "Color output: auto, always, never")
+	fs.StringVar(&configPath, "config", "", "Config file to read")
+	fs.BoolVar(&jsonOutput, "json", false, "Output the status as JSON")
+	fs.BoolVar(&verbose, "verbose", false, "Show detailed progress and debug information")
+
+	// The config file may select a GitHub Enterprise Server with api-url or
+	// web-url, whose token is then the one shown.
+	_, args, code, ok := parseConfigFlags(fs, args, authUsage)
+	if !ok {
+		return code
+	}
+
+	useColor, err := config.ShouldUseColor(colorMode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	stderrColor := config.ShouldUseColorForFile(colorMode, os.Stderr)
+
+	if len(args) != 1 || args[0] != "status" {
+		fmt.Fprint(os.Stderr, authUsage)
+		return 2
+	}
+
+	host, err := hostOpts.host()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), stderrColor))
+		return 2
+	}
+
+	log := logging.New(verbose)
+	defer func() { _ = log.Sync() }()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	token, warnings := config.FindGitHubToken(host)
+	for _, w := range warnings {
+		fmt.Fprintln(os.Stderr, render.FormatWarning(w, stderrColor))
+	}
+
+	client := github.NewClient(token.Value, "nprt/"+version, log)
+	client.BaseURL = host.APIURL
+	status, err := client.GetAuthStatus(ctx)
+	if err != nil {
+		var apiErr *github.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			fmt.Fprintln(os.Stderr, render.FormatError("GitHub rejected the token: "+apiErr.Message, stderrColor))
+			return 3
+		}
+		fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), stderrColor))
+		return 1
+	}
+
+	status.TokenSource = token.Source
+	renderer := render.NewRenderer(os.Stdout, useColor, false)
+	if jsonOutput {
+		err = renderer.RenderAuthStatusJSON(status)
+	} else {
+		err = renderer.RenderAuthStatus(status, time.Now())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, render.FormatError("rendering output: "+err.Error(), stderrColor))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/thatsneat-dev/nprt/internal/cli"
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+	"github.com/thatsneat-dev/nprt/internal/render"
+)
+
+// isBatch reports whether the positional arguments ask for more than a single
+// PR check.
+func isBatch(args []string) bool {
+	return len(args) > 1 || (len(args) == 1 && args[0] == "-")
+}
+
+// readPRInputs expands the positional arguments into PR inputs, replacing "-"
+// with the whitespace-separated inputs read from stdin.
+func readPRInputs(args []string, stdin io.Reader) ([]string, error) {
+	var inputs []string
+	readStdin := false
+	for _, arg := range args {
+		if arg != "-" {
+			inputs = append(inputs, arg)
+			continue
+		}
+		if readStdin {
+			return nil, errors.New("- may only be given once")
+		}
+		readStdin = true
+
+		scanner := bufio.NewScanner(stdin)
+		scanner.Split(bufio.ScanWords)
+		for scanner.Scan() {
+			inputs = append(inputs, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading PRs from stdin: %w", err)
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, errors.New("no PRs given")
+	}
+	return inputs, nil
+}
+
+// parsePRInputs parses every input, reporting all invalid ones at once.
+func (s *session) parsePRInputs(inputs []string) ([]config.PRRef, error) {
+	refs := make([]config.PRRef, 0, len(inputs))
+	var invalid []string
+	for _, input := range inputs {
+		ref, err := s.host.ParsePRInput(input)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	if len(invalid) > 0 {
+		return nil, errors.New(strings.Join(invalid, "\n"))
+	}
+	return refs, nil
+}
+
+// runBatch checks several PRs and renders them as a matrix, a JSON array or
+// an NDJSON stream. It exits 0 if every PR was checked, 3 if any check hit
+// the rate limit, and 1 otherwise; once every PR was checked, --require
+// decides.
+func (s *session) runBatch(ctx context.Context, args []string, workers int, ndjson bool) int {
+	if unknown := cli.HasUnknownFlags(args); unknown != "" {
+		return s.usageError("unknown flag " + unknown)
+	}
+	inputs, err := readPRInputs(args, os.Stdin)
+	if err != nil {
+		return s.usageError(err.Error())
+	}
+	refs, err := s.parsePRInputs(inputs)
+	if err != nil {
+		return s.usageError(err.Error())
+	}
+	if code := s.setupRepo(refs); code != 0 {
+		return code
+	}
+
+	channels, code := s.resolveChannels(ctx)
+	if code != 0 {
+		return code
+	}
+	if code := s.resolveRequirement(ctx); code != 0 {
+		return code
+	}
+
+	renderer := render.NewRenderer(os.Stdout, s.useColor, s.useHyperlinks)
+
+	var onResult func(core.BatchResult)
+	if ndjson {
+		onResult = func(res core.BatchResult) {
+			s.attachRateLimit(res.Status)
+			_ = renderer.RenderNDJSON(res)
+		}
+	}
+
+	results := s.checker.CheckPRs(ctx, refs, channels, workers, onResult)
+	if !ndjson {
+		for _, res := range results {
+			s.attachRateLimit(res.Status)
+		}
+	}
+
+	switch {
+	case s.template != nil:
+		if err := s.renderTemplateResults(renderer, results); err != nil {
+			fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), s.stderrColor))
+			return 1
+		}
+	case !ndjson:
+		err = renderer.RenderBatchAs(s.format, results)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, render.FormatError("rendering output: "+err.Error(), s.stderrColor))
+		return 1
+	}
+
+	if code := batchExitCode(results); code != 0 {
+		return code
+	}
+	return s.checkBatchRequirement(results)
+}
+
+func batchExitCode(results []core.BatchResult) int {
+	code := 0
+	for _, res := range results {
+		if res.Err == nil {
+			continue
+		}
+		var apiErr *github.APIError
+		if errors.As(res.Err, &apiErr) && (apiErr.StatusCode == 403 || apiErr.StatusCode == 429) {
+			return 3
+		}
+		code = 1
+	}
+	return code
+}
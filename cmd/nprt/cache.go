@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/thatsneat-dev/nprt/internal/cache"
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/render"
+)
+
+const cacheUsage = `Usage: nprt cache stats [--json]
+       nprt cache clear
+
+Show or clear the on-disk cache.
+
+Merged PRs and channels that contain a PR are cached permanently, since
+neither can change. Unmerged PRs and channels that do not contain a PR yet
+are cached for 10 minutes. Use --refresh to bypass the cache for a single
+run, or --no-cache to disable it.
+
+Commands:
+  stats        Show the cache directory, number of entries and size
+  clear        Remove every cached entry
+
+Options:
+  --json             Output stats as JSON
+  -h, --help         Show this help message
+`
+
+func runCache(args []string) int {
+	var jsonOutput bool
+
+	fs := flag.NewFlagSet("nprt cache", flag.ContinueOnError)
+	fs.BoolVar(&jsonOutput, "json", false, "Output stats as JSON")
+
+	args, code, ok := parseFlags(fs, args, cacheUsage)
+	if !ok {
+		return code
+	}
+	if len(args) != 1 {
+		fmt.Fprint(os.Stderr, cacheUsage)
+		return 2
+	}
+
+	stderrColor := config.ShouldUseColorForFile("auto", os.Stderr)
+
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), stderrColor))
+		return 1
+	}
+	store := cache.New(dir)
+
+	switch args[0] {
+	case "stats":
+		stats, err := store.Stats()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), stderrColor))
+			return 1
+		}
+		if jsonOutput {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(stats); err != nil {
+				fmt.Fprintln(os.Stderr, render.FormatError("rendering output: "+err.Error(), stderrColor))
+				return 1
+			}
+			return 0
+		}
+		printCacheStats(stats)
+		return 0
+	case "clear":
+		removed, err := store.Clear()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), stderrColor))
+			return 1
+		}
+		fmt.Printf("Removed %d cache entries from %s\n", removed, dir)
+		return 0
+	default:
+		fmt.Fprintln(os.Stderr, render.FormatError("unknown cache command "+args[0], stderrColor))
+		return 2
+	}
+}
+
+func printCacheStats(stats cache.Stats) {
+	fmt.Printf("Directory: %s\n", stats.Dir)
+	fmt.Printf("Entries:   %d (%d expired)\n", stats.Entries, stats.Expired)
+	fmt.Printf("Size:      %d bytes\n", stats.Bytes)
+
+	kinds := make([]string, 0, len(stats.ByKind))
+	for kind := range stats.ByKind {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	for _, kind := range kinds {
+		fmt.Printf("  %-10s %d\n", kind, stats.ByKind[kind])
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/thatsneat-dev/nprt/internal/cli"
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/render"
+)
+
+const configUsage = `Usage: nprt config show [--config <path>]
+
+Show the effective settings: every option after applying the config file,
+the custom channels and the channel groups. The output is itself a valid
+config file.
+
+The config file is read from $XDG_CONFIG_HOME/nprt/config.toml, or the file
+given with --config; --config= disables it. It has these tables:
+
+  [defaults]   Default values for options, keyed by flag name. Options given
+               on the command line override them.
+  [channels]   Custom NixOS/nixpkgs channels, as name = "branch"
+  [groups]     Channel groups usable in --channels and --until, as a list of
+               channels, presets and other groups
+  [repos."owner/name"]
+               Branches checked by default in another repository, as
+               channels = ["branch", ...]
+  [repos."owner/name".presets]
+               Presets usable in --channels for that repository, as a list
+               of branches and other presets
+
+Example:
+
+  [defaults]
+  color = "always"
+  hyperlinks = "never"
+  timeline-pages = 5
+  channels = "prod"
+
+  [channels]
+  tracking = "release-25.05"
+
+  [groups]
+  prod = ["nixos-25.05", "nixos-25.05-small"]
+
+  [repos."nix-community/home-manager"]
+  channels = ["master", "release-25.05"]
+
+  [repos."nix-community/home-manager".presets]
+  stable = ["release-25.05"]
+
+Options:
+  --config           Config file to read (default: $XDG_CONFIG_HOME/nprt/config.toml)
+  -h, --help         Show this help message
+`
+
+// unconfigurableFlags are the flags that cannot be set in [defaults].
+var unconfigurableFlags = map[string]bool{
+	"config":  true,
+	"version": true,
+}
+
+// configurableFlags returns a flag set with the flags of every command, which
+// are the keys accepted in [defaults].
+func configurableFlags() *flag.FlagSet {
+	var (
+		opts  options
+		check checkOptions
+		w     watchOptions
+	)
+	fs := flag.NewFlagSet("nprt config", flag.ContinueOnError)
+	opts.register(fs)
+	check.register(fs)
+	w.register(fs)
+	return fs
+}
+
+// loadConfig reads the config file named by --config in args, or the default
+// config file if it exists, and applies its [defaults] to fs. It returns nil
+// if there is no config file.
+func loadConfig(fs *flag.FlagSet, args []string) (*config.File, error) {
+	path, explicit := cli.FlagValue(fs, args, "config")
+	if !explicit {
+		var err error
+		if path, err = config.DefaultConfigPath(); err != nil {
+			return nil, nil
+		}
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := config.LoadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := applyDefaults(fs, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// applyDefaults sets the flags of fs to the values in the [defaults] table of
+// file. Keys that are flags of another command are skipped; keys that are not
+// flags of any command are an error. Array values set repeatable flags once
+// per element and are joined with commas otherwise.
+func applyDefaults(fs *flag.FlagSet, file *config.File) error {
+	known := configurableFlags()
+
+	keys := make([]string, 0, len(file.Defaults))
+	for key := range file.Defaults {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if known.Lookup(key) == nil || unconfigurableFlags[key] {
+			return fmt.Errorf("%s: unknown option %q in [defaults]", file.Path, key)
+		}
+		f := fs.Lookup(key)
+		if f == nil {
+			continue
+		}
+
+		values := file.Defaults[key]
+		if _, ok := f.Value.(*cli.StringList); !ok {
+			values = []string{strings.Join(values, ",")}
+		}
+		for _, v := range values {
+			if err := fs.Set(key, v); err != nil {
+				return fmt.Errorf("%s: invalid value %q for %s in [defaults]: %v", file.Path, v, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func runConfig(args []string) int {
+	var opts options
+
+	fs := configurableFlags()
+	args, code, ok := opts.parseFlags(fs, args, configUsage)
+	if !ok {
+		return code
+	}
+	if len(args) != 1 {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+	if args[0] != "show" {
+		stderrColor := config.ShouldUseColorForFile("auto", os.Stderr)
+		fmt.Fprintln(os.Stderr, render.FormatError("unknown config command "+args[0], stderrColor))
+		return 2
+	}
+
+	printConfig(fs, opts.file)
+	return 0
+}
+
+// printConfig prints the effective option values and the custom channels and
+// groups of file in config file syntax.
+func printConfig(fs *flag.FlagSet, file *config.File) {
+	if file != nil {
+		fmt.Printf("# Config file: %s\n", file.Path)
+	} else {
+		fmt.Println("# No config file; showing the built-in defaults")
+	}
+
+	fmt.Println("\n[defaults]")
+	fs.VisitAll(func(f *flag.Flag) {
+		if !unconfigurableFlags[f.Name] {
+			fmt.Printf("%s = %s\n", f.Name, formatFlagValue(f))
+		}
+	})
+
+	if file == nil {
+		return
+	}
+	if len(file.Channels) > 0 {
+		fmt.Println("\n[channels]")
+		for _, ch := range file.Channels {
+			fmt.Printf("%s = %s\n", ch.Name, config.QuoteTOML(ch.Branch))
+		}
+	}
+	if groups := file.GroupNames(); len(groups) > 0 {
+		fmt.Println("\n[groups]")
+		for _, name := range groups {
+			fmt.Printf("%s = %s\n", name, formatTOMLArray(file.Groups[name]))
+		}
+	}
+	for _, repo := range file.RepoNames() {
+		rc := file.ForRepo(repo)
+		table := "repos." + config.QuoteTOML(repo.String())
+		if len(rc.Channels) > 0 {
+			fmt.Printf("\n[%s]\n", table)
+			branches := make([]string, len(rc.Channels))
+			for i, ch := range rc.Channels {
+				branches[i] = ch.Branch
+			}
+			fmt.Printf("channels = %s\n", formatTOMLArray(branches))
+		}
+		if len(rc.Presets) > 0 {
+			fmt.Printf("\n[%s.presets]\n", table)
+			names := make([]string, 0, len(rc.Presets))
+			for name := range rc.Presets {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			for _, name := range names {
+				fmt.Printf("%s = %s\n", config.QuoteTOML(name), formatTOMLArray(rc.Presets[name]))
+			}
+		}
+	}
+}
+
+// formatFlagValue formats the value of f as a TOML value: booleans and
+// integers bare, repeatable flags as arrays and everything else as strings.
+func formatFlagValue(f *flag.Flag) string {
+	if list, ok := f.Value.(*cli.StringList); ok {
+		return formatTOMLArray(*list)
+	}
+	if getter, ok := f.Value.(flag.Getter); ok {
+		switch getter.Get().(type) {
+		case bool, int:
+			return f.Value.String()
+		}
+	}
+	return config.QuoteTOML(f.Value.String())
+}
+
+func formatTOMLArray(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = config.QuoteTOML(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
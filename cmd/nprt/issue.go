@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/github"
+	"github.com/thatsneat-dev/nprt/internal/render"
+)
+
+// runIssue checks the merged PRs related to an issue and renders them under
+// the issue headline, or as one JSON document. It exits like batch mode: 0 if
+// every merged PR was checked, 3 if any check hit the rate limit, and 1
+// otherwise; once every merged PR was checked, --require decides.
+func (s *session) runIssue(ctx context.Context, issue *github.NotPullRequestError, channels []config.Channel, workers int, ndjson bool) int {
+	status := s.checker.CheckIssue(ctx, issue, channels, workers)
+	for _, res := range status.PRs {
+		s.attachRateLimit(res.Status)
+	}
+
+	renderer := render.NewRenderer(os.Stdout, s.useColor, s.useHyperlinks)
+	var err error
+	switch {
+	case s.template != nil:
+		// A template renders PRs, so it is applied to each merged PR.
+		if err := s.renderTemplateResults(renderer, status.PRs); err != nil {
+			fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), s.stderrColor))
+			return 1
+		}
+	case ndjson:
+		err = renderer.RenderIssueNDJSON(status)
+	default:
+		err = renderer.RenderIssueAs(s.format, status)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, render.FormatError("rendering output: "+err.Error(), s.stderrColor))
+		return 1
+	}
+
+	if code := batchExitCode(status.PRs); code != 0 {
+		return code
+	}
+	return s.checkBatchRequirement(status.PRs)
+}
@@ -7,13 +7,17 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/thatsneat-dev/nprt/internal/cache"
 	"github.com/thatsneat-dev/nprt/internal/cli"
 	"github.com/thatsneat-dev/nprt/internal/config"
 	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/git"
 	"github.com/thatsneat-dev/nprt/internal/github"
 	"github.com/thatsneat-dev/nprt/internal/logging"
 	"github.com/thatsneat-dev/nprt/internal/render"
@@ -21,164 +25,829 @@ import (
 
 var version = "dev"
 
-const usage = `Usage: nprt [options] <PR number | PR URL>
+const usage = `Usage: nprt [options] <PR number | PR URL | commit>...
+       nprt [options] -
+       nprt watch [options] <PR number | PR URL | commit>
+       nprt cache <stats | clear>
+       nprt auth status
+       nprt config show
 
 Track which nixpkgs channels contain a given pull request.
 
+Commands:
+  watch        Re-check a PR until it reaches the given channels (see nprt watch --help)
+  cache        Show or clear the on-disk cache (see nprt cache --help)
+  auth         Show the GitHub token and rate limit status (see nprt auth --help)
+  config       Show the effective settings of the config file (see nprt config --help)
+
 Arguments:
-  PR number    A pull request number (e.g., 476497)
-  PR URL       A full GitHub PR URL (e.g., https://github.com/NixOS/nixpkgs/pull/476497)
+  PR number    A pull request number (e.g., 476497 or #476497), optionally with
+               its repository (e.g., NixOS/nixpkgs#476497)
+  PR URL       A GitHub PR or issue URL (e.g., https://github.com/NixOS/nixpkgs/pull/476497),
+               including sub-pages such as /files; the repository is taken from the URL
+  commit       A full or abbreviated commit SHA (e.g., 3f2a9c1) or commit URL;
+               the commit is checked and the PR that introduced it is shown
+  -            Read whitespace-separated PR numbers, URLs or commits from stdin
+
+With more than one PR, the results are shown as a matrix with one row per PR.
 
 Options:
-  --channels         Comma-separated list of channels to check (default: master,staging-next,nixpkgs-unstable,nixos-unstable-small,nixos-unstable)
+` + commonOptionsUsage + `  --format           Output format: table, json, markdown, csv, tsv, yaml (default: table,
+                     or json with --json)
+  --issues           Issue handling: auto, check, list (default: auto, which checks the
+                     merged related PRs of an issue when stdout is a terminal)
+  --landed           Show when the change landed in each channel that has it
+  --ndjson           Output one JSON document per line, as each PR finishes
+  --parallel         Number of PRs to check concurrently (default: 4)
+  --require          Exit 4 unless these channels contain the PR, or 5 if that is unknown:
+                     a comma-separated list of channels or presets, all, or any
+  --template         Render each PR with a Go template instead of a format
+                     (see TEMPLATES in docs/USAGE.md)
+  --template-file    Like --template, with the template read from a file
+  --wide             Show how far behind each channel is, its head commit and how old
+                     that commit is
+  --version          Print version and exit
+  -h, --help         Show this help message
+
+Environment:
+  GH_TOKEN, GITHUB_TOKEN  GitHub personal access token for higher rate limits
+                          (GH_ENTERPRISE_TOKEN, GITHUB_ENTERPRISE_TOKEN for
+                          a GitHub Enterprise Server)
+  NPRT_GITHUB_TOKEN_FILE  File containing the token
+  NPRT_API_URL            Default for --api-url
+  NPRT_WEB_URL            Default for --web-url
+
+Without these, the token is read from $CREDENTIALS_DIRECTORY/github-token,
+the gh CLI's hosts.yml, or the API host's entry of ~/.netrc.
+
+Defaults for every option, custom channels and channel groups are read from
+$XDG_CONFIG_HOME/nprt/config.toml (see nprt config --help).
+`
+
+// commonOptionsUsage documents the flags shared by all commands.
+const commonOptionsUsage = `  --channels         Comma-separated list of channels or presets to check (default: the channels downstream of the PR's base branch)
+                     Stable channels (nixos-YY.MM, nixos-YY.MM-small, nixpkgs-YY.MM-darwin, release-YY.MM)
+                     are discovered from GitHub. Presets: stable, current, previous, unstable
+  --api              GitHub API to use: auto, rest, graphql (default: auto, which uses
+                     GraphQL when a token is set and REST otherwise)
+  --api-url          GitHub API base URL (default: https://api.github.com, or
+                     <web-url>/api/v3 for a GitHub Enterprise Server)
+  --web-url          GitHub web base URL for PR URLs and links (default: https://github.com,
+                     or derived from --api-url)
   --color            Color output mode: auto, always, never (default: auto)
+  --config           Config file to read (default: $XDG_CONFIG_HOME/nprt/config.toml)
   --hyperlinks       Hyperlink mode: auto, always, never (default: auto)
+  --git-dir          Answer channel checks from a local nixpkgs checkout instead of the GitHub API
+  --git-fetch        Fetch the checked channel branches into --git-dir first
   --json             Output results as JSON
+  --max-wait         Longest wait for a GitHub rate limit to reset before failing (default: 1m)
+  --no-cache         Do not read or write the on-disk cache
+  --refresh          Ignore cached results and replace them with fresh ones
+  --repo             GitHub repository to check, as owner/name (default: the repository
+                     of the PR URLs, or NixOS/nixpkgs)
+  --retries          Number of retries for failed GitHub requests (default: 3)
   --timeline-pages   Number of timeline pages to fetch for related PRs (default: 3)
   --verbose          Show detailed progress and debug information
-  --version          Print version and exit
-  -h, --help         Show this help message
-
-Environment:
-  GITHUB_TOKEN  GitHub personal access token for higher rate limits
 `
 
 func main() {
-	os.Exit(run())
+	os.Exit(run(os.Args[1:]))
 }
 
-func run() int {
-	var (
-		channelsFlag  string
-		colorMode     string
-		hyperlinkMode string
-		jsonOutput    bool
-		timelinePages int
-		verbose       bool
-		showVersion   bool
-	)
+func run(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "watch":
+			return runWatch(args[1:])
+		case "cache":
+			return runCache(args[1:])
+		case "auth":
+			return runAuth(args[1:])
+		case "config":
+			return runConfig(args[1:])
+		}
+	}
+	return runCheck(args)
+}
+
+// hostOptions selects the GitHub instance to talk to.
+type hostOptions struct {
+	apiURL string
+	webURL string
+}
+
+// register adds the host flags to fs. NPRT_API_URL and NPRT_WEB_URL provide
+// their defaults.
+func (h *hostOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&h.apiURL, "api-url", os.Getenv("NPRT_API_URL"), "GitHub API base URL")
+	fs.StringVar(&h.webURL, "web-url", os.Getenv("NPRT_WEB_URL"), "GitHub web base URL")
+}
+
+// host returns the GitHub instance selected by the flags, github.com if
+// neither is set.
+func (h *hostOptions) host() (config.Host, error) {
+	return config.ParseHost(h.apiURL, h.webURL)
+}
+
+// options holds the flags shared by all commands.
+type options struct {
+	hostOptions
+
+	api           string
+	channels      string
+	colorMode     string
+	configPath    string
+	hyperlinkMode string
+	gitDir        string
+	gitFetch      bool
+	jsonOutput    bool
+	// jsonErrors and ndjson are set by the check command when --format=json
+	// or --ndjson ask for JSON output, so that failures are written as JSON
+	// documents like with --json.
+	jsonErrors    bool
+	ndjson        bool
+	maxWait       time.Duration
+	noCache       bool
+	refresh       bool
+	repo          string
+	retries       int
+	timelinePages int
+	verbose       bool
+
+	// file is the loaded config file, or nil if there is none.
+	file *config.File
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+	o.hostOptions.register(fs)
+	fs.StringVar(&o.api, "api", "auto", "GitHub API to use: auto, rest, graphql")
+	fs.StringVar(&o.channels, "channels", "", "Comma-separated list of channels to check")
+	fs.StringVar(&o.colorMode, "color", "auto", "Color output: auto, always, never")
+	fs.StringVar(&o.configPath, "config", "", "Config file to read")
+	fs.StringVar(&o.hyperlinkMode, "hyperlinks", "auto", "Hyperlinks: auto, always, never")
+	fs.StringVar(&o.gitDir, "git-dir", "", "Answer channel checks from a local nixpkgs checkout")
+	fs.BoolVar(&o.gitFetch, "git-fetch", false, "Fetch the channel branches into --git-dir before checking")
+	fs.BoolVar(&o.jsonOutput, "json", false, "Output results as JSON")
+	fs.DurationVar(&o.maxWait, "max-wait", github.DefaultMaxWait, "Longest wait for a GitHub rate limit to reset before failing")
+	fs.BoolVar(&o.noCache, "no-cache", false, "Do not read or write the on-disk cache")
+	fs.BoolVar(&o.refresh, "refresh", false, "Ignore cached results and replace them with fresh ones")
+	fs.StringVar(&o.repo, "repo", "", "GitHub repository to check, as owner/name")
+	fs.IntVar(&o.retries, "retries", github.DefaultRetries, "Number of retries for failed GitHub requests")
+	fs.IntVar(&o.timelinePages, "timeline-pages", github.DefaultTimelinePages, "Number of timeline pages to fetch for related PRs")
+	fs.BoolVar(&o.verbose, "verbose", false, "Show detailed progress and debug information")
+}
 
-	flag.StringVar(&channelsFlag, "channels", "", "Comma-separated list of channels to check")
-	flag.StringVar(&colorMode, "color", "auto", "Color output: auto, always, never")
-	flag.StringVar(&hyperlinkMode, "hyperlinks", "auto", "Hyperlinks: auto, always, never")
-	flag.BoolVar(&jsonOutput, "json", false, "Output results as JSON")
-	flag.IntVar(&timelinePages, "timeline-pages", github.DefaultTimelinePages, "Number of timeline pages to fetch for related PRs")
-	flag.BoolVar(&verbose, "verbose", false, "Show detailed progress and debug information")
-	flag.BoolVar(&showVersion, "version", false, "Print version and exit")
+// parseFlags loads the config file and applies its defaults to fs before
+// parsing args, so that flags on the command line override the config file.
+// If JSON output is asked for, a usage error is also written to stdout as a
+// JSON document.
+func (o *options) parseFlags(fs *flag.FlagSet, args []string, usageText string) ([]string, int, bool) {
+	file, rest, err := loadFlags(fs, args, usageText)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		if jsonOutput, ndjson := wantsJSON(fs, args); jsonOutput || ndjson {
+			writeErrorJSON(core.BatchResult{Err: &core.UsageError{Message: err.Error()}}, ndjson)
+		}
+	}
+	o.file = file
+	code, ok := flagsExitCode(err)
+	return rest, code, ok
+}
 
-	flag.Usage = func() {
-		fmt.Fprint(os.Stderr, usage)
+// parseConfigFlags is parseFlags for commands that do not take all options,
+// returning the loaded config file.
+func parseConfigFlags(fs *flag.FlagSet, args []string, usageText string) (*config.File, []string, int, bool) {
+	file, args, err := loadFlags(fs, args, usageText)
+	code, ok := flagsExitCode(err)
+	return file, args, code, ok
+}
+
+// loadFlags loads the config file into fs and parses args. Errors are
+// printed to stderr.
+func loadFlags(fs *flag.FlagSet, args []string, usageText string) (*config.File, []string, error) {
+	file, err := loadConfig(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), config.ShouldUseColorForFile("auto", os.Stderr)))
+		return nil, nil, err
 	}
+	args, err = parseFlagArgs(fs, args, usageText)
+	return file, args, err
+}
+
+// parseFlags parses args into fs, allowing flags after positional arguments.
+// It returns the positional arguments, or an exit code if parsing failed or
+// help was requested.
+func parseFlags(fs *flag.FlagSet, args []string, usageText string) ([]string, int, bool) {
+	args, err := parseFlagArgs(fs, args, usageText)
+	code, ok := flagsExitCode(err)
+	return args, code, ok
+}
 
-	if err := flag.CommandLine.Parse(cli.ReorderArgs(flag.CommandLine, os.Args[1:])); err != nil {
-		return 2
+// parseFlagArgs is parseFlags returning the error of fs.Parse, which has
+// already been printed to stderr.
+func parseFlagArgs(fs *flag.FlagSet, args []string, usageText string) ([]string, error) {
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usageText)
 	}
+	if err := fs.Parse(cli.ReorderArgs(fs, args)); err != nil {
+		return nil, err
+	}
+	return fs.Args(), nil
+}
 
-	if showVersion {
-		fmt.Printf("nprt version %s\n", version)
-		return 0
+// flagsExitCode returns the exit code for an error of parseFlagArgs: 0 when
+// help was requested and 2 for invalid flags. ok is true without an error.
+func flagsExitCode(err error) (code int, ok bool) {
+	switch {
+	case err == nil:
+		return 0, true
+	case errors.Is(err, flag.ErrHelp):
+		return 0, false
+	default:
+		return 2, false
 	}
+}
 
-	if timelinePages < 1 || timelinePages > 10 {
-		fmt.Fprintln(os.Stderr, "Error: --timeline-pages must be between 1 and 10")
-		return 2
+// wantsJSON reports whether args or the config file ask for JSON output,
+// with --json or --format=json, or for --ndjson. It is meant for errors of
+// flag parsing, which leave the flags after the error unset in fs.
+func wantsJSON(fs *flag.FlagSet, args []string) (jsonOutput, ndjson bool) {
+	value := func(name string) string {
+		if v, ok := cli.FlagValue(fs, args, name); ok {
+			return v
+		}
+		if f := fs.Lookup(name); f != nil {
+			return f.Value.String()
+		}
+		return ""
+	}
+	isTrue := func(name string) bool {
+		on, _ := strconv.ParseBool(value(name))
+		return on
+	}
+	return isTrue("json") || value("format") == "json", isTrue("ndjson")
+}
+
+// session holds the settings and clients shared by a single command run.
+type session struct {
+	opts          *options
+	useColor      bool
+	stderrColor   bool
+	useHyperlinks bool
+	log           *zap.Logger
+	host          config.Host
+	client        *github.Client
+	cache         *cache.Store
+	// format is the output format: a table, or JSON with --json.
+	format render.Format
+	// template replaces format when set with --template or --template-file.
+	template *render.Template
+	// jsonErrors writes failures to stdout as JSON documents, on one line
+	// with ndjson. jsonErrorRef is the PR being checked, once it is known.
+	jsonErrors   bool
+	jsonErrorRef *config.PRRef
+	ndjson       bool
+	// wide looks up the head commit of every channel and selects the wide
+	// table.
+	wide bool
+	// landed finds when the change landed in every present channel.
+	landed bool
+	// requireSpec is --require, parsed into require once the repository
+	// is known.
+	requireSpec string
+	require     *core.Requirement
+	// repo and checker are set by setupRepo once the PRs to check are known.
+	repo    config.Repo
+	checker *core.Checker
+}
+
+// newSession validates the shared options and sets up logging and the
+// GitHub client. It returns an exit code if the options are invalid.
+func newSession(o *options) (*session, int) {
+	s := &session{
+		opts:        o,
+		stderrColor: config.ShouldUseColorForFile(o.colorMode, os.Stderr),
+		format:      render.FormatTable,
+		jsonErrors:  o.jsonOutput || o.jsonErrors,
+		ndjson:      o.ndjson,
+	}
+	if o.jsonOutput {
+		s.format = render.FormatJSON
+	}
+
+	if o.timelinePages < 1 || o.timelinePages > 10 {
+		return nil, s.usageError("--timeline-pages must be between 1 and 10")
+	}
+	if o.retries < 0 || o.retries > 10 {
+		return nil, s.usageError("--retries must be between 0 and 10")
+	}
+	if o.maxWait < 0 {
+		return nil, s.usageError("--max-wait must not be negative")
 	}
 
 	// Compute color settings early so all errors can be styled
-	useColor, err := config.ShouldUseColor(colorMode)
+	var err error
+	if s.useColor, err = config.ShouldUseColor(o.colorMode); err != nil {
+		return nil, s.usageError(err.Error())
+	}
+	if s.useHyperlinks, err = config.ShouldUseHyperlinks(o.hyperlinkMode); err != nil {
+		return nil, s.usageError(err.Error())
+	}
+	s.log = logging.New(o.verbose)
+
+	host, err := o.host()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return 2
+		return nil, s.usageError(err.Error())
 	}
-	stderrColor := config.ShouldUseColorForFile(colorMode, os.Stderr)
-	useHyperlinks, err := config.ShouldUseHyperlinks(hyperlinkMode)
+	s.host = host
+
+	s.client = github.NewClient(findToken(host, s.log, s.stderrColor), "nprt/"+version, s.log)
+	s.client.BaseURL = host.APIURL
+	s.client.TimelinePages = o.timelinePages
+	s.client.Retry.MaxRetries = o.retries
+	s.client.Retry.MaxWait = o.maxWait
+
+	if !o.noCache {
+		if dir, err := cache.DefaultDir(); err == nil {
+			s.cache = cache.New(dir)
+			s.cache.Refresh = o.refresh
+			if !host.IsDefault() {
+				// A GitHub Enterprise Server may mirror the same
+				// repositories as github.com.
+				s.cache.Namespace = host.WebURL + "/"
+			}
+			s.client.Cache = s.cache
+		} else {
+			s.log.Debug("cache disabled", zap.Error(err))
+		}
+	}
+	// Without a disk cache, validators still save requests within this run.
+	if s.cache != nil {
+		s.client.Validators = github.NewDiskValidators(s.cache, s.log)
+	} else {
+		s.client.Validators = github.NewMemoryValidators()
+	}
+
+	switch {
+	case o.api != "auto" && o.api != "rest" && o.api != "graphql":
+		return nil, s.usageError(fmt.Sprintf("invalid --api value %q: must be auto, rest, or graphql", o.api))
+	case o.api == "graphql" && s.client.Token == "":
+		return nil, s.usageError("--api=graphql requires a GitHub token")
+	case o.gitFetch && o.gitDir == "":
+		return nil, s.usageError("--git-fetch requires --git-dir")
+	}
+
+	return s, 0
+}
+
+// setupRepo picks the repository to check for refs and sets up the checker
+// for it. It returns an exit code if the repository is invalid or its local
+// checkout cannot be opened.
+func (s *session) setupRepo(refs []config.PRRef) int {
+	repo, err := s.pickRepo(refs)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return 2
+		return s.usageError(err.Error())
 	}
+	s.repo = repo
+	s.client.Repo = repo
+	s.log.Debug("checking repository", zap.Stringer("repo", repo))
 
-	args := flag.Args()
+	var backend core.Backend = s.client
+	if s.opts.api == "graphql" || (s.opts.api == "auto" && s.client.Token != "") {
+		backend = github.NewGraphQLClient(s.client)
+	}
 
+	if s.opts.gitDir != "" {
+		checkout, err := git.Open(context.Background(), s.opts.gitDir, s.host, repo, s.log)
+		if err != nil {
+			return s.reportError(err)
+		}
+		checkout.Fetch = s.opts.gitFetch
+		// PR metadata still comes from GitHub; only ancestry is answered locally.
+		backend = core.Combine(backend, checkout)
+	}
+
+	s.checker = core.NewChecker(backend, s.log)
+	s.checker.Repo = repo
+	s.checker.Host = s.host
+	s.checker.Details = s.wide
+	s.checker.Landing = s.landed
+	return 0
+}
+
+// pickRepo returns the repository to check: --repo if given, otherwise the
+// repository of the PR URLs, or NixOS/nixpkgs. PRs of several repositories
+// cannot be checked in one run.
+func (s *session) pickRepo(refs []config.PRRef) (config.Repo, error) {
+	var repo config.Repo
+	if s.opts.repo != "" {
+		r, err := config.ParseRepo(s.opts.repo)
+		if err != nil {
+			return config.Repo{}, err
+		}
+		repo = r
+	}
+
+	for _, ref := range refs {
+		switch {
+		case ref.Repo.IsZero():
+		case repo.IsZero():
+			repo = ref.Repo
+		case repo.Is(ref.Repo):
+		case s.opts.repo != "":
+			return config.Repo{}, fmt.Errorf("%s is in %s, not in --repo %s", ref, ref.Repo, repo)
+		default:
+			return config.Repo{}, fmt.Errorf("PRs of %s and %s cannot be checked together; check them separately", repo, ref.Repo)
+		}
+	}
+
+	if repo.IsZero() {
+		return config.DefaultRepo, nil
+	}
+	return repo, nil
+}
+
+// findToken looks up the GitHub token, logging where it came from and
+// printing a warning for every token file that was refused.
+func findToken(host config.Host, log *zap.Logger, stderrColor bool) string {
+	token, warnings := config.FindGitHubToken(host)
+	for _, w := range warnings {
+		fmt.Fprintln(os.Stderr, render.FormatWarning(w, stderrColor))
+	}
+	if token.Value != "" {
+		log.Debug("using GitHub token", zap.String("source", token.Source))
+	} else {
+		log.Debug("no GitHub token found")
+	}
+	return token.Value
+}
+
+func (s *session) close() {
+	_ = s.log.Sync()
+}
+
+// usageError prints a usage error and returns exit code 2.
+func (s *session) usageError(msg string) int {
+	s.renderErrorJSON(&core.UsageError{Message: msg})
+	fmt.Fprintln(os.Stderr, render.FormatError(msg, s.stderrColor))
+	return 2
+}
+
+// parsePRArg validates the positional arguments of a command that takes a
+// single PR or commit and sets up the checker for its repository.
+func (s *session) parsePRArg(args []string, usageText string) (config.PRRef, int) {
 	if unknown := cli.HasUnknownFlags(args); unknown != "" {
-		fmt.Fprintln(os.Stderr, render.FormatError("unknown flag "+unknown, stderrColor))
-		return 2
+		return config.PRRef{}, s.usageError("unknown flag " + unknown)
 	}
 
 	if len(args) != 1 {
-		fmt.Fprint(os.Stderr, usage)
-		return 2
+		s.renderErrorJSON(&core.UsageError{Message: "expected a PR number, URL or commit"})
+		fmt.Fprint(os.Stderr, usageText)
+		return config.PRRef{}, 2
 	}
 
-	prNumber, err := config.ParsePRInput(args[0])
+	ref, err := s.host.ParsePRInput(args[0])
 	if err != nil {
-		fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), stderrColor))
-		return 2
+		return config.PRRef{}, s.usageError(err.Error())
+	}
+	if code := s.setupRepo([]config.PRRef{ref}); code != 0 {
+		return config.PRRef{}, code
+	}
+	return ref, 0
+}
+
+// catalog returns the channels selectable for input. Stable release channels
+// are only discovered when the input asks for something beyond the defaults,
+// so the common case costs no extra request. Other repositories only have
+// the channels and presets of their config file table.
+func (s *session) catalog(ctx context.Context, input string) (*config.Catalog, error) {
+	if !s.repo.IsNixpkgs() {
+		return config.NewRepoCatalog(s.repo, s.opts.file.ForRepo(s.repo)), nil
+	}
+	if !config.NeedsDiscovery(input, s.opts.file) {
+		return config.NewCatalog(nil).Extend(s.opts.file), nil
 	}
 
-	channels, err := config.ParseChannels(channelsFlag)
+	discovered, err := core.DiscoverChannels(ctx, s.client, s.cache, s.log)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), stderrColor))
-		return 2
+		return nil, err
 	}
+	return config.NewCatalog(discovered).Extend(s.opts.file), nil
+}
 
-	token := config.GetGitHubToken()
+// resolveChannels parses the --channels flag. Without --channels, it returns
+// nil so that the checker picks channels from the PR's base branch, or the
+// configured channels of repositories other than NixOS/nixpkgs.
+func (s *session) resolveChannels(ctx context.Context) ([]config.Channel, int) {
+	if s.opts.channels == "" && s.repo.IsNixpkgs() {
+		return nil, 0
+	}
 
-	// Create logger based on verbose flag
-	log := logging.New(verbose)
-	defer func() { _ = log.Sync() }()
+	catalog, err := s.catalog(ctx, s.opts.channels)
+	if err != nil {
+		return nil, s.reportError(err)
+	}
 
-	log.Debug("fetching PR", zap.Int("pr", prNumber))
+	channels, err := catalog.Parse(s.opts.channels)
+	if err != nil {
+		return nil, s.usageError(err.Error())
+	}
+	return channels, 0
+}
 
-	client := github.NewClient(token, "nprt/"+version, log)
-	client.TimelinePages = timelinePages
-	checker := core.NewChecker(client, log)
+// reportCheckError prints an error returned by a PR check and returns the
+// matching exit code. Issues get a rendered warning listing related PRs.
+func (s *session) reportCheckError(err error) int {
+	s.renderErrorJSON(err)
+
+	// NotPullRequestError gets special rendering with icons/colors/hyperlinks
+	var notPRErr *github.NotPullRequestError
+	if errors.As(err, &notPRErr) {
+		info := render.IssueWarning{
+			Number: notPRErr.Number,
+			Title:  notPRErr.Title,
+			State:  notPRErr.State,
+			URL:    notPRErr.URL,
+		}
+		info.RelatedPRs = notPRErr.RelatedPRs
+		stderrHyperlinks := config.ShouldUseHyperlinksForFile(s.opts.hyperlinkMode, os.Stderr)
+		errRenderer := render.NewRenderer(os.Stderr, s.stderrColor, stderrHyperlinks)
+		_ = errRenderer.RenderIssueWarning(info)
+		return 1
+	}
 
-	// Set up context with signal handling for clean cancellation
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer cancel()
+	return s.reportError(err)
+}
 
-	status, err := checker.CheckPR(ctx, prNumber, channels)
-	if err != nil {
-		// 403 errors (rate limit, auth failure) get a distinct exit code
-		var apiErr *github.APIError
-		if errors.As(err, &apiErr) && (apiErr.StatusCode == 403 || apiErr.StatusCode == 429) {
-			fmt.Fprintln(os.Stderr, render.FormatError(apiErr.Message, stderrColor))
-			return 3
-		}
+// reportError prints err to stderr and returns the matching exit code:
+// 3 for rate limit and auth failures, 1 for everything else.
+func (s *session) reportError(err error) int {
+	s.renderErrorJSON(err)
 
-		// NotPullRequestError gets special rendering with icons/colors/hyperlinks
-		var notPRErr *github.NotPullRequestError
-		if errors.As(err, &notPRErr) {
-			info := render.IssueWarning{
-				Number: notPRErr.Number,
-				Title:  notPRErr.Title,
-				State:  notPRErr.State,
-				URL:    notPRErr.URL,
-			}
-			info.RelatedPRs = notPRErr.RelatedPRs
-			stderrHyperlinks := config.ShouldUseHyperlinksForFile(hyperlinkMode, os.Stderr)
-			errRenderer := render.NewRenderer(os.Stderr, stderrColor, stderrHyperlinks)
-			_ = errRenderer.RenderIssueWarning(info)
-			return 1
-		}
+	// 403 errors (rate limit, auth failure) get a distinct exit code
+	var apiErr *github.APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == 403 || apiErr.StatusCode == 429) {
+		fmt.Fprintln(os.Stderr, render.FormatError(apiErr.Message, s.stderrColor))
+		return 3
+	}
+	fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), s.stderrColor))
+	return 1
+}
 
-		fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), stderrColor))
+// renderErrorJSON writes err to stdout as a JSON document if JSON output was
+// requested: the document of the failed check once the PR is known, and one
+// with only the error before. Scripts then see every failure in
+// machine-readable form; the message is still printed to stderr.
+func (s *session) renderErrorJSON(err error) {
+	if !s.jsonErrors {
+		return
+	}
+	// Only the first report of a failure is written.
+	s.jsonErrors = false
+	res := core.BatchResult{Err: err}
+	if s.jsonErrorRef != nil {
+		res = s.checker.Result(*s.jsonErrorRef, nil, err)
+	}
+	writeErrorJSON(res, s.ndjson)
+}
+
+// writeErrorJSON writes the failed result res to stdout, on one line with
+// ndjson.
+func writeErrorJSON(res core.BatchResult, ndjson bool) {
+	renderer := render.NewRenderer(os.Stdout, false, false)
+	if ndjson {
+		_ = renderer.RenderNDJSON(res)
+	} else {
+		_ = renderer.RenderResultJSON(res)
+	}
+}
+
+// attachRateLimit records the latest rate limit budget in status, so that it
+// is part of the JSON output.
+func (s *session) attachRateLimit(status *core.PRStatus) {
+	if status == nil {
+		return
+	}
+	if rl, ok := s.client.RateLimit(); ok {
+		status.RateLimit = &rl
+	}
+}
+
+// renderResult writes a single result as one line of JSON.
+func (s *session) renderResult(res core.BatchResult) int {
+	renderer := render.NewRenderer(os.Stdout, s.useColor, s.useHyperlinks)
+	if err := renderer.RenderNDJSON(res); err != nil {
+		fmt.Fprintln(os.Stderr, render.FormatError("rendering output: "+err.Error(), s.stderrColor))
 		return 1
 	}
+	return 0
+}
 
-	renderer := render.NewRenderer(os.Stdout, useColor, useHyperlinks)
+// setFormat selects the output format of the check command from --format,
+// which defaults to JSON with --json and to a table otherwise.
+func (s *session) setFormat(format string, ndjson bool) int {
+	if format == "" {
+		return 0
+	}
+	f, err := render.ParseFormat(format)
+	if err != nil {
+		return s.usageError(err.Error())
+	}
+	switch {
+	case s.opts.jsonOutput && f != render.FormatJSON:
+		return s.usageError(fmt.Sprintf("--json conflicts with --format=%s", f))
+	case ndjson && f != render.FormatJSON:
+		return s.usageError(fmt.Sprintf("--ndjson conflicts with --format=%s", f))
+	}
+	s.format = f
+	return 0
+}
+
+// setTemplate parses the template of --template or --template-file, which
+// replaces the output format. A template that does not parse is a usage
+// error.
+func (s *session) setTemplate(check *checkOptions) int {
+	if check.template == "" && check.templateFile == "" {
+		return 0
+	}
+	switch {
+	case check.template != "" && check.templateFile != "":
+		return s.usageError("--template conflicts with --template-file")
+	case check.format != "":
+		return s.usageError("--template conflicts with --format")
+	case s.opts.jsonOutput:
+		return s.usageError("--template conflicts with --json")
+	case check.ndjson:
+		return s.usageError("--template conflicts with --ndjson")
+	}
+
+	name, text := "--template", check.template
+	if check.templateFile != "" {
+		data, err := os.ReadFile(check.templateFile)
+		if err != nil {
+			return s.usageError(err.Error())
+		}
+		name, text = check.templateFile, string(data)
+	}
+	tmpl, err := render.ParseTemplate(name, text)
+	if err != nil {
+		return s.usageError(err.Error())
+	}
+	s.template = tmpl
+	return 0
+}
+
+// renderTemplateResults executes the template for every checked PR of a
+// batch and reports the PRs that could not be checked on stderr.
+func (s *session) renderTemplateResults(renderer *render.Renderer, results []core.BatchResult) error {
+	for _, res := range results {
+		if res.Err != nil {
+			fmt.Fprintln(os.Stderr, render.FormatError(res.Label()+": "+res.Err.Error(), s.stderrColor))
+			continue
+		}
+		if err := renderer.RenderTemplate(s.template, res.Status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	if jsonOutput {
-		if err := renderer.RenderJSON(status); err != nil {
-			fmt.Fprintln(os.Stderr, render.FormatError("rendering output: "+err.Error(), stderrColor))
+// renderStatus writes status to stdout in the selected format.
+func (s *session) renderStatus(status *core.PRStatus) int {
+	renderer := render.NewRenderer(os.Stdout, s.useColor, s.useHyperlinks)
+	if s.template != nil {
+		if err := renderer.RenderTemplate(s.template, status); err != nil {
+			fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), s.stderrColor))
 			return 1
 		}
-	} else {
-		if err := renderer.RenderTable(status); err != nil {
-			fmt.Fprintln(os.Stderr, render.FormatError("rendering output: "+err.Error(), stderrColor))
+		return 0
+	}
+	if s.wide && s.format == render.FormatTable {
+		if err := renderer.RenderWideTable(status); err != nil {
+			fmt.Fprintln(os.Stderr, render.FormatError("rendering output: "+err.Error(), s.stderrColor))
 			return 1
 		}
+		return 0
+	}
+	if err := renderer.RenderStatusAs(s.format, status); err != nil {
+		fmt.Fprintln(os.Stderr, render.FormatError("rendering output: "+err.Error(), s.stderrColor))
+		return 1
 	}
-
 	return 0
 }
+
+// checkOptions holds the flags of the default check command.
+type checkOptions struct {
+	format       string
+	issueMode    string
+	landed       bool
+	ndjson       bool
+	parallel     int
+	require      string
+	showVersion  bool
+	template     string
+	templateFile string
+	wide         bool
+}
+
+func (c *checkOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.format, "format", "", "Output format: table, json, markdown, csv, tsv, yaml")
+	fs.StringVar(&c.issueMode, "issues", "auto", "Issue handling: auto, check, list")
+	fs.BoolVar(&c.landed, "landed", false, "Show when the change landed in each channel")
+	fs.BoolVar(&c.ndjson, "ndjson", false, "Output one JSON document per line")
+	fs.IntVar(&c.parallel, "parallel", core.DefaultBatchWorkers, "Number of PRs to check concurrently")
+	fs.StringVar(&c.require, "require", "", "Channels that must contain the PR: a list, all or any")
+	fs.BoolVar(&c.showVersion, "version", false, "Print version and exit")
+	fs.StringVar(&c.template, "template", "", "Go template to render each PR with")
+	fs.StringVar(&c.templateFile, "template-file", "", "File containing the Go template to render each PR with")
+	fs.BoolVar(&c.wide, "wide", false, "Show the head commit and age of every channel")
+}
+
+func runCheck(args []string) int {
+	var (
+		opts  options
+		check checkOptions
+	)
+
+	fs := flag.NewFlagSet("nprt", flag.ContinueOnError)
+	opts.register(fs)
+	check.register(fs)
+
+	args, code, ok := opts.parseFlags(fs, args, usage)
+	if !ok {
+		return code
+	}
+
+	if check.showVersion {
+		fmt.Printf("nprt version %s\n", version)
+		return 0
+	}
+
+	opts.jsonErrors = check.format == string(render.FormatJSON) || check.ndjson
+	opts.ndjson = check.ndjson
+	s, code := newSession(&opts)
+	if s == nil {
+		return code
+	}
+	defer s.close()
+
+	if check.parallel < 1 || check.parallel > 16 {
+		return s.usageError("--parallel must be between 1 and 16")
+	}
+	checkIssues, err := config.ShouldCheckIssues(check.issueMode)
+	if err != nil {
+		return s.usageError(err.Error())
+	}
+	if code := s.setFormat(check.format, check.ndjson); code != 0 {
+		return code
+	}
+	if code := s.setTemplate(&check); code != 0 {
+		return code
+	}
+	s.wide = check.wide
+	s.landed = check.landed
+	s.requireSpec = check.require
+
+	// Set up context with signal handling for clean cancellation
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if isBatch(args) {
+		return s.runBatch(ctx, args, check.parallel, check.ndjson)
+	}
+
+	ref, code := s.parsePRArg(args, usage)
+	if code != 0 {
+		return code
+	}
+	s.jsonErrorRef = &ref
+
+	channels, code := s.resolveChannels(ctx)
+	if code != 0 {
+		return code
+	}
+	if code := s.resolveRequirement(ctx); code != 0 {
+		return code
+	}
+
+	s.log.Debug("fetching PR", zap.Stringer("pr", ref))
+
+	status, err := s.checker.Check(ctx, ref, channels)
+	var notPRErr *github.NotPullRequestError
+	if checkIssues && errors.As(err, &notPRErr) {
+		return s.runIssue(ctx, notPRErr, channels, check.parallel, check.ndjson)
+	}
+	if err != nil {
+		return s.reportCheckError(err)
+	}
+	s.attachRateLimit(status)
+
+	if check.ndjson {
+		code = s.renderResult(s.checker.Result(ref, status, nil))
+	} else {
+		code = s.renderStatus(status)
+	}
+	if code != 0 {
+		return code
+	}
+	return s.checkRequirement(status)
+}
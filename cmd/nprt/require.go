@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/render"
+)
+
+// Exit codes of checks that ran but do not meet --require.
+const (
+	exitNotPresent = 4
+	exitUnknown    = 5
+)
+
+// resolveRequirement parses --require into s.require. The named required
+// channels are checked in addition to the others, so that they are checked
+// even if --channels or the base branch leaves them out.
+func (s *session) resolveRequirement(ctx context.Context) int {
+	if s.requireSpec == "" {
+		return 0
+	}
+	req, names := core.ParseRequirement(s.requireSpec)
+	if names != "" {
+		catalog, err := s.catalog(ctx, names)
+		if err != nil {
+			return s.reportError(err)
+		}
+		required, err := catalog.Parse(names)
+		if err != nil {
+			return s.usageError("--require: " + err.Error())
+		}
+		s.checker.ExtraChannels = required
+		for _, ch := range required {
+			req.Channels = append(req.Channels, ch.Name)
+		}
+	}
+	s.require = &req
+	return 0
+}
+
+// requireExitCode returns the exit code for the outcome of --require and
+// explains a failure on stderr. Without --require, it is 0.
+func (s *session) requireExitCode(outcome core.ChannelStatus) int {
+	if s.require == nil {
+		return 0
+	}
+	switch outcome {
+	case core.StatusNotPresent:
+		fmt.Fprintln(os.Stderr, render.FormatError("a required channel does not contain the change", s.stderrColor))
+		return exitNotPresent
+	case core.StatusUnknown:
+		fmt.Fprintln(os.Stderr, render.FormatError("the status of a required channel is unknown", s.stderrColor))
+		return exitUnknown
+	default:
+		return 0
+	}
+}
+
+// checkRequirement returns the exit code of --require for a single status.
+func (s *session) checkRequirement(status *core.PRStatus) int {
+	if s.require == nil {
+		return 0
+	}
+	return s.requireExitCode(s.require.Evaluate(status))
+}
+
+// checkBatchRequirement returns the exit code of --require for a batch.
+func (s *session) checkBatchRequirement(results []core.BatchResult) int {
+	if s.require == nil {
+		return 0
+	}
+	return s.requireExitCode(s.require.EvaluateResults(results))
+}
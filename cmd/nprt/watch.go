@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/thatsneat-dev/nprt/internal/cli"
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/notify"
+	"github.com/thatsneat-dev/nprt/internal/render"
+	"github.com/thatsneat-dev/nprt/internal/watch"
+)
+
+const watchUsage = `Usage: nprt watch [options] <PR number | PR URL | commit>
+
+Re-check a pull request until it reaches every --until channel.
+
+On a terminal, the table is redrawn in place after every check. Otherwise,
+the table is printed once and then one line per channel status change.
+With --json, one JSON document is printed per check that changed something.
+
+Hooks given with --on-change are called for every channel status change:
+  exec:<command>     Run command through /bin/sh with NPRT_REPO, NPRT_PR,
+                     NPRT_PR_TITLE, NPRT_PR_URL, NPRT_CHANNEL, NPRT_BRANCH,
+                     NPRT_OLD_STATUS, NPRT_NEW_STATUS, NPRT_MERGE_COMMIT and
+                     NPRT_COMMIT set
+  <url>, json:<url>  POST the PR status JSON to url
+  ntfy:<url>         POST a plain-text message to an ntfy topic URL
+Failed webhooks are retried up to 3 times. Hook failures are reported but do
+not stop watching.
+
+Exits 0 once all --until channels contain the PR, or 1 on timeout.
+
+Options:
+  --until            Comma-separated list of channels or presets to wait for (default: all checked channels)
+  --interval         Delay between checks (default: 5m, minimum: 10s)
+  --jitter           Random variation applied to each delay (default: 30s)
+  --timeout          Give up after this long; 0 waits forever (default: 24h)
+  --on-change        Hook to call on every channel status change (repeatable)
+` + commonOptionsUsage + `  -h, --help         Show this help message
+`
+
+// watchOptions holds the flags of the watch command.
+type watchOptions struct {
+	until    string
+	interval time.Duration
+	jitter   time.Duration
+	timeout  time.Duration
+	onChange cli.StringList
+}
+
+func (w *watchOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&w.until, "until", "", "Comma-separated list of channels to wait for")
+	fs.DurationVar(&w.interval, "interval", watch.DefaultInterval, "Delay between checks")
+	fs.DurationVar(&w.jitter, "jitter", watch.DefaultJitter, "Random variation applied to each delay")
+	fs.DurationVar(&w.timeout, "timeout", watch.DefaultTimeout, "Give up after this long")
+	fs.Var(&w.onChange, "on-change", "Hook to call on every channel status change")
+}
+
+func runWatch(args []string) int {
+	var (
+		opts options
+		w    watchOptions
+	)
+
+	fs := flag.NewFlagSet("nprt watch", flag.ContinueOnError)
+	opts.register(fs)
+	w.register(fs)
+
+	args, code, ok := opts.parseFlags(fs, args, watchUsage)
+	if !ok {
+		return code
+	}
+
+	s, code := newSession(&opts)
+	if s == nil {
+		return code
+	}
+	defer s.close()
+	// With --json, documents are printed one per line, failures included.
+	s.ndjson = true
+
+	// Results that can still change must be re-fetched on every check.
+	s.client.NegativeCacheTTL = 0
+
+	if w.interval < watch.MinInterval {
+		return s.usageError(fmt.Sprintf("--interval must be at least %s", watch.MinInterval))
+	}
+	if w.jitter < 0 || w.jitter >= w.interval {
+		return s.usageError("--jitter must be between 0 and --interval")
+	}
+	if w.timeout < 0 {
+		return s.usageError("--timeout must not be negative")
+	}
+
+	var hooks []notify.Hook
+	for _, spec := range w.onChange {
+		hook, err := notify.ParseHook(spec)
+		if err != nil {
+			return s.usageError(err.Error())
+		}
+		hooks = append(hooks, hook)
+	}
+
+	ref, code := s.parsePRArg(args, watchUsage)
+	if code != 0 {
+		return code
+	}
+	s.jsonErrorRef = &ref
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	channels, code := s.resolveChannels(ctx)
+	if code != 0 {
+		return code
+	}
+
+	var untilNames []string
+	if w.until != "" {
+		catalog, err := s.catalog(ctx, w.until)
+		if err != nil {
+			return s.reportError(err)
+		}
+		untilChannels, err := catalog.Parse(w.until)
+		if err != nil {
+			return s.usageError(err.Error())
+		}
+		// Make sure every target is checked, even if --channels or the base
+		// branch leaves it out.
+		s.checker.ExtraChannels = untilChannels
+		for _, ch := range untilChannels {
+			untilNames = append(untilNames, ch.Name)
+		}
+	}
+
+	display := newWatchDisplay(s, untilNames)
+
+	check := func(ctx context.Context) (*core.PRStatus, error) {
+		return s.checker.Check(ctx, ref, channels)
+	}
+
+	status, err := watch.Run(ctx, check, watch.Options{
+		Interval:  w.interval,
+		Jitter:    w.jitter,
+		Timeout:   w.timeout,
+		Until:     untilNames,
+		RateLimit: s.client.RateLimit,
+		OnUpdate: func(prev, cur *core.PRStatus) {
+			changes := core.DiffChannels(prev, cur)
+			display.update(prev, cur, changes, w.interval)
+			for _, err := range notify.Dispatch(ctx, hooks, cur, changes, s.log) {
+				fmt.Fprintln(os.Stderr, render.FormatError(err.Error(), s.stderrColor))
+			}
+		},
+		OnRetry: func(err error, wait time.Duration) {
+			fmt.Fprintln(os.Stderr, render.FormatError(
+				fmt.Sprintf("%s (retrying in %s)", err, wait.Round(time.Second)), s.stderrColor))
+		},
+	}, s.log)
+	if err != nil {
+		if errors.Is(err, watch.ErrTimeout) {
+			return s.reportError(fmt.Errorf("%w after %s", err, w.timeout))
+		}
+		return s.reportCheckError(err)
+	}
+
+	display.done(status)
+	return 0
+}
+
+// watchDisplay renders watch progress: a live table on a terminal, change
+// lines otherwise, or one JSON document per change.
+type watchDisplay struct {
+	s     *session
+	until []string
+	live  *render.LiveView
+}
+
+func newWatchDisplay(s *session, until []string) *watchDisplay {
+	d := &watchDisplay{s: s, until: until}
+	if !s.opts.jsonOutput && config.IsTerminal() {
+		d.live = render.NewLiveView(os.Stdout)
+	}
+	return d
+}
+
+func (d *watchDisplay) update(prev, cur *core.PRStatus, changes []core.ChannelChange, interval time.Duration) {
+	now := time.Now()
+
+	switch {
+	case d.s.opts.jsonOutput:
+		if prev == nil || len(changes) > 0 {
+			d.s.attachRateLimit(cur)
+			data, err := json.Marshal(cur)
+			if err == nil {
+				fmt.Println(string(data))
+			}
+		}
+	case d.live != nil:
+		var buf bytes.Buffer
+		renderer := render.NewRenderer(&buf, d.s.useColor, d.s.useHyperlinks)
+		_ = renderer.RenderTable(cur)
+		_ = renderer.RenderWatchFooter(now, interval, d.until)
+		_ = d.live.Redraw(buf.Bytes())
+	default:
+		renderer := render.NewRenderer(os.Stdout, d.s.useColor, d.s.useHyperlinks)
+		if prev == nil {
+			_ = renderer.RenderTable(cur)
+			fmt.Println()
+			return
+		}
+		_ = renderer.RenderChanges(now, changes)
+	}
+}
+
+// done redraws the final state without the "next check" hint.
+func (d *watchDisplay) done(status *core.PRStatus) {
+	if d.live == nil {
+		return
+	}
+	var buf bytes.Buffer
+	renderer := render.NewRenderer(&buf, d.s.useColor, d.s.useHyperlinks)
+	_ = renderer.RenderTable(status)
+	_ = renderer.RenderWatchFooter(time.Now(), 0, d.until)
+	_ = d.live.Redraw(buf.Bytes())
+}
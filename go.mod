@@ -2,6 +2,9 @@ module github.com/thatsneat-dev/nprt
 
 go 1.25.5
 
-require go.uber.org/zap v1.27.1
+require (
+	github.com/pelletier/go-toml/v2 v2.4.3
+	go.uber.org/zap v1.27.1
+)
 
 require go.uber.org/multierr v1.10.0 // indirect
@@ -0,0 +1,152 @@
+// Package cache provides a small file-backed key/value store rooted in the
+// user's XDG cache directory.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AppName is the directory name used under the user's cache directory.
+const AppName = "nprt"
+
+// Store persists JSON-encoded values on disk, one file per key.
+type Store struct {
+	dir string
+
+	// Now returns the current time; it is overridable for tests.
+	Now func() time.Time
+
+	// Refresh makes every Get miss while Put still writes, so all cached
+	// values are replaced with fresh ones.
+	Refresh bool
+
+	// Namespace is prepended to every key, so that stores for different
+	// GitHub hosts share a directory without sharing entries.
+	Namespace string
+}
+
+// entry is the on-disk representation of a cached value.
+type entry struct {
+	Key string `json:"key"`
+	// Kind is the first segment of the key without the namespace, e.g.
+	// "pulls", which groups entries in Stats.
+	Kind    string          `json:"kind,omitempty"`
+	Expires time.Time       `json:"expires,omitzero"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// DefaultDir returns the nprt cache directory, honoring XDG_CACHE_HOME and
+// falling back to the platform default user cache directory.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, AppName), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine cache directory: %w", err)
+	}
+	return filepath.Join(dir, AppName), nil
+}
+
+// New creates a Store rooted at dir. The directory is created lazily on
+// the first write.
+func New(dir string) *Store {
+	return &Store{dir: dir, Now: time.Now}
+}
+
+// Dir returns the directory the store writes to.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get decodes the value stored under key into v. It returns false if the
+// key is missing, expired, or cannot be decoded, or if Refresh is set.
+func (s *Store) Get(key string, v any) bool {
+	if s.Refresh {
+		return false
+	}
+	key = s.Namespace + key
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return false
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Key != key {
+		return false
+	}
+	if !e.Expires.IsZero() && !s.Now().Before(e.Expires) {
+		return false
+	}
+
+	return json.Unmarshal(e.Value, v) == nil
+}
+
+// Put stores v under key. A ttl of zero keeps the value until it is
+// explicitly removed.
+func (s *Store) Put(key string, v any, ttl time.Duration) error {
+	kind, _, _ := strings.Cut(key, "/")
+	key = s.Namespace + key
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache value: %w", err)
+	}
+
+	e := entry{Key: key, Kind: kind, Value: value}
+	if ttl > 0 {
+		e.Expires = s.Now().Add(ttl)
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent readers never observe
+	// a partially written entry.
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the value stored under key, if any.
+func (s *Store) Delete(key string) error {
+	err := os.Remove(s.path(s.Namespace + key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete cache entry: %w", err)
+	}
+	return nil
+}
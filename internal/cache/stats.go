@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Stats summarizes the contents of a Store.
+type Stats struct {
+	Dir     string         `json:"dir"`
+	Entries int            `json:"entries"`
+	Expired int            `json:"expired"`
+	Bytes   int64          `json:"bytes"`
+	ByKind  map[string]int `json:"by_kind"`
+}
+
+// Stats scans the store directory. Entries are grouped by the first segment
+// of their key without the namespace, e.g. "pulls" or "compare", whichever
+// store wrote them. A missing directory is an empty store.
+func (s *Store) Stats() (Stats, error) {
+	stats := Stats{Dir: s.dir, ByKind: make(map[string]int)}
+
+	files, err := s.entryFiles()
+	if err != nil {
+		return stats, err
+	}
+
+	now := s.Now()
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var e entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			continue
+		}
+
+		stats.Entries++
+		stats.Bytes += info.Size()
+		kind := e.Kind
+		if kind == "" {
+			// Entries written before kinds were stored.
+			kind, _, _ = strings.Cut(strings.TrimPrefix(e.Key, s.Namespace), "/")
+		}
+		stats.ByKind[kind]++
+		if !e.Expires.IsZero() && !now.Before(e.Expires) {
+			stats.Expired++
+		}
+	}
+	return stats, nil
+}
+
+// Clear removes every entry from the store and returns how many were removed.
+func (s *Store) Clear() (int, error) {
+	files, err := s.entryFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, path := range files {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to delete cache entry: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// entryFiles lists the entry files in the store directory.
+func (s *Store) entryFiles() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+	return files, nil
+}
@@ -70,6 +70,49 @@ func ReorderArgs(fs *flag.FlagSet, args []string) []string {
 	return append(flags, positionals...)
 }
 
+// FlagValue returns the value of the flag name in args without parsing the
+// other flags, so that it can be acted on before fs.Parse runs. The values
+// of other known flags are skipped, and parsing stops at "--". As with
+// fs.Parse, the last occurrence wins, and a boolean flag without a value is
+// "true". It returns false if the flag is not given.
+func FlagValue(fs *flag.FlagSet, args []string, name string) (string, bool) {
+	var (
+		value string
+		found bool
+	)
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			break
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			continue
+		}
+
+		flagName, hasValue := ParseFlagName(a)
+		f := fs.Lookup(flagName)
+		switch {
+		case f == nil:
+			continue
+		case isBoolFlag(f) && !hasValue:
+			if flagName == name {
+				value, found = "true", true
+			}
+		case hasValue:
+			if flagName == name {
+				_, value, _ = strings.Cut(a, "=")
+				found = true
+			}
+		case i+1 < len(args):
+			i++
+			if flagName == name {
+				value, found = args[i], true
+			}
+		}
+	}
+	return value, found
+}
+
 // HasUnknownFlags checks remaining args for unrecognized flags.
 func HasUnknownFlags(args []string) string {
 	for _, a := range args {
@@ -85,3 +128,16 @@ func HasUnknownFlags(args []string) string {
 	}
 	return ""
 }
+
+// StringList is a flag.Value that collects every occurrence of a repeatable
+// flag.
+type StringList []string
+
+func (l *StringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *StringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
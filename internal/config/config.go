@@ -5,8 +5,6 @@ package config
 import (
 	"fmt"
 	"os"
-	"regexp"
-	"strconv"
 	"strings"
 )
 
@@ -38,94 +36,19 @@ func AvailableChannelNames() string {
 type Channel struct {
 	Name   string
 	Branch string
-}
-
-// prURLRegex matches GitHub PR URLs for the NixOS/nixpkgs repository.
-var prURLRegex = regexp.MustCompile(`^https://github\.com/NixOS/nixpkgs/pull/(\d+)/?$`)
-
-// issueURLRegex matches GitHub issue URLs for the NixOS/nixpkgs repository.
-var issueURLRegex = regexp.MustCompile(`^https://github\.com/NixOS/nixpkgs/issues/(\d+)/?$`)
-
-// ParsePRInput parses a PR number or GitHub PR/issue URL and returns the number.
-// Issue URLs are accepted so that the user gets helpful error messages with related PRs.
-func ParsePRInput(input string) (int, error) {
-	input = strings.TrimSpace(input)
-
-	if num, err := strconv.Atoi(input); err == nil {
-		if num <= 0 {
-			return 0, fmt.Errorf("PR number must be a positive integer")
-		}
-		return num, nil
-	}
-
-	matches := prURLRegex.FindStringSubmatch(input)
-	if matches != nil {
-		num, _ := strconv.Atoi(matches[1])
-		return num, nil
-	}
-
-	matches = issueURLRegex.FindStringSubmatch(input)
-	if matches != nil {
-		num, _ := strconv.Atoi(matches[1])
-		return num, nil
-	}
-
-	return 0, fmt.Errorf("invalid PR input: must be a number or https://github.com/NixOS/nixpkgs/pull/{number}")
+	// Release is the stable release (e.g. "25.05") the channel belongs to,
+	// or empty for unstable channels.
+	Release string
 }
 
 // ParseChannels parses a comma-separated list of channel names and returns
 // matching channels. Returns an error if any names are unknown.
-// Returns all defaults if input is empty.
-func ParseChannels(input string) ([]Channel, error) {
-	if input == "" {
-		return GetDefaultChannels(), nil
-	}
-
-	var requested []string
-	for _, part := range strings.Split(input, ",") {
-		name := strings.TrimSpace(part)
-		if name != "" {
-			requested = append(requested, name)
-		}
-	}
-
-	if len(requested) == 0 {
-		return GetDefaultChannels(), nil
-	}
-
-	valid := make(map[string]bool)
-	for _, ch := range defaultChannels {
-		valid[ch.Name] = true
-	}
-
-	var unknown []string
-	for _, name := range requested {
-		if !valid[name] {
-			unknown = append(unknown, name)
-		}
-	}
-
-	if len(unknown) > 0 {
-		return nil, fmt.Errorf("unknown channels: %s; available: %s", strings.Join(unknown, ", "), AvailableChannelNames())
-	}
-
-	seen := make(map[string]bool)
-	var channels []Channel
-	for _, ch := range defaultChannels {
-		for _, name := range requested {
-			if ch.Name == name && !seen[name] {
-				channels = append(channels, ch)
-				seen[name] = true
-			}
-		}
-	}
-
-	return channels, nil
-}
-
-// GetGitHubToken returns the GITHUB_TOKEN environment variable.
-func GetGitHubToken() string {
-	return os.Getenv("GITHUB_TOKEN")
+// Returns all defaults if input is empty. Only the default channels, the
+// "unstable" preset and the custom channels and groups of f are known; use a
+// Catalog with discovered release channels to accept stable channels. f may
+// be nil.
+func ParseChannels(input string, f *File) ([]Channel, error) {
+	return NewCatalog(nil).Extend(f).Parse(input)
 }
 
 // IsTerminal returns true if stdout is connected to a terminal.
@@ -160,6 +83,23 @@ func ShouldUseHyperlinks(hyperlinkMode string) (bool, error) {
 	}
 }
 
+// ShouldCheckIssues determines whether the merged pull requests related to
+// an issue are checked instead of only listed, based on the issue mode
+// setting. In auto mode they are checked when stdout is a terminal, so that
+// scripts keep getting an error for issue numbers.
+func ShouldCheckIssues(issueMode string) (bool, error) {
+	switch issueMode {
+	case "check":
+		return true, nil
+	case "list":
+		return false, nil
+	case "auto", "":
+		return IsTerminal(), nil
+	default:
+		return false, fmt.Errorf("invalid issue mode %q: must be auto, check, or list", issueMode)
+	}
+}
+
 // ShouldUseHyperlinksForFile determines if OSC 8 hyperlinks should be used
 // for a specific file descriptor. Hyperlinks are independent of color support.
 func ShouldUseHyperlinksForFile(hyperlinkMode string, f *os.File) bool {
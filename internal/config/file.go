@@ -0,0 +1,297 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ConfigFileName is the name of the config file under the nprt config
+// directory.
+const ConfigFileName = "config.toml"
+
+// File is a parsed nprt config file. It has these tables:
+//
+//	[defaults]                    flag name = value, applied before the command-line flags
+//	[channels]                    custom NixOS/nixpkgs channel name = branch
+//	[groups]                      group name = list of channels, presets and other groups
+//	[repos."owner/name"]          channels = list of branches checked by default
+//	[repos."owner/name".presets]  preset name = list of branches and other presets
+type File struct {
+	Path string
+	// Defaults maps flag names to their values. Scalars have one value;
+	// arrays have one value per element.
+	Defaults map[string][]string
+	// Channels are the custom channels, in the order they were defined.
+	Channels []Channel
+	// Groups maps group names to their members.
+	Groups map[string][]string
+	// Repos holds the channels of other repositories, keyed by the lower
+	// case "owner/name".
+	Repos map[string]*RepoConfig
+}
+
+// DefaultConfigPath returns the path of the nprt config file, honoring
+// XDG_CONFIG_HOME and falling back to the platform default user config
+// directory.
+func DefaultConfigPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "nprt", ConfigFileName), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine config directory: %w", err)
+	}
+	return filepath.Join(dir, "nprt", ConfigFileName), nil
+}
+
+// LoadFile reads and parses the config file at path.
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	f, err := ParseFile(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	f.Path = path
+	return f, nil
+}
+
+// ParseFile parses the contents of a config file. Custom channels and groups
+// are checked for names that clash with built-in channels and presets, and
+// groups for cycles. Unknown group members are only reported when a group is
+// used, since stable channels are discovered on demand.
+func ParseFile(data string) (*File, error) {
+	entries, err := parseTOML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{
+		Defaults: make(map[string][]string),
+		Groups:   make(map[string][]string),
+		Repos:    make(map[string]*RepoConfig),
+	}
+	for _, e := range entries {
+		if len(e.table) > 0 && e.table[0] == "repos" {
+			if err := f.parseRepoEntry(e); err != nil {
+				return nil, fmt.Errorf("line %d: %w", e.line, err)
+			}
+			continue
+		}
+
+		switch strings.Join(e.table, ".") {
+		case "defaults":
+			f.Defaults[e.key] = e.values
+		case "channels":
+			if err := checkCustomName(e.key); err != nil {
+				return nil, fmt.Errorf("line %d: channel %w", e.line, err)
+			}
+			if e.array || strings.TrimSpace(e.values[0]) == "" {
+				return nil, fmt.Errorf("line %d: channel %q must be set to a branch name", e.line, e.key)
+			}
+			f.Channels = append(f.Channels, Channel{Name: e.key, Branch: strings.TrimSpace(e.values[0])})
+		case "groups":
+			if err := checkCustomName(e.key); err != nil {
+				return nil, fmt.Errorf("line %d: group %w", e.line, err)
+			}
+			var members []string
+			for _, v := range e.values {
+				members = append(members, splitChannelList(v)...)
+			}
+			if len(members) == 0 {
+				return nil, fmt.Errorf("line %d: group %q has no channels", e.line, e.key)
+			}
+			f.Groups[e.key] = members
+		case "":
+			return nil, fmt.Errorf("line %d: key %q must be in a [defaults], [channels] or [groups] table", e.line, e.key)
+		default:
+			return nil, fmt.Errorf("line %d: unknown table [%s]", e.line, strings.Join(e.table, "."))
+		}
+	}
+
+	for _, ch := range f.Channels {
+		if _, ok := f.Groups[ch.Name]; ok {
+			return nil, fmt.Errorf("%q is defined as both a channel and a group", ch.Name)
+		}
+	}
+	for _, name := range f.GroupNames() {
+		if err := checkCycle("group", f.Groups, name, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, rc := range f.Repos {
+		for name := range rc.Presets {
+			if err := checkCycle(rc.Repo.String()+" preset", rc.Presets, name, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return f, nil
+}
+
+// parseRepoEntry adds an entry of a [repos."owner/name"] or
+// [repos."owner/name".presets] table.
+func (f *File) parseRepoEntry(e tomlEntry) error {
+	if len(e.table) < 2 || len(e.table) > 3 || (len(e.table) == 3 && e.table[2] != "presets") {
+		return fmt.Errorf("unknown table [%s]; use [repos.\"owner/name\"] or [repos.\"owner/name\".presets]", strings.Join(e.table, "."))
+	}
+	repo, err := ParseRepo(e.table[1])
+	if err != nil {
+		return err
+	}
+	if repo.IsNixpkgs() {
+		return fmt.Errorf("channels of %s are built in; use the [channels] and [groups] tables to add more", repo)
+	}
+
+	rc, ok := f.Repos[repoKey(repo)]
+	if !ok {
+		rc = &RepoConfig{Repo: repo, Presets: make(map[string][]string)}
+		f.Repos[repoKey(repo)] = rc
+	}
+
+	var names []string
+	for _, v := range e.values {
+		names = append(names, splitChannelList(v)...)
+	}
+
+	if len(e.table) == 2 {
+		if e.key != "channels" {
+			return fmt.Errorf("unknown key %q for %s; only channels is allowed", e.key, repo)
+		}
+		for _, name := range names {
+			rc.Channels = append(rc.Channels, Channel{Name: name, Branch: name})
+		}
+		return nil
+	}
+
+	if len(names) == 0 {
+		return fmt.Errorf("%s preset %q has no channels", repo, e.key)
+	}
+	rc.Presets[e.key] = names
+	return nil
+}
+
+// checkCustomName rejects names that would shadow a default channel, a
+// preset or a stable channel, or that cannot be used in --channels.
+func checkCustomName(name string) error {
+	switch {
+	case strings.ContainsAny(name, ", \t"):
+		return fmt.Errorf("name %q must not contain commas or spaces", name)
+	case isDefaultChannel(name):
+		return fmt.Errorf("name %q is already a default channel", name)
+	case channelPresets[name] != nil:
+		return fmt.Errorf("name %q is already a preset", name)
+	case releaseBranchRegex.MatchString(name):
+		return fmt.Errorf("name %q is already a stable channel", name)
+	}
+	return nil
+}
+
+// checkCycle reports a group or preset that directly or indirectly contains
+// itself.
+func checkCycle(kind string, groups map[string][]string, name string, path []string) error {
+	for i, p := range path {
+		if p == name {
+			return fmt.Errorf("%s %q contains itself: %s", kind, name, strings.Join(append(path[i:], name), " -> "))
+		}
+	}
+	for _, member := range groups[name] {
+		if _, ok := groups[member]; ok {
+			if err := checkCycle(kind, groups, member, append(path, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ForRepo returns the channels configured for repo, or nil if there are
+// none.
+func (f *File) ForRepo(repo Repo) *RepoConfig {
+	if f == nil {
+		return nil
+	}
+	return f.Repos[repoKey(repo)]
+}
+
+// RepoNames returns the configured repositories in sorted order.
+func (f *File) RepoNames() []Repo {
+	if f == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(f.Repos))
+	for key := range f.Repos {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	repos := make([]Repo, len(keys))
+	for i, key := range keys {
+		repos[i] = f.Repos[key].Repo
+	}
+	return repos
+}
+
+// GroupNames returns the names of the groups in sorted order.
+func (f *File) GroupNames() []string {
+	if f == nil {
+		return nil
+	}
+	names := make([]string, 0, len(f.Groups))
+	for name := range f.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// expandGroups replaces every group in names by its members, recursively.
+func (f *File) expandGroups(names []string) []string {
+	if f == nil {
+		return names
+	}
+	return expandGroups(f.Groups, names)
+}
+
+// expandGroups replaces every entry of names that is a key of groups by its
+// members, recursively. groups must not contain cycles.
+func expandGroups(groups map[string][]string, names []string) []string {
+	if len(groups) == 0 {
+		return names
+	}
+	var out []string
+	for _, name := range names {
+		if members, ok := groups[name]; ok {
+			out = append(out, expandGroups(groups, members)...)
+			continue
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+// hasChannel reports whether name is one of the custom channels.
+func (f *File) hasChannel(name string) bool {
+	if f == nil {
+		return false
+	}
+	for _, ch := range f.Channels {
+		if ch.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isDefaultChannel(name string) bool {
+	for _, ch := range defaultChannels {
+		if ch.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Host is a GitHub instance: github.com or a GitHub Enterprise Server.
+type Host struct {
+	// APIURL is the base URL of the REST API, e.g. https://api.github.com
+	// or https://github.example.com/api/v3.
+	APIURL string
+	// WebURL is the base URL of web pages, e.g. https://github.com.
+	WebURL string
+}
+
+// DefaultHost is github.com.
+var DefaultHost = Host{APIURL: "https://api.github.com", WebURL: "https://github.com"}
+
+// gheAPIPath is the path of the REST API on GitHub Enterprise Server.
+const gheAPIPath = "/api/v3"
+
+// ParseHost returns the host with the given API and web URLs. Either may be
+// empty, in which case it is derived from the other: a GitHub Enterprise
+// Server at https://github.example.com serves its API under /api/v3. Both
+// empty is github.com.
+func ParseHost(apiURL, webURL string) (Host, error) {
+	var err error
+	if apiURL, err = normalizeBaseURL("API", apiURL); err != nil {
+		return Host{}, err
+	}
+	if webURL, err = normalizeBaseURL("web", webURL); err != nil {
+		return Host{}, err
+	}
+
+	switch {
+	case apiURL == "" && webURL == "":
+		return DefaultHost, nil
+	case apiURL == "":
+		if webURL == DefaultHost.WebURL {
+			return DefaultHost, nil
+		}
+		apiURL = webURL + gheAPIPath
+	case webURL == "":
+		switch {
+		case apiURL == DefaultHost.APIURL:
+			webURL = DefaultHost.WebURL
+		case strings.HasSuffix(apiURL, gheAPIPath):
+			webURL = strings.TrimSuffix(apiURL, gheAPIPath)
+		default:
+			u, _ := url.Parse(apiURL)
+			webURL = u.Scheme + "://" + u.Host
+		}
+	}
+	return Host{APIURL: apiURL, WebURL: webURL}, nil
+}
+
+// normalizeBaseURL checks that s is an http or https URL without query or
+// fragment and removes its trailing slashes.
+func normalizeBaseURL(kind, s string) (string, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "/")
+	if s == "" {
+		return "", nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid %s URL %q: must be an http or https URL such as https://github.example.com", kind, s)
+	}
+	return s, nil
+}
+
+// IsDefault reports whether h is github.com.
+func (h Host) IsDefault() bool {
+	return h == DefaultHost
+}
+
+// Name returns the host name of the web URL, e.g. "github.com".
+func (h Host) Name() string {
+	u, err := url.Parse(h.WebURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// APIName returns the host name of the API URL, e.g. "api.github.com".
+func (h Host) APIName() string {
+	u, err := url.Parse(h.APIURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// GraphQLURL returns the GraphQL endpoint: /graphql below the API URL on
+// github.com, and /api/graphql on GitHub Enterprise Server.
+func (h Host) GraphQLURL() string {
+	if strings.HasSuffix(h.APIURL, gheAPIPath) {
+		return strings.TrimSuffix(h.APIURL, gheAPIPath) + "/api/graphql"
+	}
+	return h.APIURL + "/graphql"
+}
+
+// PullURL returns the web URL of pull request number in repo, given as
+// "owner/name". Results recorded without a repository belong to
+// NixOS/nixpkgs.
+func (h Host) PullURL(repo string, number int) string {
+	r, err := ParseRepo(repo)
+	if err != nil {
+		r = DefaultRepo
+	}
+	return fmt.Sprintf("%s/%s/pull/%d", h.WebURL, r, number)
+}
+
+// CommitURL returns the web URL of commit sha in repo, given as
+// "owner/name".
+func (h Host) CommitURL(repo string, sha string) string {
+	r, err := ParseRepo(repo)
+	if err != nil {
+		r = DefaultRepo
+	}
+	return fmt.Sprintf("%s/%s/commit/%s", h.WebURL, r, sha)
+}
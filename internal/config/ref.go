@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RefKind says what a PRRef points to.
+type RefKind string
+
+const (
+	// RefPR is a pull request. Bare numbers are PRs until GitHub says
+	// otherwise.
+	RefPR RefKind = "pr"
+	// RefIssue is an issue, given by its URL.
+	RefIssue RefKind = "issue"
+	// RefCommit is a commit, given by its SHA or URL.
+	RefCommit RefKind = "commit"
+)
+
+// PRRef is a pull request given on the command line, either by number or by
+// a commit it introduced.
+type PRRef struct {
+	Kind RefKind
+	// Repo is the repository named by a URL or an owner/name#N reference, or
+	// zero for a bare number or commit.
+	Repo   Repo
+	Number int
+	// Commit is the full or abbreviated SHA of a commit given instead of a
+	// PR number, in lower case.
+	Commit string
+}
+
+// String returns "#N" for a PR or issue number and the commit otherwise.
+func (r PRRef) String() string {
+	if r.Commit != "" {
+		return r.Commit
+	}
+	return "#" + strconv.Itoa(r.Number)
+}
+
+// ParsePRInput parses a PR number, commit SHA or github.com pull request,
+// issue or commit URL. See Host.ParsePRInput.
+func ParsePRInput(input string) (PRRef, error) {
+	return DefaultHost.ParsePRInput(input)
+}
+
+// shaRegex matches full and abbreviated commit SHAs. Inputs made only of
+// digits are PR numbers.
+var shaRegex = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// ParsePRInput parses the ways a PR, issue or commit is usually copied:
+//
+//	123, #123                    PR number
+//	NixOS/nixpkgs#123            PR number in a repository
+//	3f2a9c1, 3f2a9c1e5b7d...     abbreviated or full commit SHA
+//	https://github.com/o/r/...   pull request, issue or commit URL of h
+//
+// URLs may use http, a www. prefix, a query string, a fragment and point to
+// a sub-page such as /pull/123/files or /pull/123/commits/<sha>. The
+// repository is taken from URLs and owner/name#N references.
+func (h Host) ParsePRInput(input string) (PRRef, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return PRRef{}, fmt.Errorf("empty PR input")
+	}
+
+	if num, err := strconv.Atoi(input); err == nil {
+		if num <= 0 {
+			return PRRef{}, fmt.Errorf("invalid PR input %q: PR number must be a positive integer", input)
+		}
+		return PRRef{Kind: RefPR, Number: num}, nil
+	}
+
+	if shaRegex.MatchString(input) {
+		return PRRef{Kind: RefCommit, Commit: strings.ToLower(input)}, nil
+	}
+
+	if strings.Contains(input, "://") {
+		ref, err := h.parseURL(input)
+		if err != nil {
+			return PRRef{}, fmt.Errorf("invalid PR input %q: %w", input, err)
+		}
+		return ref, nil
+	}
+
+	if repo, number, ok := strings.Cut(input, "#"); ok {
+		ref, err := parseShorthand(repo, number)
+		if err != nil {
+			return PRRef{}, fmt.Errorf("invalid PR input %q: %w", input, err)
+		}
+		return ref, nil
+	}
+
+	if host, _, _ := strings.Cut(input, "/"); sameHost(host, h.Name()) {
+		return PRRef{}, fmt.Errorf("invalid PR input %q: URLs must start with https://", input)
+	}
+	return PRRef{}, fmt.Errorf("invalid PR input %q: must be a number, #number, owner/repo#number, a commit SHA or a URL such as %s",
+		input, h.PullURLPattern())
+}
+
+// parseShorthand parses the parts of "#N" and "owner/name#N" around the "#".
+func parseShorthand(repo, number string) (PRRef, error) {
+	num, err := strconv.Atoi(number)
+	if err != nil || num <= 0 {
+		return PRRef{}, fmt.Errorf("%q after # is not a PR number", number)
+	}
+	ref := PRRef{Kind: RefPR, Number: num}
+	if repo != "" {
+		if ref.Repo, err = ParseRepo(repo); err != nil {
+			return PRRef{}, err
+		}
+	}
+	return ref, nil
+}
+
+// parseURL parses a pull request, issue or commit URL of h. The query string
+// and fragment are ignored.
+func (h Host) parseURL(input string) (PRRef, error) {
+	u, err := url.Parse(input)
+	if err != nil {
+		return PRRef{}, fmt.Errorf("malformed URL")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return PRRef{}, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+
+	web, _ := url.Parse(h.WebURL)
+	if !sameHost(u.Host, web.Host) {
+		if h.IsDefault() {
+			return PRRef{}, fmt.Errorf("URL is on %s, not %s; use --web-url for a GitHub Enterprise Server", u.Host, web.Host)
+		}
+		return PRRef{}, fmt.Errorf("URL is on %s, not %s", u.Host, web.Host)
+	}
+	path, ok := strings.CutPrefix(u.Path, web.Path+"/")
+	if !ok {
+		return PRRef{}, fmt.Errorf("URL is not below %s", h.WebURL)
+	}
+
+	// owner/name/kind/id, followed by an optional sub-page.
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 4 {
+		return PRRef{}, fmt.Errorf("URL does not point to a pull request, issue or commit")
+	}
+	repo, err := ParseRepo(parts[0] + "/" + parts[1])
+	if err != nil {
+		return PRRef{}, err
+	}
+	// .diff and .patch views share the URL of the page.
+	id := strings.TrimSuffix(strings.TrimSuffix(parts[3], ".diff"), ".patch")
+
+	switch parts[2] {
+	case "pull", "issues":
+		num, err := strconv.Atoi(id)
+		if err != nil || num <= 0 {
+			return PRRef{}, fmt.Errorf("%q in URL is not a PR or issue number", parts[3])
+		}
+		kind := RefPR
+		if parts[2] == "issues" {
+			kind = RefIssue
+		}
+		return PRRef{Kind: kind, Repo: repo, Number: num}, nil
+	case "commit", "commits":
+		if !shaRegex.MatchString(id) {
+			return PRRef{}, fmt.Errorf("%q in URL is not a commit SHA", parts[3])
+		}
+		return PRRef{Kind: RefCommit, Repo: repo, Commit: strings.ToLower(id)}, nil
+	default:
+		return PRRef{}, fmt.Errorf("URL does not point to a pull request, issue or commit")
+	}
+}
+
+// sameHost reports whether two host names are the same, ignoring case and a
+// www. prefix.
+func sameHost(a, b string) bool {
+	a = strings.TrimPrefix(strings.ToLower(a), "www.")
+	b = strings.TrimPrefix(strings.ToLower(b), "www.")
+	return a != "" && a == b
+}
+
+// PullURLPattern describes the pull request URLs of h for error messages.
+func (h Host) PullURLPattern() string {
+	return h.WebURL + "/{owner}/{repo}/pull/{number}"
+}
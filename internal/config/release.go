@@ -0,0 +1,300 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// releaseBranchRegex matches the branches that make up a stable release:
+// nixos-YY.MM, nixos-YY.MM-small, nixpkgs-YY.MM-darwin and release-YY.MM.
+var releaseBranchRegex = regexp.MustCompile(`^(?:nixos-(\d{2}\.\d{2})(?:-small)?|nixpkgs-(\d{2}\.\d{2})-darwin|release-(\d{2}\.\d{2}))$`)
+
+// ReleaseBranchPrefixes are the branch name prefixes under which stable
+// release branches live. Discovery only needs to list these.
+var ReleaseBranchPrefixes = []string{"nixos-", "nixpkgs-", "release-"}
+
+// ReleaseChannelFromBranch returns the channel for a stable release branch
+// such as nixos-25.05. It returns false for any other branch name.
+func ReleaseChannelFromBranch(branch string) (Channel, bool) {
+	m := releaseBranchRegex.FindStringSubmatch(branch)
+	if m == nil {
+		return Channel{}, false
+	}
+
+	release := m[1] + m[2] + m[3]
+	return Channel{Name: branch, Branch: branch, Release: release}, true
+}
+
+// ReleaseChannels filters a list of branch names down to stable release
+// channels, ordered newest release first.
+func ReleaseChannels(branches []string) []Channel {
+	var channels []Channel
+	seen := make(map[string]bool)
+	for _, b := range branches {
+		if ch, ok := ReleaseChannelFromBranch(b); ok && !seen[ch.Name] {
+			channels = append(channels, ch)
+			seen[ch.Name] = true
+		}
+	}
+
+	sort.SliceStable(channels, func(i, j int) bool {
+		if channels[i].Release != channels[j].Release {
+			return channels[i].Release > channels[j].Release
+		}
+		return releaseKindOrder(channels[i].Name) < releaseKindOrder(channels[j].Name)
+	})
+	return channels
+}
+
+// releaseKindOrder orders the branches of a single release from the most
+// upstream (release-YY.MM) to the most downstream (nixos-YY.MM).
+func releaseKindOrder(name string) int {
+	switch {
+	case strings.HasPrefix(name, "release-"):
+		return 0
+	case strings.HasSuffix(name, "-small"):
+		return 1
+	case strings.HasSuffix(name, "-darwin"):
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Catalog is the set of channels that can be selected with --channels:
+// the unstable defaults, any discovered stable release channels, and the
+// custom channels and groups of the config file.
+type Catalog struct {
+	channels []Channel
+	releases []string
+	file     *File
+	// repo is set for repositories other than NixOS/nixpkgs, which have
+	// only the channels and presets of their config file table.
+	repo *RepoConfig
+}
+
+// NewCatalog creates a Catalog from the default channels and the given
+// discovered release channels.
+func NewCatalog(discovered []Channel) *Catalog {
+	c := &Catalog{channels: GetDefaultChannels()}
+
+	seen := make(map[string]bool)
+	for _, ch := range c.channels {
+		seen[ch.Name] = true
+	}
+
+	released := make(map[string]bool)
+	for _, ch := range discovered {
+		if seen[ch.Name] {
+			continue
+		}
+		seen[ch.Name] = true
+		c.channels = append(c.channels, ch)
+
+		// A release only counts as supported once its nixos-YY.MM channel
+		// exists; the release-YY.MM branch is cut a few weeks earlier.
+		if ch.Name == "nixos-"+ch.Release && !released[ch.Release] {
+			released[ch.Release] = true
+			c.releases = append(c.releases, ch.Release)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(c.releases)))
+	return c
+}
+
+// NewRepoCatalog creates a Catalog for a repository other than
+// NixOS/nixpkgs from its config file table, which may be nil. Such catalogs
+// accept any branch name, since the branches of the repository are not
+// discovered.
+func NewRepoCatalog(repo Repo, rc *RepoConfig) *Catalog {
+	if rc == nil {
+		rc = &RepoConfig{Repo: repo}
+	}
+	return &Catalog{channels: rc.Channels, repo: rc}
+}
+
+// Extend adds the custom channels and groups of f to the catalog. A nil f
+// adds nothing.
+func (c *Catalog) Extend(f *File) *Catalog {
+	if f == nil {
+		return c
+	}
+	c.channels = append(c.channels, f.Channels...)
+	c.file = f
+	return c
+}
+
+// Channels returns a copy of every channel in the catalog.
+func (c *Catalog) Channels() []Channel {
+	out := make([]Channel, len(c.channels))
+	copy(out, c.channels)
+	return out
+}
+
+// SupportedReleases returns the current and previous stable releases,
+// newest first.
+func (c *Catalog) SupportedReleases() []string {
+	n := min(len(c.releases), 2)
+	return c.releases[:n]
+}
+
+// channelPresets maps preset names accepted by --channels to the channels
+// they resolve to.
+var channelPresets = map[string]func(c *Catalog) []Channel{
+	"unstable": func(c *Catalog) []Channel { return GetDefaultChannels() },
+	"stable":   func(c *Catalog) []Channel { return c.releaseChannels(c.SupportedReleases()...) },
+	"current":  func(c *Catalog) []Channel { return c.releaseChannels(c.nthRelease(0)...) },
+	"previous": func(c *Catalog) []Channel { return c.releaseChannels(c.nthRelease(1)...) },
+}
+
+// PresetNames returns the names of the --channels presets in sorted order.
+func PresetNames() []string {
+	names := make([]string, 0, len(channelPresets))
+	for name := range channelPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Catalog) nthRelease(n int) []string {
+	if n >= len(c.releases) {
+		return nil
+	}
+	return []string{c.releases[n]}
+}
+
+func (c *Catalog) releaseChannels(releases ...string) []Channel {
+	var out []Channel
+	for _, release := range releases {
+		for _, ch := range c.channels {
+			if ch.Release == release {
+				out = append(out, ch)
+			}
+		}
+	}
+	return out
+}
+
+// NeedsDiscovery reports whether resolving the --channels input requires
+// the stable release channels to be discovered first, i.e. whether it
+// names anything besides the default channels and the custom channels of f,
+// once the groups of f are expanded. f may be nil.
+func NeedsDiscovery(input string, f *File) bool {
+	for _, name := range f.expandGroups(splitChannelList(input)) {
+		if !isDefaultChannel(name) && !f.hasChannel(name) && name != "unstable" {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses a comma-separated list of channel names, presets and groups
+// and returns the matching channels. Returns an error if any names are
+// unknown or a preset resolves to no channels. Returns all defaults if input
+// is empty.
+func (c *Catalog) Parse(input string) ([]Channel, error) {
+	if c.repo != nil {
+		return c.parseRepo(input), nil
+	}
+
+	requested := c.file.expandGroups(splitChannelList(input))
+	if len(requested) == 0 {
+		return GetDefaultChannels(), nil
+	}
+
+	byName := make(map[string]Channel)
+	for _, ch := range c.channels {
+		byName[ch.Name] = ch
+	}
+
+	var unknown []string
+	selected := make(map[string]bool)
+	for _, name := range requested {
+		if preset, ok := channelPresets[name]; ok {
+			resolved := preset(c)
+			if len(resolved) == 0 {
+				return nil, fmt.Errorf("channel preset %q matched no channels; no stable releases were discovered", name)
+			}
+			for _, ch := range resolved {
+				selected[ch.Name] = true
+			}
+			continue
+		}
+		if _, ok := byName[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		selected[name] = true
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown channels: %s; available: %s", strings.Join(unknown, ", "), c.availableNames())
+	}
+
+	var channels []Channel
+	for _, ch := range c.channels {
+		if selected[ch.Name] {
+			channels = append(channels, ch)
+		}
+	}
+
+	return channels, nil
+}
+
+// parseRepo resolves input for a repository other than NixOS/nixpkgs:
+// presets are expanded and every other name is taken as a branch. Returns
+// the configured channels, which may be none, if input is empty.
+func (c *Catalog) parseRepo(input string) []Channel {
+	requested := expandGroups(c.repo.Presets, splitChannelList(input))
+	if len(requested) == 0 {
+		return c.Channels()
+	}
+
+	var channels []Channel
+	seen := make(map[string]bool)
+	for _, name := range requested {
+		if !seen[name] {
+			seen[name] = true
+			channels = append(channels, Channel{Name: name, Branch: name})
+		}
+	}
+	return channels
+}
+
+// availableNames lists the default channels, the channels of the supported
+// releases, the custom channels, the presets and the groups. Older releases
+// are accepted but not listed.
+func (c *Catalog) availableNames() string {
+	var names []string
+	for _, ch := range GetDefaultChannels() {
+		names = append(names, ch.Name)
+	}
+	for _, ch := range c.releaseChannels(c.SupportedReleases()...) {
+		names = append(names, ch.Name)
+	}
+	if c.file != nil {
+		for _, ch := range c.file.Channels {
+			names = append(names, ch.Name)
+		}
+	}
+	available := fmt.Sprintf("%s (presets: %s", strings.Join(names, ", "), strings.Join(PresetNames(), ", "))
+	if groups := c.file.GroupNames(); len(groups) > 0 {
+		available += "; groups: " + strings.Join(groups, ", ")
+	}
+	return available + ")"
+}
+
+func splitChannelList(input string) []string {
+	var names []string
+	for _, part := range strings.Split(input, ",") {
+		name := strings.TrimSpace(part)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Repo identifies a GitHub repository.
+type Repo struct {
+	Owner string
+	Name  string
+}
+
+// DefaultRepo is the repository checked unless another one is given.
+var DefaultRepo = Repo{Owner: "NixOS", Name: "nixpkgs"}
+
+// repoRegex matches "owner/name" with the characters GitHub allows.
+var repoRegex = regexp.MustCompile(`^([A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)/([A-Za-z0-9._-]+)$`)
+
+// ParseRepo parses a repository given as "owner/name".
+func ParseRepo(s string) (Repo, error) {
+	m := repoRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || m[2] == "." || m[2] == ".." {
+		return Repo{}, fmt.Errorf("invalid repository %q: must be owner/name", s)
+	}
+	return Repo{Owner: m[1], Name: m[2]}, nil
+}
+
+// String returns "owner/name", or an empty string for the zero Repo.
+func (r Repo) String() string {
+	if r.IsZero() {
+		return ""
+	}
+	return r.Owner + "/" + r.Name
+}
+
+// IsZero reports whether r is unset.
+func (r Repo) IsZero() bool {
+	return r.Owner == "" && r.Name == ""
+}
+
+// Is reports whether r and other are the same repository. GitHub treats
+// owner and repository names case-insensitively.
+func (r Repo) Is(other Repo) bool {
+	return strings.EqualFold(r.Owner, other.Owner) && strings.EqualFold(r.Name, other.Name)
+}
+
+// IsNixpkgs reports whether r is NixOS/nixpkgs, the only repository whose
+// channels, presets and branch topology are built in.
+func (r Repo) IsNixpkgs() bool {
+	return r.Is(DefaultRepo)
+}
+
+// RepoConfig holds the channels of a repository other than NixOS/nixpkgs,
+// defined in a [repos."owner/name"] table of the config file.
+type RepoConfig struct {
+	Repo Repo
+	// Channels are checked when --channels is not given.
+	Channels []Channel
+	// Presets maps preset names to the channels, branches and other presets
+	// they stand for.
+	Presets map[string][]string
+}
+
+// repoKey is the case-insensitive map key of a repository.
+func repoKey(r Repo) string {
+	return strings.ToLower(r.String())
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Token is a GitHub token together with where it was found.
+type Token struct {
+	Value string
+	// Source describes where the token came from, e.g. "GH_TOKEN" or
+	// "/home/user/.netrc".
+	Source string
+}
+
+// tokenSource looks up a token in one place. It returns an empty token if
+// there is none, and a warning if a token was found but refused.
+type tokenSource func() (Token, string)
+
+// FindGitHubToken returns the first token for host found in, in order:
+// GH_TOKEN, GITHUB_TOKEN, the file named by NPRT_GITHUB_TOKEN_FILE, the
+// github-token systemd credential, the gh CLI's hosts.yml and ~/.netrc. Like
+// gh, a GitHub Enterprise Server reads GH_ENTERPRISE_TOKEN and
+// GITHUB_ENTERPRISE_TOKEN instead of the github.com variables. It also
+// returns a warning for every token file that was refused because other
+// users can read it. The returned token is empty if none was found.
+func FindGitHubToken(host Host) (Token, []string) {
+	envNames := []string{"GH_TOKEN", "GITHUB_TOKEN"}
+	if !host.IsDefault() {
+		envNames = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+	sources := []tokenSource{
+		envToken(envNames[0]),
+		envToken(envNames[1]),
+		tokenFileFromEnv,
+		systemdCredential,
+		ghHostsToken(host.Name()),
+		netrcToken(host.APIName()),
+	}
+
+	var warnings []string
+	for _, source := range sources {
+		token, warning := source()
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+		if token.Value != "" {
+			return token, warnings
+		}
+	}
+	return Token{}, warnings
+}
+
+func envToken(name string) tokenSource {
+	return func() (Token, string) {
+		return Token{Value: strings.TrimSpace(os.Getenv(name)), Source: name}, ""
+	}
+}
+
+func tokenFileFromEnv() (Token, string) {
+	path := os.Getenv("NPRT_GITHUB_TOKEN_FILE")
+	if path == "" {
+		return Token{}, ""
+	}
+	// Unlike the other token files, this one was named by the user, so it
+	// being missing is worth a warning.
+	if _, err := os.Stat(path); err != nil {
+		return Token{}, fmt.Sprintf("ignoring NPRT_GITHUB_TOKEN_FILE %s: %v", path, err)
+	}
+	return readTokenFile(path, func(data string) string {
+		return strings.TrimSpace(data)
+	})
+}
+
+// systemdCredential reads the github-token credential passed to a systemd
+// service with LoadCredential= or SetCredential=.
+func systemdCredential() (Token, string) {
+	dir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if dir == "" {
+		return Token{}, ""
+	}
+	return readTokenFile(filepath.Join(dir, "github-token"), func(data string) string {
+		return strings.TrimSpace(data)
+	})
+}
+
+// ghHostsToken reads the token of host stored by `gh auth login`. Newer gh
+// versions keep the token in the system keyring instead, in which case
+// hosts.yml has none.
+func ghHostsToken(host string) tokenSource {
+	return func() (Token, string) {
+		return readGHHostsToken(host)
+	}
+}
+
+func readGHHostsToken(host string) (Token, string) {
+	dir := os.Getenv("GH_CONFIG_DIR")
+	if dir == "" {
+		base := os.Getenv("XDG_CONFIG_HOME")
+		if base == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return Token{}, ""
+			}
+			base = filepath.Join(home, ".config")
+		}
+		dir = filepath.Join(base, "gh")
+	}
+	return readTokenFile(filepath.Join(dir, "hosts.yml"), func(data string) string {
+		return parseGHHosts(data, host)
+	})
+}
+
+// parseGHHosts returns the oauth_token of host from a gh hosts.yml file. It
+// understands the subset of YAML gh writes: a top-level key per host with
+// the token either directly below it or below users.<login>.
+func parseGHHosts(data, host string) string {
+	inHost := false
+	for line := range strings.Lines(data) {
+		line = strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if line[0] != ' ' && line[0] != '\t' {
+			key := strings.TrimSuffix(trimmed, ":")
+			inHost = strings.Trim(key, `"'`) == host
+			continue
+		}
+		if !inHost {
+			continue
+		}
+
+		if value, ok := strings.CutPrefix(trimmed, "oauth_token:"); ok {
+			if token := strings.Trim(strings.TrimSpace(value), `"'`); token != "" {
+				return token
+			}
+		}
+	}
+	return ""
+}
+
+// netrcToken reads the password of machine, the API host such as
+// api.github.com, from the file named by NETRC, or ~/.netrc.
+func netrcToken(machine string) tokenSource {
+	return func() (Token, string) {
+		return readNetrcToken(machine)
+	}
+}
+
+func readNetrcToken(machine string) (Token, string) {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Token{}, ""
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+	return readTokenFile(path, func(data string) string {
+		return parseNetrc(data, machine)
+	})
+}
+
+// parseNetrc returns the password of machine from netrc data. Entries are
+// whitespace-separated name/value pairs, started by "machine" or "default";
+// macro definitions are not supported.
+func parseNetrc(data, machine string) string {
+	var (
+		current  string
+		password string
+	)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Split(bufio.ScanWords)
+	for scanner.Scan() {
+		switch scanner.Text() {
+		case "machine":
+			if current == machine && password != "" {
+				return password
+			}
+			current, password = "", ""
+			if scanner.Scan() {
+				current = scanner.Text()
+			}
+		case "default":
+			if current == machine && password != "" {
+				return password
+			}
+			current, password = "", ""
+		case "password":
+			if scanner.Scan() {
+				password = scanner.Text()
+			}
+		case "login", "account":
+			// Skip the value so it is not mistaken for a keyword.
+			scanner.Scan()
+		}
+	}
+	if current == machine {
+		return password
+	}
+	return ""
+}
+
+// readTokenFile reads a file containing a token and extracts the token with
+// parse. Missing files yield no token; files that cannot be read yield a
+// warning. Tokens in files that other users can read are refused with a
+// warning, since they are exposed to those users.
+func readTokenFile(path string, parse func(string) string) (Token, string) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Token{}, ""
+	}
+	if err != nil {
+		return Token{}, fmt.Sprintf("ignoring token in %s: %v", path, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Token{}, fmt.Sprintf("ignoring token in %s: %v", path, err)
+	}
+	token := Token{Value: parse(string(data)), Source: path}
+	if token.Value == "" {
+		return Token{}, ""
+	}
+
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o044 != 0 {
+		return Token{}, fmt.Sprintf("ignoring token in %s: file is readable by other users; run chmod 600 %s", path, path)
+	}
+	return token, ""
+}
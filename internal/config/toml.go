@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// tomlEntry is a single key/value pair of a TOML document. Scalars are
+// converted to their string form, since every value ends up as a flag value
+// or a channel name; arrays keep one string per element.
+type tomlEntry struct {
+	// table is the path of the enclosing table, e.g. ["repos",
+	// "nix-community/home-manager"]; it is empty for top-level keys.
+	table  []string
+	key    string
+	values []string
+	array  bool
+	line   int
+}
+
+// parseTOML parses a TOML document into its key/value pairs, in document
+// order. The document is first decoded as a whole so that syntax errors,
+// duplicate keys and redefined tables are reported by the TOML library; it
+// is then walked expression by expression to keep the order and line of
+// every key. The config file only needs tables and string, integer, boolean
+// and array values, so dotted keys, inline tables, arrays of tables, floats
+// and dates are rejected.
+func parseTOML(data string) ([]tomlEntry, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal([]byte(data), &doc); err != nil {
+		var de *toml.DecodeError
+		if errors.As(err, &de) {
+			line, _ := de.Position()
+			return nil, fmt.Errorf("line %d: %s", line, strings.TrimPrefix(de.Error(), "toml: "))
+		}
+		return nil, err
+	}
+
+	var (
+		p       unstable.Parser
+		entries []tomlEntry
+		table   []string
+	)
+	p.Reset([]byte(data))
+	for p.NextExpression() {
+		expr := p.Expression()
+		path, line := tomlKey(&p, expr.Key())
+		switch expr.Kind {
+		case unstable.ArrayTable:
+			return nil, fmt.Errorf("line %d: arrays of tables are not supported", line)
+		case unstable.Table:
+			table = path
+		case unstable.KeyValue:
+			if len(path) > 1 {
+				return nil, fmt.Errorf("line %d: invalid key %s; keys with dots must be quoted", line, strings.Join(path, "."))
+			}
+			values, array, err := tomlValues(expr.Value())
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			entries = append(entries, tomlEntry{table: table, key: path[0], values: values, array: array, line: line})
+		}
+	}
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// tomlKey returns the parts of a possibly dotted key and the line it starts
+// on.
+func tomlKey(p *unstable.Parser, it unstable.Iterator) ([]string, int) {
+	var (
+		path []string
+		line int
+	)
+	for it.Next() {
+		if line == 0 {
+			line = p.Shape(it.Node().Raw).Start.Line
+		}
+		path = append(path, string(it.Node().Data))
+	}
+	return path, line
+}
+
+// tomlValues converts a scalar or an array of scalars to strings.
+func tomlValues(v *unstable.Node) ([]string, bool, error) {
+	if v.Kind != unstable.Array {
+		value, err := tomlScalar(v)
+		if err != nil {
+			return nil, false, err
+		}
+		return []string{value}, false, nil
+	}
+
+	values := []string{}
+	it := v.Children()
+	for it.Next() {
+		if it.Node().Kind == unstable.Array {
+			return nil, false, fmt.Errorf("nested arrays are not supported")
+		}
+		value, err := tomlScalar(it.Node())
+		if err != nil {
+			return nil, false, err
+		}
+		values = append(values, value)
+	}
+	return values, true, nil
+}
+
+// tomlScalar converts a string, integer or boolean to a string.
+func tomlScalar(v *unstable.Node) (string, error) {
+	switch v.Kind {
+	case unstable.String, unstable.Bool:
+		return string(v.Data), nil
+	case unstable.Integer:
+		n, err := strconv.ParseInt(string(v.Data), 0, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid integer %s", v.Data)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case unstable.InlineTable:
+		return "", fmt.Errorf("inline tables are not supported")
+	case unstable.Float:
+		return "", fmt.Errorf("floats are not supported")
+	default:
+		return "", fmt.Errorf("dates and times are not supported")
+	}
+}
+
+// QuoteTOML formats s as a TOML basic string.
+func QuoteTOML(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
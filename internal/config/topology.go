@@ -0,0 +1,125 @@
+package config
+
+import (
+	"regexp"
+	"slices"
+)
+
+// unstableTopology maps each unstable branch to the branches it is merged or
+// promoted into. Feature branches such as haskell-updates and python-updates
+// are merged into staging like any other mass rebuild.
+var unstableTopology = map[string][]string{
+	"haskell-updates":      {"staging"},
+	"python-updates":       {"staging"},
+	"staging":              {"staging-next"},
+	"staging-next":         {"master"},
+	"master":               {"nixos-unstable-small", "nixpkgs-unstable"},
+	"nixos-unstable-small": {"nixos-unstable"},
+}
+
+// releaseTopologyRegex matches the branches of a stable release that have
+// downstream branches.
+var releaseTopologyRegex = regexp.MustCompile(`^(staging|staging-next|release|nixos)-(\d{2}\.\d{2})(-small)?$`)
+
+// Downstream returns the branches a change on branch flows into next.
+// It returns nil for channels at the end of the pipeline and for branches
+// with an unknown topology.
+func Downstream(branch string) []string {
+	if next, ok := unstableTopology[branch]; ok {
+		return next
+	}
+
+	m := releaseTopologyRegex.FindStringSubmatch(branch)
+	if m == nil {
+		return nil
+	}
+
+	kind, release, small := m[1], m[2], m[3] != ""
+	switch {
+	case kind == "staging" && !small:
+		return []string{"staging-next-" + release}
+	case kind == "staging-next" && !small:
+		return []string{"release-" + release}
+	case kind == "release" && !small:
+		return []string{"nixos-" + release + "-small", "nixpkgs-" + release + "-darwin"}
+	case kind == "nixos" && small:
+		return []string{"nixos-" + release}
+	}
+	return nil
+}
+
+// Pipeline is the ordered path a change takes from a base branch through the
+// channels downstream of it. Stage 0 holds the base branch itself; every
+// later stage holds the branches first reachable at that depth, so channels
+// fed by several paths appear after all of their upstreams.
+type Pipeline struct {
+	Stages   [][]Channel
+	upstream map[string][]string
+}
+
+// PipelineFor builds the pipeline for a PR merged into base. It returns nil
+// if base has no known downstream branches.
+func PipelineFor(base string) *Pipeline {
+	if len(Downstream(base)) == 0 {
+		return nil
+	}
+
+	// Compute the longest distance from base to each branch so that a
+	// branch is placed after every branch that feeds into it.
+	depth := map[string]int{base: 0}
+	upstream := make(map[string][]string)
+	order := []string{base}
+	queue := []string{base}
+	for len(queue) > 0 {
+		branch := queue[0]
+		queue = queue[1:]
+		for _, next := range Downstream(branch) {
+			if !slices.Contains(upstream[next], branch) {
+				upstream[next] = append(upstream[next], branch)
+			}
+			if d, seen := depth[next]; !seen {
+				order = append(order, next)
+			} else if d > depth[branch] {
+				continue
+			}
+			depth[next] = depth[branch] + 1
+			queue = append(queue, next)
+		}
+	}
+
+	p := &Pipeline{upstream: upstream}
+	for _, branch := range order {
+		d := depth[branch]
+		for len(p.Stages) <= d {
+			p.Stages = append(p.Stages, nil)
+		}
+		p.Stages[d] = append(p.Stages[d], pipelineChannel(branch))
+	}
+	return p
+}
+
+// pipelineChannel returns the channel for a branch in a pipeline, tagging
+// stable release branches with their release.
+func pipelineChannel(branch string) Channel {
+	ch := Channel{Name: branch, Branch: branch}
+	if m := releaseTopologyRegex.FindStringSubmatch(branch); m != nil {
+		ch.Release = m[2]
+	} else if rc, ok := ReleaseChannelFromBranch(branch); ok {
+		ch.Release = rc.Release
+	}
+	return ch
+}
+
+// Channels returns every channel in the pipeline in stage order.
+func (p *Pipeline) Channels() []Channel {
+	var out []Channel
+	for _, stage := range p.Stages {
+		out = append(out, stage...)
+	}
+	return out
+}
+
+// Upstream returns the branches in the pipeline that feed directly into name.
+func (p *Pipeline) Upstream(name string) []string {
+	return p.upstream[name]
+}
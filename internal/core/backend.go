@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+// PRSource fetches pull request metadata and backports.
+type PRSource interface {
+	GetPullRequest(ctx context.Context, number int) (*github.PullRequest, error)
+	FindBackports(ctx context.Context, pr *github.PullRequest) ([]github.Backport, error)
+}
+
+// CommitSource is implemented by PR sources that can resolve abbreviated
+// commit SHAs and find the pull requests that introduced a commit.
+type CommitSource interface {
+	ResolveCommit(ctx context.Context, sha string) (string, error)
+	GetCommitPullRequests(ctx context.Context, sha string) ([]github.PullRequest, error)
+}
+
+// BranchComparer reports how a commit relates to a branch. A BehindBy of zero
+// means the branch contains the commit.
+type BranchComparer interface {
+	CompareCommitWithBranch(ctx context.Context, commit, branch string) (*github.CompareResult, error)
+}
+
+// MultiBranchComparer is implemented by comparers that can compare a commit
+// with several branches in one round trip. Results are in the order of
+// branches.
+type MultiBranchComparer interface {
+	CompareCommitWithBranches(ctx context.Context, commit string, branches []string) ([]github.BranchComparison, error)
+}
+
+// BranchFetcher is implemented by comparers that need to update branches
+// before comparing against them, such as a local git checkout.
+type BranchFetcher interface {
+	FetchBranches(ctx context.Context, branches []string) error
+}
+
+// BranchHeadSource is implemented by comparers that can look up the head
+// commits of branches. Heads are in the order of branches.
+type BranchHeadSource interface {
+	GetBranchHeads(ctx context.Context, branches []string) ([]github.BranchHead, error)
+}
+
+// LandingResolver is implemented by comparers that can find when a commit
+// landed in a branch that contains it. head is the head commit of branch, as
+// returned by GetBranchHeads.
+type LandingResolver interface {
+	FindLanding(ctx context.Context, commit, branch, head string) (*github.Landing, error)
+}
+
+// Backend provides everything a Checker needs. *github.Client and
+// *github.GraphQLClient implement it.
+type Backend interface {
+	PRSource
+	BranchComparer
+}
+
+// Combine returns a Backend that takes PR metadata from prs and answers
+// branch comparisons with comparer.
+func Combine(prs PRSource, comparer BranchComparer) Backend {
+	return combined{prs, comparer}
+}
+
+type combined struct {
+	PRSource
+	BranchComparer
+}
+
+// FetchBranches forwards to the comparer if it needs fetching.
+func (b combined) FetchBranches(ctx context.Context, branches []string) error {
+	if f, ok := b.BranchComparer.(BranchFetcher); ok {
+		return f.FetchBranches(ctx, branches)
+	}
+	return nil
+}
+
+// errNoBranchHeads is returned for head lookups by comparers that do not
+// support them.
+var errNoBranchHeads = errors.New("looking up branch heads is not supported by this backend")
+
+// GetBranchHeads forwards to the comparer if it can look up branch heads.
+func (b combined) GetBranchHeads(ctx context.Context, branches []string) ([]github.BranchHead, error) {
+	if s, ok := b.BranchComparer.(BranchHeadSource); ok {
+		return s.GetBranchHeads(ctx, branches)
+	}
+	return nil, errNoBranchHeads
+}
+
+// errNoLanding is returned for landing lookups by comparers that do not
+// support them.
+var errNoLanding = errors.New("finding landings is not supported by this backend")
+
+// FindLanding forwards to the comparer if it can find landings.
+func (b combined) FindLanding(ctx context.Context, commit, branch, head string) (*github.Landing, error) {
+	if r, ok := b.BranchComparer.(LandingResolver); ok {
+		return r.FindLanding(ctx, commit, branch, head)
+	}
+	return nil, errNoLanding
+}
+
+// errNoCommitSource is returned for commit lookups by PR sources that do not
+// support them.
+var errNoCommitSource = errors.New("looking up commits is not supported by this backend")
+
+// ResolveCommit forwards to the PR source if it can look up commits.
+func (b combined) ResolveCommit(ctx context.Context, sha string) (string, error) {
+	if s, ok := b.PRSource.(CommitSource); ok {
+		return s.ResolveCommit(ctx, sha)
+	}
+	return "", errNoCommitSource
+}
+
+// GetCommitPullRequests forwards to the PR source if it can look up commits.
+func (b combined) GetCommitPullRequests(ctx context.Context, sha string) ([]github.PullRequest, error) {
+	if s, ok := b.PRSource.(CommitSource); ok {
+		return s.GetCommitPullRequests(ctx, sha)
+	}
+	return nil, errNoCommitSource
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+// resolveBackports re-checks stable channels that do not contain the PR's
+// merge commit against the merge commits of the PR's backports. A channel
+// that contains a backport is marked present via that backport. The
+// releases the PR's backport labels ask for without a backport PR are
+// recorded in status.
+func (c *Checker) resolveBackports(ctx context.Context, status *PRStatus, pr *github.PullRequest, channels []config.Channel, results []ChannelResult) {
+	var pending []int
+	for i, ch := range channels {
+		if ch.Release != "" && results[i].Status != StatusPresent {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 && len(github.BackportTargets(pr)) == 0 {
+		return
+	}
+
+	backports, err := c.backend.FindBackports(ctx, pr)
+	if err != nil {
+		c.log.Debug("backport lookup failed", zap.Int("pr", pr.Number), zap.Error(err))
+		return
+	}
+	status.MissingBackports = github.MissingBackports(pr, backports)
+
+	byRelease := make(map[string][]github.Backport)
+	for _, bp := range backports {
+		if bp.Merged && bp.MergeCommitSHA != "" {
+			byRelease[bp.Release] = append(byRelease[bp.Release], bp)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, i := range pending {
+		candidates := byRelease[channels[i].Release]
+		if len(candidates) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, bp := range candidates {
+				c.log.Debug("checking backport",
+					zap.String("channel", channels[i].Name),
+					zap.Int("backport", bp.Number))
+				res := c.checkChannel(ctx, bp.MergeCommitSHA, channels[i])
+				if res.Status == StatusPresent {
+					res.ViaBackport = bp.Number
+					res.commit = bp.MergeCommitSHA
+					res.ViaBackportURL = c.Host.PullURL(c.Repo.String(), bp.Number)
+					results[i] = res
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
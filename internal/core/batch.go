@@ -0,0 +1,168 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+)
+
+// DefaultBatchWorkers is the number of PRs checked concurrently in batch mode.
+const DefaultBatchWorkers = 4
+
+// BatchResult is the outcome of checking one PR or commit in a batch.
+type BatchResult struct {
+	// Repo is the repository of the PR as "owner/name".
+	Repo   string
+	Number int
+	// Commit is the commit given instead of a PR number, as given.
+	Commit string
+	// URL is the web URL of the PR or commit.
+	URL    string
+	Status *PRStatus
+	Err    error
+}
+
+// Label returns "#N" for a PR and the commit for a commit.
+func (r BatchResult) Label() string {
+	if r.Commit != "" {
+		return ShortSHA(r.Commit)
+	}
+	return config.PRRef{Number: r.Number}.String()
+}
+
+// MarshalJSON encodes a successful result as its PRStatus and a failed one as
+// {"schema_version": 1, "repo": "owner/name", "pr": N, "url": "...",
+// "error": {"code": "...", "message": "..."}}, with "commit" instead of "pr"
+// for commits.
+func (r BatchResult) MarshalJSON() ([]byte, error) {
+	if r.Err != nil {
+		return json.Marshal(struct {
+			SchemaVersion int        `json:"schema_version"`
+			Repo          string     `json:"repo,omitempty"`
+			Number        int        `json:"pr,omitempty"`
+			Commit        string     `json:"commit,omitempty"`
+			URL           string     `json:"url,omitempty"`
+			Error         *ErrorInfo `json:"error"`
+		}{SchemaVersion, r.Repo, r.Number, r.Commit, r.URL, NewErrorInfo(r.Err)})
+	}
+	return json.Marshal(r.Status)
+}
+
+// DedupePRs returns refs without repeated entries, keeping the first
+// occurrence of each.
+func DedupePRs(refs []config.PRRef) []config.PRRef {
+	seen := make(map[config.PRRef]bool, len(refs))
+	out := make([]config.PRRef, 0, len(refs))
+	for _, ref := range refs {
+		// Every ref of a batch belongs to the checker's repository, and PRs
+		// and issues share their numbers.
+		key := config.PRRef{Number: ref.Number, Commit: ref.Commit}
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, ref)
+		}
+	}
+	return out
+}
+
+// Check checks a PR given by number or by a commit it introduced.
+func (c *Checker) Check(ctx context.Context, ref config.PRRef, channels []config.Channel) (*PRStatus, error) {
+	if ref.Commit != "" {
+		return c.CheckCommit(ctx, ref.Commit, channels)
+	}
+	return c.CheckPR(ctx, ref.Number, channels)
+}
+
+// CheckPRs checks several PRs or commits against the same channels using at
+// most workers concurrent checks. Repeated PRs and commits are checked once.
+// Results are returned in input order; onResult, if set, is called as each
+// check finishes, one call at a time.
+func (c *Checker) CheckPRs(ctx context.Context, refs []config.PRRef, channels []config.Channel, workers int, onResult func(BatchResult)) []BatchResult {
+	refs = DedupePRs(refs)
+	if workers < 1 {
+		workers = DefaultBatchWorkers
+	}
+	workers = min(workers, len(refs))
+
+	c.log.Debug("checking PRs", zap.Int("count", len(refs)), zap.Int("workers", workers))
+
+	results := make([]BatchResult, len(refs))
+	jobs := make(chan int)
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				status, err := c.Check(ctx, refs[i], channels)
+				results[i] = c.Result(refs[i], status, err)
+				if onResult != nil {
+					mu.Lock()
+					onResult(results[i])
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range refs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// Result returns the batch result of checking ref.
+func (c *Checker) Result(ref config.PRRef, status *PRStatus, err error) BatchResult {
+	res := BatchResult{Repo: c.Repo.String(), Number: ref.Number, Commit: ref.Commit, Status: status, Err: err}
+	if ref.Commit != "" {
+		res.URL = c.Host.CommitURL(res.Repo, ref.Commit)
+	} else {
+		res.URL = c.Host.PullURL(res.Repo, ref.Number)
+	}
+	return res
+}
+
+// MatrixColumns returns the channel names of all successful results in a
+// stable order: pipeline channels in propagation order of the first PR that
+// has them, then every other channel alphabetically.
+func MatrixColumns(results []BatchResult) []string {
+	seen := make(map[string]bool)
+	var columns, rest []string
+	for _, r := range results {
+		if r.Status == nil {
+			continue
+		}
+		for _, stage := range r.Status.Pipeline {
+			for _, name := range stage {
+				if !seen[name] {
+					seen[name] = true
+					columns = append(columns, name)
+				}
+			}
+		}
+	}
+	for _, r := range results {
+		if r.Status == nil {
+			continue
+		}
+		for _, ch := range r.Status.Channels {
+			if !seen[ch.Name] {
+				seen[ch.Name] = true
+				rest = append(rest, ch.Name)
+			}
+		}
+	}
+	slices.Sort(rest)
+	return append(columns, rest...)
+}
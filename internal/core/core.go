@@ -4,9 +4,12 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -14,6 +17,12 @@ import (
 	"github.com/thatsneat-dev/nprt/internal/github"
 )
 
+// SchemaVersion is the version of the JSON output, reported as
+// "schema_version" in every JSON document and described by
+// docs/nprt.schema.json. It is incremented when a field is removed or changes
+// meaning; new fields do not change it.
+const SchemaVersion = 1
+
 // ChannelStatus indicates whether a PR's merge commit is present in a channel.
 type ChannelStatus string
 
@@ -38,47 +47,234 @@ type ChannelResult struct {
 	Name   string        `json:"name"`
 	Branch string        `json:"branch"`
 	Status ChannelStatus `json:"status"`
-	Error  string        `json:"error,omitempty"`
+	// ViaBackport is the number of the backport PR through which the change
+	// reached the channel, or zero if the original merge commit is present.
+	ViaBackport int `json:"via_backport,omitempty"`
+	// ViaBackportURL is the web URL of the backport PR.
+	ViaBackportURL string `json:"via_backport_url,omitempty"`
+	// CommitsBehind is the number of commits of the checked commit that the
+	// channel branch lacks; zero if the channel has it.
+	CommitsBehind int `json:"commits_behind,omitempty"`
+	// Head is the commit at the head of the channel branch and HeadDate its
+	// commit date. HeadAgeSeconds is how long before the check it was
+	// committed, which tells a stuck channel from one that just has not
+	// advanced yet. They are only set with Checker.Details.
+	Head           string    `json:"head,omitempty"`
+	HeadDate       time.Time `json:"head_date,omitzero"`
+	HeadAgeSeconds int64     `json:"head_age_seconds,omitempty"`
+	// LandedRevision is the first commit on the first-parent history of
+	// the channel branch that contains the change, and LandedAt its commit
+	// date. They are only set for present channels with Checker.Landing.
+	LandedRevision string    `json:"landed_revision,omitempty"`
+	LandedAt       time.Time `json:"landed_at,omitzero"`
+	Error          string    `json:"error,omitempty"`
+
+	// commit is the commit found in a present channel: the checked one, or
+	// the merge commit of the backport in ViaBackport.
+	commit string
 }
 
 // PRStatus contains the full status of a PR including all channel results.
 type PRStatus struct {
-	Number      int             `json:"pr"`
-	Title       string          `json:"title,omitempty"`
-	Author      string          `json:"author,omitempty"`
-	State       PRState         `json:"state"`
-	MergeCommit string          `json:"merge_commit,omitempty"`
-	Channels    []ChannelResult `json:"channels"`
+	// Repo is the repository of the PR as "owner/name".
+	Repo   string `json:"repo,omitempty"`
+	Number int    `json:"pr"`
+	// URL is the web URL of the PR.
+	URL         string  `json:"url,omitempty"`
+	Title       string  `json:"title,omitempty"`
+	Author      string  `json:"author,omitempty"`
+	State       PRState `json:"state"`
+	MergeCommit string  `json:"merge_commit,omitempty"`
+	// Commit is the commit that was checked when a commit was given instead
+	// of a PR; the other fields then describe the PR that introduced it,
+	// if any.
+	Commit     string          `json:"commit,omitempty"`
+	CommitURL  string          `json:"commit_url,omitempty"`
+	BaseBranch string          `json:"base_branch,omitempty"`
+	Channels   []ChannelResult `json:"channels"`
+	// Pipeline lists the checked channels in the order the change flows
+	// through them from the base branch; channels in the same stage are
+	// promoted independently of each other.
+	Pipeline [][]string `json:"pipeline,omitempty"`
+	// WaitingOn lists the next hops the change has not reached yet although
+	// everything upstream of them already contains it.
+	WaitingOn []string `json:"waiting_on,omitempty"`
+	// MissingBackports lists the stable releases, e.g. "25.05", that the
+	// PR's backport labels ask for but no backport PR targets yet.
+	MissingBackports []string `json:"missing_backports,omitempty"`
+	// RateLimit is the GitHub API budget left after the check, if any
+	// request reported it.
+	RateLimit *github.RateLimit `json:"rate_limit,omitempty"`
+}
+
+// MarshalJSON encodes the status with the schema version.
+func (s PRStatus) MarshalJSON() ([]byte, error) {
+	// plain has the fields of PRStatus but not this method.
+	type plain PRStatus
+	return json.Marshal(struct {
+		SchemaVersion int `json:"schema_version"`
+		plain
+	}{SchemaVersion, plain(s)})
 }
 
-// Checker queries GitHub to determine PR status and channel propagation.
+// Checker queries a Backend to determine PR status and channel propagation.
 type Checker struct {
-	client *github.Client
-	log    *zap.Logger
+	// Repo is the repository the backend answers for. Channel selection
+	// from the base branch only applies to NixOS/nixpkgs.
+	Repo config.Repo
+	// Host is the GitHub instance of Repo, used for the web URLs of PRs.
+	Host config.Host
+	// Details makes checks also look up the head commit of every channel
+	// branch, if the backend supports it.
+	Details bool
+	// Landing makes checks also find when the change landed in every
+	// channel that contains it, if the backend supports it.
+	Landing bool
+	// ExtraChannels are checked in addition to the channels given to or
+	// selected by every check, such as the channels --require asks for.
+	ExtraChannels []config.Channel
+	// Now returns the time head ages are measured from.
+	Now func() time.Time
+
+	backend Backend
+	log     *zap.Logger
 }
 
-// NewChecker creates a new Checker with the given GitHub client and logger.
-func NewChecker(client *github.Client, log *zap.Logger) *Checker {
-	return &Checker{client: client, log: log.Named("core")}
+// NewChecker creates a new Checker for NixOS/nixpkgs on github.com with the
+// given backend and logger.
+func NewChecker(backend Backend, log *zap.Logger) *Checker {
+	return &Checker{Repo: config.DefaultRepo, Host: config.DefaultHost, Now: time.Now, backend: backend, log: log.Named("core")}
 }
 
 // CheckPR fetches a PR and checks its propagation across the given channels.
+// If no channels are given, they are selected from the pipeline downstream
+// of the PR's base branch, falling back to the default channels for base
+// branches with an unknown topology. In other repositories, whose topology
+// is unknown, the base branch itself is checked.
 func (c *Checker) CheckPR(ctx context.Context, prNumber int, channels []config.Channel) (*PRStatus, error) {
-	pr, err := c.client.GetPullRequest(ctx, prNumber)
+	pr, err := c.backend.GetPullRequest(ctx, prNumber)
+	if err != nil {
+		return nil, err
+	}
+	if pr.Merged && pr.MergeCommitSHA == "" {
+		return nil, fmt.Errorf("PR #%d has no merge commit SHA", prNumber)
+	}
+
+	var commit string
+	if pr.Merged {
+		commit = pr.MergeCommitSHA
+	} else {
+		c.log.Debug("PR not merged, skipping channel checks", zap.Int("pr", prNumber))
+	}
+	return c.check(ctx, c.newStatus(pr), pr, commit, channels)
+}
+
+// CheckCommit resolves a full or abbreviated commit SHA and checks the
+// commit's propagation across the given channels. The status describes the
+// pull request that introduced the commit, preferring the one that merged
+// it; without channels, they are selected from that PR's base branch.
+func (c *Checker) CheckCommit(ctx context.Context, sha string, channels []config.Channel) (*PRStatus, error) {
+	commits, ok := c.backend.(CommitSource)
+	if !ok {
+		return nil, errNoCommitSource
+	}
+	full, err := commits.ResolveCommit(ctx, sha)
 	if err != nil {
 		return nil, err
 	}
+	prs, err := commits.GetCommitPullRequests(ctx, full)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the pull requests of commit %s: %w", sha, err)
+	}
+
+	pr := pickCommitPR(full, prs)
+	status := &PRStatus{Repo: c.Repo.String()}
+	if pr != nil {
+		status = c.newStatus(pr)
+		c.log.Debug("found pull request of commit", zap.String("commit", full), zap.Int("pr", pr.Number))
+	} else if len(channels) == 0 && !c.Repo.IsNixpkgs() {
+		return nil, fmt.Errorf("commit %s belongs to no pull request; use --channels to choose the branches to check", sha)
+	}
+	status.Commit = full
+	status.CommitURL = c.Host.CommitURL(c.Repo.String(), full)
+
+	return c.check(ctx, status, pr, full, channels)
+}
+
+// Subject names what was checked: "PR #N", or "Commit <sha>" for a commit
+// that belongs to no pull request.
+func (s *PRStatus) Subject() string {
+	if s.Number == 0 && s.Commit != "" {
+		return "Commit " + ShortSHA(s.Commit)
+	}
+	return fmt.Sprintf("PR #%d", s.Number)
+}
+
+// ShortSHA abbreviates a commit SHA to 12 characters for display.
+func ShortSHA(sha string) string {
+	return sha[:min(len(sha), 12)]
+}
 
-	status := &PRStatus{
+// pickCommitPR returns the pull request that merged commit, or else the first
+// merged one, or else the first one. It returns nil if there are none.
+func pickCommitPR(commit string, prs []github.PullRequest) *github.PullRequest {
+	var merged *github.PullRequest
+	for i := range prs {
+		if prs[i].Merged && prs[i].MergeCommitSHA == commit {
+			return &prs[i]
+		}
+		if prs[i].Merged && merged == nil {
+			merged = &prs[i]
+		}
+	}
+	if merged != nil {
+		return merged
+	}
+	if len(prs) > 0 {
+		return &prs[0]
+	}
+	return nil
+}
+
+// newStatus returns the status of pr before any channel is checked.
+func (c *Checker) newStatus(pr *github.PullRequest) *PRStatus {
+	return &PRStatus{
+		Repo:        c.Repo.String(),
 		Number:      pr.Number,
+		URL:         c.Host.PullURL(c.Repo.String(), pr.Number),
 		Title:       pr.Title,
 		Author:      pr.User.Login,
 		State:       determinePRState(pr),
 		MergeCommit: pr.MergeCommitSHA,
+		BaseBranch:  pr.Base.Ref,
 	}
+}
 
-	if !pr.Merged {
-		c.log.Debug("PR not merged, skipping channel checks", zap.Int("pr", prNumber))
+// check fills in the channels of status by checking commit against them. An
+// empty commit is in no channel. pr, if not nil, is the PR the commit belongs
+// to: without channels, they are selected from its base branch, and merged
+// PRs are also looked for in stable channels through their backports.
+func (c *Checker) check(ctx context.Context, status *PRStatus, pr *github.PullRequest, commit string, channels []config.Channel) (*PRStatus, error) {
+	var pipeline *config.Pipeline
+	if c.Repo.IsNixpkgs() && status.BaseBranch != "" {
+		pipeline = config.PipelineFor(status.BaseBranch)
+	}
+	if len(channels) == 0 {
+		switch {
+		case pipeline != nil:
+			channels = pipeline.Channels()
+		case c.Repo.IsNixpkgs():
+			channels = config.GetDefaultChannels()
+		default:
+			channels = []config.Channel{{Name: status.BaseBranch, Branch: status.BaseBranch}}
+		}
+		c.log.Debug("selected channels from base branch",
+			zap.String("base", status.BaseBranch),
+			zap.Int("count", len(channels)))
+	}
+	channels = withChannels(channels, c.ExtraChannels)
+
+	if commit == "" {
 		results := make([]ChannelResult, len(channels))
 		for i, ch := range channels {
 			results[i] = ChannelResult{
@@ -87,21 +283,82 @@ func (c *Checker) CheckPR(ctx context.Context, prNumber int, channels []config.C
 				Status: StatusNotPresent,
 			}
 		}
+		c.describeHeads(ctx, results)
+		describePipeline(status, pipeline, results)
 		status.Channels = SortChannelResults(results)
 		return status, nil
 	}
 
-	if pr.MergeCommitSHA == "" {
-		return nil, fmt.Errorf("PR #%d has no merge commit SHA", prNumber)
-	}
-
 	c.log.Debug("checking channels",
 		zap.Int("count", len(channels)),
-		zap.String("commit", pr.MergeCommitSHA[:12]),
+		zap.String("commit", ShortSHA(commit)),
 	)
 
-	// Check all channels in parallel for faster results
+	if err := c.fetchBranches(ctx, channels); err != nil {
+		return nil, err
+	}
+
+	results := c.checkChannels(ctx, commit, channels)
+	for i := range results {
+		if results[i].Status == StatusPresent {
+			results[i].commit = commit
+		}
+	}
+
+	if pr != nil && pr.Merged {
+		c.resolveBackports(ctx, status, pr, channels, results)
+	}
+	c.describeHeads(ctx, results)
+	c.describeLandings(ctx, results)
+	describePipeline(status, pipeline, results)
+
+	status.Channels = SortChannelResults(results)
+	return status, nil
+}
+
+// withChannels returns channels followed by the channels of extra it does
+// not already contain, without modifying channels.
+func withChannels(channels, extra []config.Channel) []config.Channel {
+	seen := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		seen[ch.Name] = true
+	}
+	merged := slices.Clip(channels)
+	for _, ch := range extra {
+		if !seen[ch.Name] {
+			merged = append(merged, ch)
+			seen[ch.Name] = true
+		}
+	}
+	return merged
+}
+
+// checkChannels determines if a commit is present in each channel, with a
+// single batch comparison if the backend supports it and in parallel
+// otherwise.
+func (c *Checker) checkChannels(ctx context.Context, commit string, channels []config.Channel) []ChannelResult {
 	results := make([]ChannelResult, len(channels))
+
+	if m, ok := c.backend.(MultiBranchComparer); ok {
+		branches := make([]string, len(channels))
+		for i, ch := range channels {
+			branches[i] = ch.Branch
+		}
+		comparisons, err := m.CompareCommitWithBranches(ctx, commit, branches)
+		for i, ch := range channels {
+			if err != nil {
+				results[i] = channelResult(ch, nil, err)
+				continue
+			}
+			results[i] = channelResult(ch, comparisons[i].Result, comparisons[i].Err)
+		}
+		if err != nil {
+			c.log.Debug("batch channel check failed", zap.Error(err))
+		}
+		return results
+	}
+
+	// Check all channels in parallel for faster results
 	var wg sync.WaitGroup
 	wg.Add(len(channels))
 
@@ -109,23 +366,140 @@ func (c *Checker) CheckPR(ctx context.Context, prNumber int, channels []config.C
 		go func() {
 			defer wg.Done()
 			if ctx.Err() != nil {
-				results[i] = ChannelResult{
-					Name:   ch.Name,
-					Branch: ch.Branch,
-					Status: StatusUnknown,
-					Error:  ctx.Err().Error(),
-				}
+				results[i] = channelResult(ch, nil, ctx.Err())
 				return
 			}
 			c.log.Debug("checking channel", zap.String("channel", ch.Name), zap.String("branch", ch.Branch))
-			results[i] = c.checkChannel(ctx, pr.MergeCommitSHA, ch)
+			results[i] = c.checkChannel(ctx, commit, ch)
 		}()
 	}
 
 	wg.Wait()
+	return results
+}
 
-	status.Channels = SortChannelResults(results)
-	return status, nil
+// describeHeads fills in the head commit of every channel branch with
+// Details. Failed lookups only leave the head empty.
+func (c *Checker) describeHeads(ctx context.Context, results []ChannelResult) {
+	if !c.Details {
+		return
+	}
+	source, ok := c.backend.(BranchHeadSource)
+	if !ok {
+		return
+	}
+
+	branches := make([]string, len(results))
+	for i, res := range results {
+		branches[i] = res.Branch
+	}
+	heads, err := source.GetBranchHeads(ctx, branches)
+	if err != nil {
+		c.log.Debug("branch head lookup failed", zap.Error(err))
+		return
+	}
+
+	now := c.Now()
+	for i, head := range heads {
+		if head.Err != nil {
+			c.log.Debug("branch head lookup failed", zap.String("branch", branches[i]), zap.Error(head.Err))
+			continue
+		}
+		results[i].Head = head.SHA
+		results[i].HeadDate = head.Date
+		results[i].HeadAgeSeconds = int64(max(now.Sub(head.Date), 0) / time.Second)
+	}
+}
+
+// describeLandings fills in when the change landed in every present channel
+// with Landing. The heads of the channel branches are taken from Details or
+// looked up together, then the channels are searched in parallel. Failed
+// lookups only leave the landing empty.
+func (c *Checker) describeLandings(ctx context.Context, results []ChannelResult) {
+	if !c.Landing {
+		return
+	}
+	resolver, ok := c.backend.(LandingResolver)
+	if !ok {
+		return
+	}
+
+	heads := make(map[string]string)
+	var branches []string
+	for _, res := range results {
+		if res.Status != StatusPresent || res.commit == "" {
+			continue
+		}
+		if res.Head != "" {
+			heads[res.Branch] = res.Head
+		} else if !slices.Contains(branches, res.Branch) {
+			branches = append(branches, res.Branch)
+		}
+	}
+	if len(branches) > 0 {
+		if err := c.lookUpHeads(ctx, branches, heads); err != nil {
+			c.log.Debug("branch head lookup failed", zap.Error(err))
+			return
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range results {
+		res := &results[i]
+		head := heads[res.Branch]
+		if res.Status != StatusPresent || res.commit == "" || head == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			landing, err := resolver.FindLanding(ctx, res.commit, res.Branch, head)
+			if err != nil {
+				c.log.Debug("landing lookup failed", zap.String("branch", res.Branch), zap.Error(err))
+				return
+			}
+			res.LandedRevision = landing.SHA
+			res.LandedAt = landing.Date
+		}()
+	}
+	wg.Wait()
+}
+
+// lookUpHeads adds the head commit of each branch to heads, in a single
+// GetBranchHeads call. Branches whose head cannot be found are left out.
+func (c *Checker) lookUpHeads(ctx context.Context, branches []string, heads map[string]string) error {
+	source, ok := c.backend.(BranchHeadSource)
+	if !ok {
+		return errNoBranchHeads
+	}
+	found, err := source.GetBranchHeads(ctx, branches)
+	if err != nil {
+		return err
+	}
+	for i, head := range found {
+		if head.Err != nil {
+			c.log.Debug("branch head lookup failed", zap.String("branch", branches[i]), zap.Error(head.Err))
+			continue
+		}
+		heads[branches[i]] = head.SHA
+	}
+	return nil
+}
+
+// fetchBranches updates the channel branches if the backend needs it.
+func (c *Checker) fetchBranches(ctx context.Context, channels []config.Channel) error {
+	f, ok := c.backend.(BranchFetcher)
+	if !ok {
+		return nil
+	}
+	branches := make([]string, len(channels))
+	for i, ch := range channels {
+		branches[i] = ch.Branch
+	}
+	if err := f.FetchBranches(ctx, branches); err != nil {
+		return fmt.Errorf("failed to fetch channel branches: %w", err)
+	}
+	return nil
 }
 
 func determinePRState(pr *github.PullRequest) PRState {
@@ -143,16 +517,23 @@ func determinePRState(pr *github.PullRequest) PRState {
 
 // checkChannel determines if a commit is present in the given channel branch.
 func (c *Checker) checkChannel(ctx context.Context, commit string, ch config.Channel) ChannelResult {
+	compare, err := c.backend.CompareCommitWithBranch(ctx, commit, ch.Branch)
+	if err != nil {
+		c.log.Debug("channel check failed", zap.String("channel", ch.Name), zap.Error(err))
+	}
+	return channelResult(ch, compare, err)
+}
+
+// channelResult maps the comparison of a commit with a channel branch to the
+// channel's status. A failed comparison leaves the status unknown.
+func channelResult(ch config.Channel, compare *github.CompareResult, err error) ChannelResult {
 	result := ChannelResult{
 		Name:   ch.Name,
 		Branch: ch.Branch,
 		Status: StatusUnknown,
 	}
-
-	compare, err := c.client.CompareCommitWithBranch(ctx, commit, ch.Branch)
 	if err != nil {
 		result.Error = err.Error()
-		c.log.Debug("channel check failed", zap.String("channel", ch.Name), zap.Error(err))
 		return result
 	}
 
@@ -162,6 +543,7 @@ func (c *Checker) checkChannel(ctx context.Context, commit string, ch config.Cha
 		result.Status = StatusPresent
 	} else {
 		result.Status = StatusNotPresent
+		result.CommitsBehind = compare.BehindBy
 	}
 
 	return result
@@ -0,0 +1,55 @@
+package core
+
+// ChannelChange records a channel whose status differs between two checks.
+type ChannelChange struct {
+	Name   string        `json:"name"`
+	Branch string        `json:"branch"`
+	From   ChannelStatus `json:"from"`
+	To     ChannelStatus `json:"to"`
+}
+
+// DiffChannels returns the channels whose status changed from prev to cur,
+// in the order they appear in cur. Channels missing from prev are reported
+// as changing from unknown. A nil prev yields no changes.
+func DiffChannels(prev, cur *PRStatus) []ChannelChange {
+	if prev == nil || cur == nil {
+		return nil
+	}
+
+	before := make(map[string]ChannelStatus, len(prev.Channels))
+	for _, ch := range prev.Channels {
+		before[ch.Name] = ch.Status
+	}
+
+	var changes []ChannelChange
+	for _, ch := range cur.Channels {
+		from, ok := before[ch.Name]
+		if !ok {
+			from = StatusUnknown
+		}
+		if from != ch.Status {
+			changes = append(changes, ChannelChange{
+				Name:   ch.Name,
+				Branch: ch.Branch,
+				From:   from,
+				To:     ch.Status,
+			})
+		}
+	}
+	return changes
+}
+
+// HasChannels reports whether every named channel is present in status.
+// Channels that were not checked count as not present.
+func HasChannels(status *PRStatus, names []string) bool {
+	present := make(map[string]bool, len(status.Channels))
+	for _, ch := range status.Channels {
+		present[ch.Name] = ch.Status == StatusPresent
+	}
+	for _, name := range names {
+		if !present[name] {
+			return false
+		}
+	}
+	return true
+}
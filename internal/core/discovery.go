@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/cache"
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+// ChannelDiscoveryTTL is how long a discovered list of release branches is
+// reused before GitHub is asked again. New releases appear twice a year, so
+// a day is plenty fresh.
+const ChannelDiscoveryTTL = 24 * time.Hour
+
+const channelDiscoveryKey = "branches/NixOS/nixpkgs/releases"
+
+// DiscoverChannels returns the stable release channels that currently exist
+// in NixOS/nixpkgs, newest release first. If store is non-nil, the branch
+// list is read from and written to it to avoid listing branches on every run.
+func DiscoverChannels(ctx context.Context, client *github.Client, store *cache.Store, log *zap.Logger) ([]config.Channel, error) {
+	log = log.Named("discovery")
+
+	var branches []string
+	if store != nil && store.Get(channelDiscoveryKey, &branches) {
+		log.Debug("using cached release branches", zap.Int("count", len(branches)))
+		return config.ReleaseChannels(branches), nil
+	}
+
+	for _, prefix := range config.ReleaseBranchPrefixes {
+		found, err := client.ListBranches(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, found...)
+	}
+
+	channels := config.ReleaseChannels(branches)
+	log.Debug("discovered release channels", zap.Int("count", len(channels)))
+
+	if store != nil {
+		// Only the matching branch names are cached; the rest is noise.
+		names := make([]string, len(channels))
+		for i, ch := range channels {
+			names[i] = ch.Branch
+		}
+		if err := store.Put(channelDiscoveryKey, names, ChannelDiscoveryTTL); err != nil {
+			log.Debug("failed to cache release branches", zap.Error(err))
+		}
+	}
+
+	return channels, nil
+}
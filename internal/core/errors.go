@@ -0,0 +1,111 @@
+package core
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+// ErrorCode classifies a failed check in JSON output. The codes are stable,
+// so scripts can match on them instead of on messages.
+type ErrorCode string
+
+const (
+	// ErrorNotFound: no PR, issue or commit exists with the given reference.
+	ErrorNotFound ErrorCode = "not_found"
+	// ErrorIsIssue: the number is an issue, not a pull request.
+	ErrorIsIssue ErrorCode = "is_issue"
+	// ErrorRateLimited: a GitHub rate limit was exhausted.
+	ErrorRateLimited ErrorCode = "rate_limited"
+	// ErrorNetwork: GitHub could not be reached.
+	ErrorNetwork ErrorCode = "network"
+	// ErrorAuth: the token was rejected or lacks access.
+	ErrorAuth ErrorCode = "auth"
+	// ErrorAPI: GitHub returned another error, such as a server error.
+	ErrorAPI ErrorCode = "api"
+	// ErrorUsage: the command line is invalid.
+	ErrorUsage ErrorCode = "usage"
+	// ErrorOther: any other failure.
+	ErrorOther ErrorCode = "error"
+)
+
+// UsageError is an invalid command line, reported with exit code 2.
+type UsageError struct {
+	Message string
+}
+
+func (e *UsageError) Error() string {
+	return e.Message
+}
+
+// ErrorInfo is the machine-readable form of a failed check.
+type ErrorInfo struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	// StatusCode is the HTTP status of GitHub API errors.
+	StatusCode int `json:"status_code,omitempty"`
+	// Issue describes the issue and its related PRs for ErrorIsIssue.
+	Issue *IssueInfo `json:"issue,omitempty"`
+}
+
+// IssueInfo describes the issue given instead of a pull request.
+type IssueInfo struct {
+	Number     int                `json:"number"`
+	Title      string             `json:"title,omitempty"`
+	State      string             `json:"state,omitempty"`
+	URL        string             `json:"url,omitempty"`
+	RelatedPRs []github.RelatedPR `json:"related_prs"`
+}
+
+// NewErrorInfo classifies err. The message is the first line of the error;
+// the related PRs of an issue are in Issue instead.
+func NewErrorInfo(err error) *ErrorInfo {
+	msg, _, _ := strings.Cut(err.Error(), "\n")
+	info := &ErrorInfo{Code: ErrorOther, Message: msg}
+
+	var (
+		notPRErr    *github.NotPullRequestError
+		notFoundErr *github.NotFoundError
+		commitErr   *github.CommitNotFoundError
+		apiErr      *github.APIError
+		netErr      net.Error
+		usageErr    *UsageError
+	)
+	switch {
+	case errors.As(err, &notPRErr):
+		info.Code = ErrorIsIssue
+		info.Issue = &IssueInfo{
+			Number:     notPRErr.Number,
+			Title:      notPRErr.Title,
+			State:      notPRErr.State,
+			URL:        notPRErr.URL,
+			RelatedPRs: notPRErr.RelatedPRs,
+		}
+		if info.Issue.RelatedPRs == nil {
+			info.Issue.RelatedPRs = []github.RelatedPR{}
+		}
+	case errors.As(err, &notFoundErr), errors.As(err, &commitErr):
+		info.Code = ErrorNotFound
+	case errors.As(err, &apiErr):
+		info.StatusCode = apiErr.StatusCode
+		info.Message = apiErr.Message
+		switch {
+		case apiErr.RateLimited || apiErr.StatusCode == http.StatusTooManyRequests:
+			info.Code = ErrorRateLimited
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			info.Code = ErrorAuth
+		case apiErr.StatusCode == http.StatusNotFound:
+			info.Code = ErrorNotFound
+		default:
+			info.Code = ErrorAPI
+		}
+	case errors.As(err, &netErr):
+		info.Code = ErrorNetwork
+	case errors.As(err, &usageErr):
+		info.Code = ErrorUsage
+	}
+	return info
+}
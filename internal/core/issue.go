@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+// IssueStatus is the propagation of the merged pull requests related to an
+// issue, answering whether the fix for the issue reached a channel.
+type IssueStatus struct {
+	Repo   string `json:"repo,omitempty"`
+	Number int    `json:"issue"`
+	URL    string `json:"url,omitempty"`
+	Title  string `json:"title,omitempty"`
+	State  string `json:"state"`
+	// Related lists every pull request cross-referenced from the issue.
+	Related []github.RelatedPR `json:"related"`
+	// PRs holds the checks of the merged related pull requests, in the
+	// order they were referenced.
+	PRs []BatchResult `json:"prs"`
+}
+
+// MarshalJSON encodes the status with the schema version.
+func (s IssueStatus) MarshalJSON() ([]byte, error) {
+	type plain IssueStatus
+	return json.Marshal(struct {
+		SchemaVersion int `json:"schema_version"`
+		plain
+	}{SchemaVersion, plain(s)})
+}
+
+// CheckIssue checks every merged pull request related to issue against the
+// same channels, using at most workers concurrent checks. Unmerged related
+// PRs are listed but not checked.
+func (c *Checker) CheckIssue(ctx context.Context, issue *github.NotPullRequestError, channels []config.Channel, workers int) *IssueStatus {
+	status := &IssueStatus{
+		Repo:    c.Repo.String(),
+		Number:  issue.Number,
+		URL:     issue.URL,
+		Title:   issue.Title,
+		State:   issue.State,
+		Related: issue.RelatedPRs,
+		PRs:     []BatchResult{},
+	}
+	if status.Related == nil {
+		status.Related = []github.RelatedPR{}
+	}
+
+	var refs []config.PRRef
+	for _, pr := range issue.RelatedPRs {
+		if pr.State == github.StateMerged {
+			refs = append(refs, config.PRRef{Kind: config.RefPR, Number: pr.Number})
+		}
+	}
+	c.log.Debug("checking merged PRs of issue",
+		zap.Int("issue", issue.Number),
+		zap.Int("related", len(issue.RelatedPRs)),
+		zap.Int("merged", len(refs)))
+
+	if len(refs) > 0 {
+		status.PRs = c.CheckPRs(ctx, refs, channels, workers, nil)
+	}
+	return status
+}
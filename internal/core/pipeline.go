@@ -0,0 +1,62 @@
+package core
+
+import (
+	"github.com/thatsneat-dev/nprt/internal/config"
+)
+
+// describePipeline records the pipeline stages covered by the checked
+// channels and the next hops the change is waiting on. The base branch is
+// treated as reached when the PR is merged even if it was not checked.
+func describePipeline(status *PRStatus, pipeline *config.Pipeline, results []ChannelResult) {
+	if pipeline == nil {
+		return
+	}
+
+	checked := make(map[string]ChannelStatus)
+	for _, res := range results {
+		checked[res.Name] = res.Status
+	}
+
+	reached := func(name string) bool {
+		if name == status.BaseBranch && status.State == PRStateMerged {
+			return true
+		}
+		return checked[name] == StatusPresent
+	}
+
+	for i, stage := range pipeline.Stages {
+		var names []string
+		for _, ch := range stage {
+			_, ok := checked[ch.Name]
+			if !ok {
+				continue
+			}
+			names = append(names, ch.Name)
+
+			if reached(ch.Name) {
+				continue
+			}
+			// The base branch itself is the next hop of an unmerged PR.
+			waiting := i == 0
+			for _, up := range pipeline.Upstream(ch.Name) {
+				if reached(up) {
+					waiting = true
+				} else {
+					waiting = false
+					break
+				}
+			}
+			if waiting {
+				status.WaitingOn = append(status.WaitingOn, ch.Name)
+			}
+		}
+		if len(names) > 0 {
+			status.Pipeline = append(status.Pipeline, names)
+		}
+	}
+
+	// A pipeline with a single stage has nothing to show beyond the table.
+	if len(status.Pipeline) < 2 {
+		status.Pipeline = nil
+	}
+}
@@ -0,0 +1,108 @@
+package core
+
+import "slices"
+
+// RequireMode selects which channels a Requirement asks to be present.
+type RequireMode int
+
+const (
+	// RequireChannels: every channel in Requirement.Channels.
+	RequireChannels RequireMode = iota
+	// RequireAll: every checked channel.
+	RequireAll
+	// RequireAny: at least one checked channel.
+	RequireAny
+)
+
+// Requirement is the channel presence that --require asks of every checked
+// PR, so that scripts can gate on the exit code instead of parsing output.
+type Requirement struct {
+	Mode RequireMode
+	// Channels are the names of the required channels with RequireChannels.
+	Channels []string
+}
+
+// ParseRequirement parses the value of --require: "all", "any", or a
+// comma-separated list of channels. The list is returned as is in channels,
+// to be resolved like --channels; Requirement.Channels is left for the
+// caller to fill in.
+func ParseRequirement(spec string) (req Requirement, channels string) {
+	switch spec {
+	case "all":
+		return Requirement{Mode: RequireAll}, ""
+	case "any":
+		return Requirement{Mode: RequireAny}, ""
+	default:
+		return Requirement{Mode: RequireChannels}, spec
+	}
+}
+
+// Evaluate reports whether status meets the requirement: StatusPresent if
+// it does, StatusNotPresent if a required channel lacks the change, and
+// StatusUnknown if that cannot be told because a status is unknown. A
+// required channel that was not checked counts as unknown.
+func (r Requirement) Evaluate(status *PRStatus) ChannelStatus {
+	var statuses []ChannelStatus
+	if r.Mode == RequireChannels {
+		byName := make(map[string]ChannelStatus, len(status.Channels))
+		for _, ch := range status.Channels {
+			byName[ch.Name] = ch.Status
+		}
+		for _, name := range r.Channels {
+			s, ok := byName[name]
+			if !ok {
+				s = StatusUnknown
+			}
+			statuses = append(statuses, s)
+		}
+	} else {
+		for _, ch := range status.Channels {
+			statuses = append(statuses, ch.Status)
+		}
+	}
+
+	if r.Mode == RequireAny {
+		switch {
+		case slices.Contains(statuses, StatusPresent):
+			return StatusPresent
+		case slices.Contains(statuses, StatusUnknown):
+			return StatusUnknown
+		default:
+			return StatusNotPresent
+		}
+	}
+	switch {
+	case slices.Contains(statuses, StatusNotPresent):
+		return StatusNotPresent
+	case slices.Contains(statuses, StatusUnknown):
+		return StatusUnknown
+	default:
+		return StatusPresent
+	}
+}
+
+// EvaluateResults evaluates the requirement for every checked PR of a batch
+// and returns the worst outcome: StatusNotPresent if any PR misses it, else
+// StatusUnknown if any cannot be told. Failed checks are skipped; they are
+// reported on their own. If no PR was checked, such as for an issue without
+// merged PRs, nothing contains the change and the outcome is
+// StatusNotPresent.
+func (r Requirement) EvaluateResults(results []BatchResult) ChannelStatus {
+	outcome := StatusNotPresent
+	for _, res := range results {
+		if res.Err != nil || res.Status == nil {
+			continue
+		}
+		switch r.Evaluate(res.Status) {
+		case StatusNotPresent:
+			return StatusNotPresent
+		case StatusUnknown:
+			outcome = StatusUnknown
+		case StatusPresent:
+			if outcome == StatusNotPresent {
+				outcome = StatusPresent
+			}
+		}
+	}
+	return outcome
+}
@@ -0,0 +1,268 @@
+// Package git answers branch containment questions from a local nixpkgs
+// checkout instead of the GitHub API.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+// DefaultRemote is used when no remote of the repository points at the
+// checked GitHub repository.
+const DefaultRemote = "origin"
+
+// fetchReuse is how long a fetched branch is considered fresh. It keeps a
+// batch of PRs from fetching the same branch once per PR.
+const fetchReuse = 30 * time.Second
+
+// Repo is a local git repository containing nixpkgs.
+type Repo struct {
+	Dir    string
+	Remote string
+	// Fetch makes FetchBranches update the requested branches from Remote.
+	Fetch bool
+	Now   func() time.Time
+
+	log *zap.Logger
+
+	mu      sync.Mutex
+	fetched map[string]time.Time
+}
+
+// Open checks that dir is a git repository and picks the remote that points
+// at the GitHub repository repo on host, falling back to DefaultRemote.
+func Open(ctx context.Context, dir string, host config.Host, repo config.Repo, log *zap.Logger) (*Repo, error) {
+	r := &Repo{
+		Dir:     dir,
+		Remote:  DefaultRemote,
+		Now:     time.Now,
+		log:     log.Named("git"),
+		fetched: make(map[string]time.Time),
+	}
+
+	if _, err := r.git(ctx, "rev-parse", "--git-dir"); err != nil {
+		return nil, fmt.Errorf("%s is not a git repository: %w", dir, err)
+	}
+
+	out, err := r.git(ctx, "remote", "-v")
+	if err != nil {
+		return nil, err
+	}
+	for line := range strings.Lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && isRemoteOf(fields[1], host, repo) {
+			r.Remote = fields[0]
+			break
+		}
+	}
+	r.log.Debug("opened repository", zap.String("dir", dir), zap.String("remote", r.Remote))
+
+	return r, nil
+}
+
+// isRemoteOf reports whether a remote URL points at repo on host, over HTTPS
+// or SSH.
+func isRemoteOf(url string, host config.Host, repo config.Repo) bool {
+	url = strings.TrimSuffix(strings.ToLower(url), ".git")
+	name := strings.ToLower(host.Name()) + "/" + strings.ToLower(repo.String())
+	sshName := strings.ToLower(host.Name()) + ":" + strings.ToLower(repo.String())
+	return strings.HasSuffix(url, name) || strings.HasSuffix(url, sshName)
+}
+
+// FetchBranches fetches the given branches from the remote, skipping branches
+// fetched in the last few seconds. It does nothing unless Fetch is set.
+func (r *Repo) FetchBranches(ctx context.Context, branches []string) error {
+	if !r.Fetch {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.Now()
+	var refspecs []string
+	for _, branch := range branches {
+		if at, ok := r.fetched[branch]; ok && now.Sub(at) < fetchReuse {
+			continue
+		}
+		refspecs = append(refspecs, fmt.Sprintf("+refs/heads/%s:%s", branch, r.remoteRef(branch)))
+	}
+	if len(refspecs) == 0 {
+		return nil
+	}
+
+	r.log.Debug("fetching branches", zap.String("remote", r.Remote), zap.Strings("refspecs", refspecs))
+	args := append([]string{"fetch", "--quiet", "--no-tags", r.Remote}, refspecs...)
+	if _, err := r.git(ctx, args...); err != nil {
+		return err
+	}
+	for _, branch := range branches {
+		r.fetched[branch] = now
+	}
+	return nil
+}
+
+// CompareCommitWithBranch reports whether branch contains commit using
+// git merge-base --is-ancestor against the remote-tracking branch, or a local
+// branch of the same name if there is no remote-tracking one. Only
+// BehindBy is filled in; AheadBy is not computed because counting the
+// commits on a channel branch is expensive.
+func (r *Repo) CompareCommitWithBranch(ctx context.Context, commit, branch string) (*github.CompareResult, error) {
+	ref, err := r.resolveBranch(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.git(ctx, "cat-file", "-e", commit+"^{commit}"); err != nil {
+		if r.wasFetched(branch) {
+			// The branch is up to date, so a commit we do not have is not on it.
+			return &github.CompareResult{Status: "diverged", BehindBy: 1}, nil
+		}
+		return nil, fmt.Errorf("commit %s not found in %s; fetch %s from %s", commit, r.Dir, branch, r.Remote)
+	}
+
+	_, err = r.git(ctx, "merge-base", "--is-ancestor", commit, ref)
+	if err == nil {
+		return &github.CompareResult{Status: "ahead"}, nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		return nil, err
+	}
+
+	out, err := r.git(ctx, "rev-list", "--count", ref+".."+commit)
+	if err != nil {
+		return nil, err
+	}
+	behind, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil {
+		return nil, fmt.Errorf("unexpected rev-list output %q", out)
+	}
+	return &github.CompareResult{Status: "diverged", BehindBy: behind}, nil
+}
+
+// GetBranchHeads returns the head commit of each branch, in the order of
+// branches, from the remote-tracking branches. They are as recent as the last
+// fetch.
+func (r *Repo) GetBranchHeads(ctx context.Context, branches []string) ([]github.BranchHead, error) {
+	heads := make([]github.BranchHead, len(branches))
+	for i, branch := range branches {
+		ref, err := r.resolveBranch(ctx, branch)
+		if err != nil {
+			heads[i].Err = err
+			continue
+		}
+		sha, date, err := r.commitDate(ctx, ref)
+		if err != nil {
+			heads[i].Err = err
+			continue
+		}
+		heads[i] = github.BranchHead{SHA: sha, Date: date}
+	}
+	return heads, nil
+}
+
+// FindLanding returns the first commit on the first-parent history of the
+// remote-tracking branch that contains commit, and its commit date. head is
+// the head commit of branch, as returned by GetBranchHeads.
+func (r *Repo) FindLanding(ctx context.Context, commit, branch, head string) (*github.Landing, error) {
+	if _, err := r.git(ctx, "merge-base", "--is-ancestor", commit, head); err != nil {
+		return nil, fmt.Errorf("branch %s does not contain commit %s: %w", branch, commit, err)
+	}
+
+	out, err := r.git(ctx, "rev-parse", "--verify", commit+"^{commit}")
+	if err != nil {
+		return nil, err
+	}
+	landing := strings.TrimSpace(out)
+
+	// The oldest first-parent commit descending from commit brought it into
+	// the branch, unless commit is on the first-parent history itself and
+	// that is its child.
+	out, err = r.git(ctx, "rev-list", "--first-parent", "--ancestry-path", commit+".."+head)
+	if err != nil {
+		return nil, err
+	}
+	if descendants := strings.Fields(out); len(descendants) > 0 {
+		oldest := descendants[len(descendants)-1]
+		parent, err := r.git(ctx, "rev-parse", oldest+"^")
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(parent) != landing {
+			landing = oldest
+		}
+	}
+
+	sha, date, err := r.commitDate(ctx, landing)
+	if err != nil {
+		return nil, err
+	}
+	return &github.Landing{SHA: sha, Date: date}, nil
+}
+
+// commitDate returns the full SHA and the commit date of rev.
+func (r *Repo) commitDate(ctx context.Context, rev string) (string, time.Time, error) {
+	out, err := r.git(ctx, "log", "-1", "--format=%H %cI", rev)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	sha, date, _ := strings.Cut(strings.TrimSpace(out), " ")
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("unexpected git log output %q", out)
+	}
+	return sha, t, nil
+}
+
+// resolveBranch returns the remote-tracking ref for branch, falling back to a
+// local branch of the same name.
+func (r *Repo) resolveBranch(ctx context.Context, branch string) (string, error) {
+	for _, ref := range []string{r.remoteRef(branch), "refs/heads/" + branch} {
+		if _, err := r.git(ctx, "rev-parse", "--verify", "--quiet", ref); err == nil {
+			return ref, nil
+		}
+	}
+	return "", fmt.Errorf("branch %s not found in %s; fetch it from %s", branch, r.Dir, r.Remote)
+}
+
+func (r *Repo) remoteRef(branch string) string {
+	return "refs/remotes/" + r.Remote + "/" + branch
+}
+
+func (r *Repo) wasFetched(branch string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.fetched[branch]
+	return ok
+}
+
+// git runs a git command in the repository and returns its stdout. Errors
+// include git's stderr and keep the *exec.ExitError for inspection.
+func (r *Repo) git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", r.Dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	r.log.Debug("running git", zap.Strings("args", args))
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
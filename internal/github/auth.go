@@ -0,0 +1,101 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AuthStatus describes the configured token and the remaining API budget.
+type AuthStatus struct {
+	TokenSet bool `json:"token_set"`
+	// TokenSource is where the token was found, e.g. "GH_TOKEN".
+	TokenSource string `json:"token_source,omitempty"`
+	Login       string `json:"login,omitempty"`
+	// Scopes are the OAuth scopes of a classic token. Fine-grained tokens
+	// and GitHub App tokens report none.
+	Scopes     []string             `json:"scopes"`
+	RateLimits map[string]RateLimit `json:"rate_limits"`
+}
+
+// GetAuthenticatedUser returns the login of the token's owner and the scopes
+// listed in the X-OAuth-Scopes header. It fails without a token.
+func (c *Client) GetAuthenticatedUser(ctx context.Context) (string, []string, error) {
+	body, header, err := c.doRequestWithHeaders(ctx, http.MethodGet, "/user", "application/vnd.github+json", nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := json.Unmarshal(body, &user); err != nil {
+		return "", nil, fmt.Errorf("failed to parse user response: %w", err)
+	}
+
+	scopes := []string{}
+	for scope := range strings.SplitSeq(header.Get("X-OAuth-Scopes"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return user.Login, scopes, nil
+}
+
+// GetRateLimits returns the budget of every rate limit resource, such as
+// "core" and "graphql". Querying it does not count against the budget.
+func (c *Client) GetRateLimits(ctx context.Context) (map[string]RateLimit, error) {
+	body, err := c.doRequestWithAccept(ctx, http.MethodGet, "/rate_limit", "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Resources map[string]struct {
+			Limit     int   `json:"limit"`
+			Remaining int   `json:"remaining"`
+			Used      int   `json:"used"`
+			Reset     int64 `json:"reset"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit response: %w", err)
+	}
+
+	limits := make(map[string]RateLimit, len(resp.Resources))
+	for name, r := range resp.Resources {
+		limits[name] = RateLimit{
+			Limit:     r.Limit,
+			Remaining: r.Remaining,
+			Used:      r.Used,
+			Reset:     time.Unix(r.Reset, 0),
+			Resource:  name,
+		}
+	}
+	return limits, nil
+}
+
+// GetAuthStatus reports whether a token is configured, who it belongs to and
+// the remaining budget of each rate limit resource.
+func (c *Client) GetAuthStatus(ctx context.Context) (*AuthStatus, error) {
+	status := &AuthStatus{TokenSet: c.Token != "", Scopes: []string{}}
+
+	if status.TokenSet {
+		login, scopes, err := c.GetAuthenticatedUser(ctx)
+		if err != nil {
+			return nil, err
+		}
+		status.Login = login
+		status.Scopes = scopes
+	}
+
+	limits, err := c.GetRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.RateLimits = limits
+	return status, nil
+}
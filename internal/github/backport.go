@@ -0,0 +1,147 @@
+package github
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// backportTitleRegex matches the title conventions used for backports in
+// nixpkgs: the backport bot's "[Backport release-25.05] ..." as well as the
+// hand-written "[release-25.05] ..." and "[25.05] ..." forms.
+var backportTitleRegex = regexp.MustCompile(`^\[(?:Backport )?(?:(?:release|staging)-)?(\d{2}\.\d{2})\]`)
+
+// stableBaseRegex matches the stable base branches a backport can target.
+var stableBaseRegex = regexp.MustCompile(`^(?:release|staging)-(\d{2}\.\d{2})$`)
+
+// backportLabelPrefix is the prefix of the labels that ask the backport bot
+// to open a backport, e.g. "backport release-25.05".
+const backportLabelPrefix = "backport "
+
+// portedLabel is set on pull requests that port a change to a stable release.
+const portedLabel = "8.has: port to stable"
+
+// Backport is a pull request that carries a change to a stable release branch.
+type Backport struct {
+	Number         int
+	Title          string
+	URL            string
+	BaseRef        string
+	Release        string
+	Merged         bool
+	MergeCommitSHA string
+}
+
+// BackportTargets returns the stable releases a pull request's labels ask to
+// backport to, e.g. "25.05" for the "backport release-25.05" label.
+func BackportTargets(pr *PullRequest) []string {
+	var releases []string
+	for _, label := range pr.Labels {
+		branch, ok := strings.CutPrefix(label.Name, backportLabelPrefix)
+		if !ok {
+			continue
+		}
+		if m := stableBaseRegex.FindStringSubmatch(branch); m != nil {
+			releases = append(releases, m[1])
+		}
+	}
+	return releases
+}
+
+// MissingBackports returns the releases a pull request's backport labels ask
+// for that none of backports targets yet, in label order.
+func MissingBackports(pr *PullRequest, backports []Backport) []string {
+	found := make(map[string]bool, len(backports))
+	for _, bp := range backports {
+		found[bp.Release] = true
+	}
+	var missing []string
+	for _, release := range BackportTargets(pr) {
+		if !found[release] {
+			missing = append(missing, release)
+			found[release] = true
+		}
+	}
+	return missing
+}
+
+// FindBackports returns the backports of a pull request. Candidates are the
+// pull requests cross-referenced from its timeline that follow the backport
+// title convention or carry the port label, and, for the releases its
+// backport labels ask for, those that reuse its title, as hand-made
+// backports often do. Each candidate is then fetched to confirm it targets a
+// stable release branch, one of the labelled ones for candidates found by
+// title, and to obtain its merge commit.
+func (c *Client) FindBackports(ctx context.Context, pr *PullRequest) ([]Backport, error) {
+	targets := BackportTargets(pr)
+	c.log.Debug("looking up backports",
+		zap.Int("pr", pr.Number),
+		zap.Strings("labelled_targets", targets))
+
+	var backports []Backport
+	for _, ref := range c.fetchCrossReferencedPRs(ctx, pr.Number, c.TimelinePages) {
+		if ref.Number == pr.Number {
+			continue
+		}
+		conventional := isBackportCandidate(ref)
+		if !conventional && !isLabelledBackportCandidate(ref, pr, targets) {
+			continue
+		}
+
+		candidate, err := c.GetPullRequest(ctx, ref.Number)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.log.Debug("failed to fetch backport candidate", zap.Int("pr", ref.Number), zap.Error(err))
+			continue
+		}
+
+		m := stableBaseRegex.FindStringSubmatch(candidate.Base.Ref)
+		if m == nil || (!conventional && !slices.Contains(targets, m[1])) {
+			c.log.Debug("backport candidate does not target a stable branch",
+				zap.Int("pr", ref.Number),
+				zap.String("base", candidate.Base.Ref))
+			continue
+		}
+
+		backports = append(backports, Backport{
+			Number:         candidate.Number,
+			Title:          candidate.Title,
+			URL:            ref.HTMLURL,
+			BaseRef:        candidate.Base.Ref,
+			Release:        m[1],
+			Merged:         candidate.Merged,
+			MergeCommitSHA: candidate.MergeCommitSHA,
+		})
+	}
+
+	c.log.Debug("backport lookup complete", zap.Int("pr", pr.Number), zap.Int("backports", len(backports)))
+
+	return backports, nil
+}
+
+// isLabelledBackportCandidate reports whether ref may be a hand-made backport
+// of pr to one of the labelled targets: a pull request whose title contains
+// pr's title.
+func isLabelledBackportCandidate(ref *crossReferenceIssue, pr *PullRequest, targets []string) bool {
+	if len(targets) == 0 || ref.PullRequest == nil || strings.TrimSpace(pr.Title) == "" {
+		return false
+	}
+	return strings.Contains(strings.ToLower(ref.Title), strings.ToLower(strings.TrimSpace(pr.Title)))
+}
+
+func isBackportCandidate(ref *crossReferenceIssue) bool {
+	if backportTitleRegex.MatchString(ref.Title) {
+		return true
+	}
+	for _, label := range ref.Labels {
+		if label.Name == portedLabel {
+			return true
+		}
+	}
+	return false
+}
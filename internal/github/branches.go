@@ -0,0 +1,232 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// gitRef represents a single entry from the git matching-refs API.
+type gitRef struct {
+	Ref string `json:"ref"`
+}
+
+// ListBranches returns the names of all branches of the client's repository
+// starting with prefix. It uses the matching-refs endpoint, which returns every match in a
+// single response instead of paging through all branches of the repository.
+func (c *Client) ListBranches(ctx context.Context, prefix string) ([]string, error) {
+	path := c.repoPath("git/matching-refs/heads/%s", url.PathEscape(prefix))
+
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []gitRef
+	if err := json.Unmarshal(body, &refs); err != nil {
+		return nil, fmt.Errorf("failed to parse refs response: %w", err)
+	}
+
+	branches := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if name, ok := strings.CutPrefix(ref.Ref, "refs/heads/"); ok {
+			branches = append(branches, name)
+		}
+	}
+
+	c.log.Debug("listed branches", zap.String("prefix", prefix), zap.Int("count", len(branches)))
+
+	return branches, nil
+}
+
+// BranchHead is the commit at the head of a branch, or the error looking it
+// up. Date is the commit date of the head commit.
+type BranchHead struct {
+	SHA  string
+	Date time.Time
+	Err  error
+}
+
+// commitResponse holds the fields of a commit in the REST API used for
+// heads and landings.
+type commitResponse struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Committer struct {
+			Date time.Time `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+// branchResponse holds the fields of the branches API used for heads.
+type branchResponse struct {
+	Commit commitResponse `json:"commit"`
+}
+
+// branchHeadWorkers is the number of branch heads looked up concurrently.
+const branchHeadWorkers = 4
+
+// GetBranchHeads returns the head commit of each branch, in the order of
+// branches, with one request per branch and at most branchHeadWorkers
+// requests at a time. Heads move, so they are never cached; conditional
+// requests still keep unchanged branches cheap.
+func (c *Client) GetBranchHeads(ctx context.Context, branches []string) ([]BranchHead, error) {
+	heads := make([]BranchHead, len(branches))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(branchHeadWorkers, len(branches)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				heads[i] = c.getBranchHead(ctx, branches[i])
+			}
+		}()
+	}
+
+	for i := range branches {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return heads, nil
+}
+
+// getBranchHead returns the head commit of branch.
+func (c *Client) getBranchHead(ctx context.Context, branch string) BranchHead {
+	body, err := c.doRequest(ctx, http.MethodGet, c.repoPath("branches/%s", url.PathEscape(branch)))
+	if err != nil {
+		return BranchHead{Err: err}
+	}
+	var resp branchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return BranchHead{Err: fmt.Errorf("failed to parse branch response: %w", err)}
+	}
+	return BranchHead{SHA: resp.Commit.SHA, Date: resp.Commit.Commit.Committer.Date}
+}
+
+// Landing is the first commit on the first-parent history of a branch that
+// contains a given commit, and its commit date: when the commit landed in
+// the branch.
+type Landing struct {
+	SHA  string    `json:"sha"`
+	Date time.Time `json:"date"`
+}
+
+// maxLandingDepth bounds the search for a landing, in first-parent commits
+// below the head of the branch.
+const maxLandingDepth = 1 << 20
+
+// FindLanding returns the landing of commit in branch, which must contain
+// it; head is the head commit of branch, as returned by GetBranchHeads.
+// Along the first-parent history of a branch, every commit from the landing
+// up contains commit and none below it does, so the landing is found by
+// comparing commit with ancestors of head: first at exponentially growing
+// distances, then by binary search. A commit that landed n first-parent
+// commits ago takes about 2·log2(n) requests. Landings do not change, so
+// they are cached permanently.
+func (c *Client) FindLanding(ctx context.Context, commit, branch, head string) (*Landing, error) {
+	key := c.landingCacheKey(commit, branch)
+	var cached Landing
+	if c.cacheGet(key, &cached) {
+		return &cached, nil
+	}
+
+	ancestor := func(depth int) string {
+		return fmt.Sprintf("%s~%d", head, depth)
+	}
+
+	found, err := c.containsCommit(ctx, commit, head)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("branch %s does not contain commit %s", branch, commit)
+	}
+
+	// lo always contains commit and hi never does.
+	lo, hi := 0, 1
+	for {
+		found, err := c.containsCommit(ctx, commit, ancestor(hi))
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			break
+		}
+		if hi >= maxLandingDepth {
+			return nil, fmt.Errorf("commit %s landed more than %d commits below the head of %s", commit, maxLandingDepth, branch)
+		}
+		lo, hi = hi, hi*2
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		found, err := c.containsCommit(ctx, commit, ancestor(mid))
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	c.log.Debug("found landing", zap.String("branch", branch), zap.Int("depth", lo))
+
+	landing, err := c.getCommitDate(ctx, ancestor(lo))
+	if err != nil {
+		return nil, err
+	}
+	c.cachePut(key, landing, true)
+	return landing, nil
+}
+
+// containsCommit reports whether rev contains commit. A rev beyond the root
+// of the history, which GitHub does not find, contains nothing. The
+// comparisons are not cached, as only the landing they lead to is of
+// interest.
+func (c *Client) containsCommit(ctx context.Context, commit, rev string) (bool, error) {
+	body, err := c.doRequest(ctx, http.MethodGet, c.repoPath("compare/%s...%s", url.PathEscape(commit), url.PathEscape(rev)))
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	var result CompareResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return false, fmt.Errorf("failed to parse compare response: %w", err)
+	}
+	return result.BehindBy == 0, nil
+}
+
+// getCommitDate returns the full SHA and commit date of rev. Listing the
+// commits starting at rev returns the same fields as getting it, without its
+// changed files.
+func (c *Client) getCommitDate(ctx context.Context, rev string) (*Landing, error) {
+	body, err := c.doRequest(ctx, http.MethodGet, c.repoPath("commits?sha=%s&per_page=1", url.QueryEscape(rev)))
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []commitResponse
+	if err := json.Unmarshal(body, &commits); err != nil {
+		return nil, fmt.Errorf("failed to parse commits response: %w", err)
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("commit %s not found", rev)
+	}
+	return &Landing{SHA: commits[0].SHA, Date: commits[0].Commit.Committer.Date}, nil
+}
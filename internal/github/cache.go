@@ -0,0 +1,60 @@
+package github
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultNegativeCacheTTL is how long results that can still change, such as
+// a commit missing from a channel or an unmerged PR, are cached.
+const DefaultNegativeCacheTTL = 10 * time.Minute
+
+func (c *Client) pullRequestCacheKey(number int) string {
+	return fmt.Sprintf("pulls/%s/%d", c.Repo, number)
+}
+
+func (c *Client) compareCacheKey(commit, branch string) string {
+	return fmt.Sprintf("compare/%s/%s...%s", c.Repo, commit, branch)
+}
+
+func (c *Client) landingCacheKey(commit, branch string) string {
+	return fmt.Sprintf("landing/%s/%s/%s", c.Repo, commit, branch)
+}
+
+func (c *Client) commitCacheKey(sha string) string {
+	return fmt.Sprintf("commits/%s/%s", c.Repo, sha)
+}
+
+func (c *Client) commitPullsCacheKey(sha string) string {
+	return fmt.Sprintf("commits/%s/%s/pulls", c.Repo, sha)
+}
+
+// cacheGet reads key from the cache, if one is configured.
+func (c *Client) cacheGet(key string, v any) bool {
+	if c.Cache == nil || !c.Cache.Get(key, v) {
+		return false
+	}
+	c.log.Debug("cache hit", zap.String("key", key))
+	return true
+}
+
+// cachePut writes v to the cache, if one is configured. Results that are
+// final are kept forever; others expire after NegativeCacheTTL and are not
+// cached at all if it is zero.
+func (c *Client) cachePut(key string, v any, final bool) {
+	if c.Cache == nil {
+		return
+	}
+	var ttl time.Duration
+	if !final {
+		if c.NegativeCacheTTL <= 0 {
+			return
+		}
+		ttl = c.NegativeCacheTTL
+	}
+	if err := c.Cache.Put(key, v, ttl); err != nil {
+		c.log.Debug("failed to write cache entry", zap.String("key", key), zap.Error(err))
+	}
+}
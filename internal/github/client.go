@@ -1,5 +1,6 @@
 // Package github provides a client for interacting with the GitHub API
-// to fetch pull request and commit information from NixOS/nixpkgs.
+// to fetch pull request and commit information from NixOS/nixpkgs and other
+// repositories.
 package github
 
 import (
@@ -9,9 +10,15 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/cache"
+	"github.com/thatsneat-dev/nprt/internal/config"
 )
 
 const (
@@ -26,14 +33,115 @@ const (
 	StateDraft  = "draft"
 )
 
-// Client is a GitHub API client configured for NixOS/nixpkgs.
+// Client is a GitHub API client for a single repository, NixOS/nixpkgs by
+// default.
 type Client struct {
 	BaseURL       string
+	Repo          config.Repo
 	Token         string
 	UserAgent     string
 	HTTPClient    *http.Client
 	TimelinePages int
-	log           *zap.Logger
+	// Cache, if set, stores PR metadata and compare results across runs.
+	Cache *cache.Store
+	// NegativeCacheTTL is how long cached results that can still change are
+	// kept; zero neither stores nor reads them.
+	NegativeCacheTTL time.Duration
+	// Validators, if set, stores ETag and Last-Modified headers so that
+	// repeated GET requests can be answered with 304 Not Modified.
+	Validators ValidatorStore
+	// Retry controls how failed idempotent requests are retried.
+	Retry RetryPolicy
+	log   *zap.Logger
+
+	mu        sync.Mutex
+	rateLimit *RateLimit
+}
+
+// RateLimit is the rate limit budget reported by a GitHub API response.
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Used      int       `json:"used"`
+	Reset     time.Time `json:"reset,omitzero"`
+	// Resource is the budget the request counted against, such as "core"
+	// for the REST API or "graphql".
+	Resource string `json:"resource,omitempty"`
+}
+
+// rateLimitExceededMessage is the error message for an exhausted budget.
+const rateLimitExceededMessage = "GitHub API rate limit exceeded. Try again later or set GITHUB_TOKEN."
+
+// ResetMessage describes when the budget resets relative to now, e.g.
+// "resets at 15:04 (in 12m)".
+func (rl RateLimit) ResetMessage(now time.Time) string {
+	if rl.Reset.IsZero() {
+		return ""
+	}
+	wait := max(rl.Reset.Sub(now), 0).Round(time.Minute)
+	if wait < time.Minute {
+		return fmt.Sprintf("resets at %s (in less than a minute)", rl.Reset.Local().Format("15:04"))
+	}
+	return fmt.Sprintf("resets at %s (in %s)", rl.Reset.Local().Format("15:04"), strings.TrimSuffix(wait.String(), "0s"))
+}
+
+// RateLimit returns the budget reported by the most recent response that
+// carried rate limit headers, or false if no such response has been seen.
+func (c *Client) RateLimit() (RateLimit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rateLimit == nil {
+		return RateLimit{}, false
+	}
+	return *c.rateLimit, true
+}
+
+// parseRateLimit reads the X-RateLimit-* headers of a response. It returns
+// false if the response carries no rate limit headers.
+func parseRateLimit(h http.Header) (RateLimit, bool) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	rl := RateLimit{Remaining: remaining, Resource: h.Get("X-RateLimit-Resource")}
+	rl.Limit, _ = strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	rl.Used, _ = strconv.Atoi(h.Get("X-RateLimit-Used"))
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(reset, 0)
+	}
+	return rl, true
+}
+
+// recordRateLimit stores the rate limit headers of a response, if present.
+func (c *Client) recordRateLimit(h http.Header) {
+	rl, ok := parseRateLimit(h)
+	if !ok {
+		return
+	}
+
+	c.log.Debug("rate limit",
+		zap.String("resource", rl.Resource),
+		zap.Int("limit", rl.Limit),
+		zap.Int("remaining", rl.Remaining),
+		zap.Int("used", rl.Used),
+		zap.Time("reset", rl.Reset))
+
+	c.mu.Lock()
+	c.rateLimit = &rl
+	c.mu.Unlock()
+}
+
+// rateLimitError returns the error for a response that exhausted the rate
+// limit, including when the budget resets if the response says so.
+func rateLimitError(status int, h http.Header) *APIError {
+	msg := rateLimitExceededMessage
+	if rl, ok := parseRateLimit(h); ok {
+		if reset := rl.ResetMessage(time.Now()); reset != "" {
+			msg = fmt.Sprintf("GitHub API rate limit exceeded (%d/%d used); it %s. Try again later or set GITHUB_TOKEN.",
+				rl.Used, rl.Limit, reset)
+		}
+	}
+	return &APIError{StatusCode: status, Message: msg, RateLimited: true}
 }
 
 // PullRequest represents a GitHub pull request with relevant fields.
@@ -50,6 +158,12 @@ type PullRequest struct {
 	Base struct {
 		Ref string `json:"ref"`
 	} `json:"base"`
+	Labels []Label `json:"labels"`
+}
+
+// Label is a GitHub issue or pull request label.
+type Label struct {
+	Name string `json:"name"`
 }
 
 // CompareResult represents the result of comparing two commits or branches.
@@ -63,6 +177,9 @@ type CompareResult struct {
 type APIError struct {
 	StatusCode int
 	Message    string
+	// RateLimited is set when the request failed because a primary or
+	// secondary rate limit was exhausted.
+	RateLimited bool
 }
 
 func (e *APIError) Error() string {
@@ -72,11 +189,12 @@ func (e *APIError) Error() string {
 // NotFoundError indicates that no issue or pull request exists with the given number.
 // Callers can detect this with: var nf *NotFoundError; errors.As(err, &nf)
 type NotFoundError struct {
+	Repo   config.Repo
 	Number int
 }
 
 func (e *NotFoundError) Error() string {
-	return fmt.Sprintf("no PR or issue #%d exists in NixOS/nixpkgs", e.Number)
+	return fmt.Sprintf("no PR or issue #%d exists in %s", e.Number, e.Repo)
 }
 
 // NotPullRequestError indicates that the number exists but is an Issue, not a PR.
@@ -120,10 +238,13 @@ type Issue struct {
 // NewClient creates a new GitHub API client with the given token, user agent, and logger.
 func NewClient(token string, userAgent string, log *zap.Logger) *Client {
 	return &Client{
-		BaseURL:       DefaultBaseURL,
-		Token:         token,
-		UserAgent:     userAgent,
-		TimelinePages: DefaultTimelinePages,
+		BaseURL:          DefaultBaseURL,
+		Repo:             config.DefaultRepo,
+		Token:            token,
+		UserAgent:        userAgent,
+		TimelinePages:    DefaultTimelinePages,
+		NegativeCacheTTL: DefaultNegativeCacheTTL,
+		Retry:            DefaultRetryPolicy(),
 		HTTPClient: &http.Client{
 			Timeout: DefaultTimeout,
 		},
@@ -131,6 +252,12 @@ func NewClient(token string, userAgent string, log *zap.Logger) *Client {
 	}
 }
 
+// repoPath returns the REST API path of a resource of the client's
+// repository, e.g. repoPath("pulls/%d", 1) for /repos/NixOS/nixpkgs/pulls/1.
+func (c *Client) repoPath(format string, args ...any) string {
+	return fmt.Sprintf("/repos/%s/%s/", url.PathEscape(c.Repo.Owner), url.PathEscape(c.Repo.Name)) + fmt.Sprintf(format, args...)
+}
+
 func (c *Client) doRequest(ctx context.Context, method, path string) ([]byte, error) {
 	return c.doRequestWithAccept(ctx, method, path, "application/vnd.github.v3+json")
 }
@@ -147,11 +274,18 @@ func extractAPIMessage(body []byte) string {
 	return string(body)
 }
 
-// GetPullRequest fetches a pull request by number from NixOS/nixpkgs.
-// If the number is an Issue (not a PR), returns NotPullRequestError.
+// GetPullRequest fetches a pull request by number from the client's
+// repository. If the number is an Issue (not a PR), returns NotPullRequestError.
 // If the number doesn't exist at all, returns NotFoundError.
+// Merged PRs are cached permanently since their merge commit cannot change.
 func (c *Client) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
-	path := fmt.Sprintf("/repos/NixOS/nixpkgs/pulls/%d", number)
+	key := c.pullRequestCacheKey(number)
+	var cached PullRequest
+	if c.cacheGet(key, &cached) && (cached.Merged || c.NegativeCacheTTL > 0) {
+		return &cached, nil
+	}
+
+	path := c.repoPath("pulls/%d", number)
 
 	body, err := c.doRequest(ctx, http.MethodGet, path)
 	if err != nil {
@@ -167,12 +301,13 @@ func (c *Client) GetPullRequest(ctx context.Context, number int) (*PullRequest,
 		return nil, fmt.Errorf("failed to parse PR response: %w", err)
 	}
 
+	c.cachePut(key, &pr, pr.Merged)
 	return &pr, nil
 }
 
-// GetIssue fetches an issue by number from NixOS/nixpkgs.
+// GetIssue fetches an issue by number from the client's repository.
 func (c *Client) GetIssue(ctx context.Context, number int) (*Issue, error) {
-	path := fmt.Sprintf("/repos/NixOS/nixpkgs/issues/%d", number)
+	path := c.repoPath("issues/%d", number)
 
 	body, err := c.doRequest(ctx, http.MethodGet, path)
 	if err != nil {
@@ -209,15 +344,23 @@ func (c *Client) disambiguateNotFound(ctx context.Context, number int) error {
 
 	var apiErr *APIError
 	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
-		return &NotFoundError{Number: number}
+		return &NotFoundError{Repo: c.Repo, Number: number}
 	}
 
 	return err
 }
 
 // CompareCommitWithBranch checks if a commit is present in a branch.
+// Results showing the commit in the branch are cached permanently; others
+// are cached for NegativeCacheTTL.
 func (c *Client) CompareCommitWithBranch(ctx context.Context, commit, branch string) (*CompareResult, error) {
-	path := fmt.Sprintf("/repos/NixOS/nixpkgs/compare/%s...%s", url.PathEscape(commit), url.PathEscape(branch))
+	key := c.compareCacheKey(commit, branch)
+	var cached CompareResult
+	if c.cacheGet(key, &cached) && (cached.BehindBy == 0 || c.NegativeCacheTTL > 0) {
+		return &cached, nil
+	}
+
+	path := c.repoPath("compare/%s...%s", url.PathEscape(commit), url.PathEscape(branch))
 
 	body, err := c.doRequest(ctx, http.MethodGet, path)
 	if err != nil {
@@ -229,5 +372,6 @@ func (c *Client) CompareCommitWithBranch(ctx context.Context, commit, branch str
 		return nil, fmt.Errorf("failed to parse compare response: %w", err)
 	}
 
+	c.cachePut(key, &result, result.BehindBy == 0)
 	return &result, nil
 }
@@ -0,0 +1,92 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+)
+
+// CommitNotFoundError indicates that no commit matches a SHA.
+// Callers can detect this with: var nf *CommitNotFoundError; errors.As(err, &nf)
+type CommitNotFoundError struct {
+	Repo config.Repo
+	SHA  string
+}
+
+func (e *CommitNotFoundError) Error() string {
+	return fmt.Sprintf("no commit %s exists in %s", e.SHA, e.Repo)
+}
+
+// ResolveCommit returns the full SHA of the commit that sha, which may be
+// abbreviated, names in the client's repository. A full SHA always names the
+// same commit, so it is cached permanently; an abbreviated one may become
+// ambiguous as commits are added, so it expires after NegativeCacheTTL.
+func (c *Client) ResolveCommit(ctx context.Context, sha string) (string, error) {
+	key := c.commitCacheKey(sha)
+	var cached string
+	if c.cacheGet(key, &cached) {
+		return cached, nil
+	}
+
+	// The sha media type returns the bare SHA instead of the whole commit.
+	body, err := c.doRequestWithAccept(ctx, http.MethodGet, c.repoPath("commits/%s", sha), "application/vnd.github.sha")
+	if err != nil {
+		var apiErr *APIError
+		// An ambiguous or malformed SHA is reported as 422.
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusUnprocessableEntity) {
+			return "", &CommitNotFoundError{Repo: c.Repo, SHA: sha}
+		}
+		return "", err
+	}
+
+	full := strings.TrimSpace(string(body))
+	if len(full) != 40 {
+		return "", fmt.Errorf("unexpected commit response for %s: %q", sha, full)
+	}
+	c.cachePut(key, full, len(sha) == 40)
+	return full, nil
+}
+
+// GetCommitPullRequests returns the pull requests associated with a commit:
+// the one that merged it into the default branch, or the open ones that
+// contain it. sha must be a full SHA.
+func (c *Client) GetCommitPullRequests(ctx context.Context, sha string) ([]PullRequest, error) {
+	key := c.commitPullsCacheKey(sha)
+	var cached []PullRequest
+	if c.cacheGet(key, &cached) {
+		return cached, nil
+	}
+
+	body, err := c.doRequestWithAccept(ctx, http.MethodGet, c.repoPath("commits/%s/pulls", sha), "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+
+	// Pull request lists report merged_at rather than merged.
+	var resp []struct {
+		PullRequest
+		MergedAt *string `json:"merged_at"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse commit pull requests response: %w", err)
+	}
+
+	prs := make([]PullRequest, len(resp))
+	for i, r := range resp {
+		prs[i] = r.PullRequest
+		prs[i].Merged = r.MergedAt != nil
+	}
+	c.log.Debug("found pull requests of commit", zap.String("commit", sha), zap.Int("count", len(prs)))
+
+	// A commit can still be merged through another pull request, so the
+	// list is not final.
+	c.cachePut(key, prs, false)
+	return prs, nil
+}
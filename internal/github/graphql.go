@@ -0,0 +1,345 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+)
+
+// graphQLPath stands for the GraphQL endpoint in requests; see graphQLURL.
+const graphQLPath = "/graphql"
+
+// graphQLURL returns the GraphQL endpoint, which GitHub Enterprise Server
+// serves at /api/graphql rather than below its /api/v3 REST API.
+func (c *Client) graphQLURL() string {
+	return config.Host{APIURL: c.BaseURL}.GraphQLURL()
+}
+
+// BranchComparison is the result of comparing a commit with one branch as
+// part of a batch. Exactly one of Result and Err is set.
+type BranchComparison struct {
+	Result *CompareResult
+	Err    error
+}
+
+// GraphQLClient answers PR lookups and channel comparisons with the GitHub
+// GraphQL API, which compares a commit with every channel in one request and
+// without the commit and file lists of the REST compare API. The GraphQL API
+// requires a token. Everything else is delegated to the REST Client.
+type GraphQLClient struct {
+	*Client
+}
+
+// NewGraphQLClient creates a GraphQLClient that shares c's settings, cache
+// and rate limit tracking.
+func NewGraphQLClient(c *Client) *GraphQLClient {
+	return &GraphQLClient{Client: c}
+}
+
+// graphQLRequest is the payload of a GraphQL query.
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+// graphQLError is a single entry of the errors list of a GraphQL response.
+type graphQLError struct {
+	Type    string   `json:"type"`
+	Message string   `json:"message"`
+	Path    []string `json:"path"`
+}
+
+// query runs a GraphQL query and decodes its data into out. Errors are
+// returned alongside the data, since GraphQL reports missing objects as
+// errors while still answering the rest of the query.
+func (g *GraphQLClient) query(ctx context.Context, query string, vars map[string]any, out any) ([]graphQLError, error) {
+	payload, err := json.Marshal(graphQLRequest{Query: query, Variables: vars})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode GraphQL query: %w", err)
+	}
+
+	body, err := g.doRequestWithBody(ctx, http.MethodPost, graphQLPath, "application/json", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL response: %w", err)
+	}
+
+	for _, e := range resp.Errors {
+		if e.Type == "RATE_LIMITED" {
+			return nil, &APIError{
+				StatusCode:  http.StatusForbidden,
+				Message:     rateLimitExceededMessage,
+				RateLimited: true,
+			}
+		}
+	}
+
+	if len(resp.Data) == 0 || string(resp.Data) == "null" {
+		if len(resp.Errors) > 0 {
+			return nil, fmt.Errorf("GitHub GraphQL error: %s", resp.Errors[0].Message)
+		}
+		return nil, fmt.Errorf("GitHub GraphQL response has no data")
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL response: %w", err)
+	}
+	return resp.Errors, nil
+}
+
+const pullRequestQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      number
+      title
+      state
+      isDraft
+      merged
+      mergeCommit { oid }
+      author { login }
+      baseRefName
+      labels(first: 100) { nodes { name } }
+    }
+  }
+}`
+
+// graphQLPullRequest is the pullRequest object selected by pullRequestQuery.
+type graphQLPullRequest struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	State       string `json:"state"`
+	IsDraft     bool   `json:"isDraft"`
+	Merged      bool   `json:"merged"`
+	MergeCommit *struct {
+		OID string `json:"oid"`
+	} `json:"mergeCommit"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	BaseRefName string `json:"baseRefName"`
+	Labels      struct {
+		Nodes []Label `json:"nodes"`
+	} `json:"labels"`
+}
+
+// toREST converts p to the shape returned by the REST API, so that cached
+// entries are interchangeable between the two clients.
+func (p *graphQLPullRequest) toREST() *PullRequest {
+	pr := &PullRequest{
+		Number: p.Number,
+		Title:  p.Title,
+		// The REST API reports merged PRs as closed.
+		State:  StateClosed,
+		Draft:  p.IsDraft,
+		Merged: p.Merged,
+		Labels: p.Labels.Nodes,
+	}
+	if p.State == "OPEN" {
+		pr.State = StateOpen
+	}
+	if p.MergeCommit != nil {
+		pr.MergeCommitSHA = p.MergeCommit.OID
+	}
+	if p.Author != nil {
+		pr.User.Login = p.Author.Login
+	}
+	pr.Base.Ref = p.BaseRefName
+	return pr
+}
+
+// GetPullRequest fetches a pull request by number from the client's
+// repository. If no pull request exists with that number, the REST API is
+// used to tell issues from missing numbers, as the REST Client does.
+func (g *GraphQLClient) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	key := g.pullRequestCacheKey(number)
+	var cached PullRequest
+	if g.cacheGet(key, &cached) && (cached.Merged || g.NegativeCacheTTL > 0) {
+		return &cached, nil
+	}
+
+	var data struct {
+		Repository struct {
+			PullRequest *graphQLPullRequest `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	vars := map[string]any{"owner": g.Repo.Owner, "name": g.Repo.Name, "number": number}
+	errs, err := g.query(ctx, pullRequestQuery, vars, &data)
+	if err != nil {
+		return nil, err
+	}
+	if data.Repository.PullRequest == nil {
+		for _, e := range errs {
+			if e.Type != "NOT_FOUND" {
+				return nil, fmt.Errorf("GitHub GraphQL error: %s", e.Message)
+			}
+		}
+		return nil, g.disambiguateNotFound(ctx, number)
+	}
+
+	pr := data.Repository.PullRequest.toREST()
+	g.cachePut(key, pr, pr.Merged)
+	return pr, nil
+}
+
+// CompareCommitWithBranch checks if a commit is present in a branch.
+func (g *GraphQLClient) CompareCommitWithBranch(ctx context.Context, commit, branch string) (*CompareResult, error) {
+	comparisons, err := g.CompareCommitWithBranches(ctx, commit, []string{branch})
+	if err != nil {
+		return nil, err
+	}
+	return comparisons[0].Result, comparisons[0].Err
+}
+
+// graphQLComparison is the comparison selected for each branch alias.
+type graphQLComparison struct {
+	Compare *struct {
+		AheadBy  int    `json:"aheadBy"`
+		BehindBy int    `json:"behindBy"`
+		Status   string `json:"status"`
+	} `json:"compare"`
+}
+
+// compareStatuses maps the status of a branch...commit comparison to the
+// REST status of the reverse commit...branch comparison.
+var compareStatuses = map[string]string{
+	"AHEAD":     "behind",
+	"BEHIND":    "ahead",
+	"DIVERGED":  "diverged",
+	"IDENTICAL": "identical",
+}
+
+// CompareCommitWithBranches compares a commit with every branch in a single
+// query, with one aliased ref per branch. Results are in the order of
+// branches and cached like those of CompareCommitWithBranch; only branches
+// without a cached result are queried.
+func (g *GraphQLClient) CompareCommitWithBranches(ctx context.Context, commit string, branches []string) ([]BranchComparison, error) {
+	comparisons := make([]BranchComparison, len(branches))
+
+	var (
+		pending []int
+		fields  strings.Builder
+		params  []string
+	)
+	vars := map[string]any{"owner": g.Repo.Owner, "name": g.Repo.Name, "commit": commit}
+	for i, branch := range branches {
+		var cached CompareResult
+		if g.cacheGet(g.compareCacheKey(commit, branch), &cached) && (cached.BehindBy == 0 || g.NegativeCacheTTL > 0) {
+			comparisons[i].Result = &cached
+			continue
+		}
+
+		alias := fmt.Sprintf("b%d", len(pending))
+		pending = append(pending, i)
+		vars[alias] = "refs/heads/" + branch
+		params = append(params, fmt.Sprintf("$%s: String!", alias))
+		fmt.Fprintf(&fields, "    %s: ref(qualifiedName: $%s) { compare(headRef: $commit) { aheadBy behindBy status } }\n", alias, alias)
+	}
+	if len(pending) == 0 {
+		return comparisons, nil
+	}
+
+	query := fmt.Sprintf("query($owner: String!, $name: String!, $commit: String!, %s) {\n  repository(owner: $owner, name: $name) {\n%s  }\n}",
+		strings.Join(params, ", "), fields.String())
+
+	g.log.Debug("comparing branches with GraphQL",
+		zap.String("commit", commit),
+		zap.Int("branches", len(pending)),
+		zap.Int("cached", len(branches)-len(pending)))
+
+	var data struct {
+		Repository map[string]*graphQLComparison `json:"repository"`
+	}
+	if _, err := g.query(ctx, query, vars, &data); err != nil {
+		return nil, err
+	}
+
+	for n, i := range pending {
+		branch := branches[i]
+		ref := data.Repository[fmt.Sprintf("b%d", n)]
+		if ref == nil || ref.Compare == nil {
+			comparisons[i].Err = &APIError{
+				StatusCode: http.StatusNotFound,
+				Message:    fmt.Sprintf("branch %s or commit %s not found", branch, commit),
+			}
+			continue
+		}
+
+		// The query compares branch...commit, so ahead and behind swap
+		// relative to the REST commit...branch comparison.
+		result := &CompareResult{
+			Status:   compareStatuses[ref.Compare.Status],
+			AheadBy:  ref.Compare.BehindBy,
+			BehindBy: ref.Compare.AheadBy,
+		}
+		g.cachePut(g.compareCacheKey(commit, branch), result, result.BehindBy == 0)
+		comparisons[i].Result = result
+	}
+
+	return comparisons, nil
+}
+
+// graphQLBranchHead is the head commit selected for each branch alias.
+type graphQLBranchHead struct {
+	Target *struct {
+		OID           string    `json:"oid"`
+		CommittedDate time.Time `json:"committedDate"`
+	} `json:"target"`
+}
+
+// GetBranchHeads returns the head commit of each branch, in the order of
+// branches, in a single query with one aliased ref per branch.
+func (g *GraphQLClient) GetBranchHeads(ctx context.Context, branches []string) ([]BranchHead, error) {
+	heads := make([]BranchHead, len(branches))
+	if len(branches) == 0 {
+		return heads, nil
+	}
+
+	var (
+		fields strings.Builder
+		params []string
+	)
+	vars := map[string]any{"owner": g.Repo.Owner, "name": g.Repo.Name}
+	for i, branch := range branches {
+		alias := fmt.Sprintf("b%d", i)
+		vars[alias] = "refs/heads/" + branch
+		params = append(params, fmt.Sprintf("$%s: String!", alias))
+		fmt.Fprintf(&fields, "    %s: ref(qualifiedName: $%s) { target { oid ... on Commit { committedDate } } }\n", alias, alias)
+	}
+	query := fmt.Sprintf("query($owner: String!, $name: String!, %s) {\n  repository(owner: $owner, name: $name) {\n%s  }\n}",
+		strings.Join(params, ", "), fields.String())
+
+	g.log.Debug("looking up branch heads with GraphQL", zap.Int("branches", len(branches)))
+
+	var data struct {
+		Repository map[string]*graphQLBranchHead `json:"repository"`
+	}
+	if _, err := g.query(ctx, query, vars, &data); err != nil {
+		return nil, err
+	}
+
+	for i, branch := range branches {
+		ref := data.Repository[fmt.Sprintf("b%d", i)]
+		if ref == nil || ref.Target == nil {
+			heads[i].Err = &APIError{
+				StatusCode: http.StatusNotFound,
+				Message:    fmt.Sprintf("branch %s not found", branch),
+			}
+			continue
+		}
+		heads[i] = BranchHead{SHA: ref.Target.OID, Date: ref.Target.CommittedDate}
+	}
+	return heads, nil
+}
@@ -0,0 +1,145 @@
+package github
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultRetries is how many times a failed request is retried.
+	DefaultRetries = 3
+	// DefaultMaxWait is the longest a request waits for a rate limit to
+	// reset before it fails instead.
+	DefaultMaxWait = time.Minute
+)
+
+// secondaryRateLimitWait is how long to wait after hitting a secondary rate
+// limit that did not say when to retry, as recommended by GitHub.
+const secondaryRateLimitWait = time.Minute
+
+// RetryPolicy controls how failed idempotent requests are retried.
+type RetryPolicy struct {
+	// MaxRetries is how many times a request is retried; zero disables
+	// retries.
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry. It doubles with every
+	// further retry, up to MaxDelay, and is randomized to spread out
+	// concurrent requests.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxWait is the longest a request waits for Retry-After or
+	// X-RateLimit-Reset. Rate limits that reset later fail immediately.
+	MaxWait time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used by NewClient.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: DefaultRetries,
+		BaseDelay:  time.Second,
+		MaxDelay:   30 * time.Second,
+		MaxWait:    DefaultMaxWait,
+	}
+}
+
+// retryHint describes whether a failed attempt may be retried, and how long
+// the server asked to wait first. A zero wait means exponential backoff.
+type retryHint struct {
+	retry bool
+	wait  time.Duration
+}
+
+// delay returns how long to wait before retry number attempt (starting at
+// zero), or false if the request should not be retried.
+func (p RetryPolicy) delay(attempt int, hint retryHint) (time.Duration, bool) {
+	if !hint.retry || attempt >= p.MaxRetries {
+		return 0, false
+	}
+	if hint.wait > 0 {
+		return hint.wait, hint.wait <= p.MaxWait
+	}
+
+	d := p.BaseDelay << attempt
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	// Keep at least half of the delay and randomize the rest.
+	if half := d / 2; half > 0 {
+		d = half + rand.N(half)
+	}
+	return d, true
+}
+
+// rateLimitHint decides whether a 403 or 429 response is worth retrying.
+// Primary rate limits are retried once they reset; secondary rate limits
+// after Retry-After or a minute. Other 403s, such as auth failures, are not
+// retried.
+func rateLimitHint(status int, h http.Header, body []byte, now time.Time) retryHint {
+	if secs, err := strconv.Atoi(h.Get("Retry-After")); err == nil && secs >= 0 {
+		return retryHint{retry: true, wait: max(time.Duration(secs)*time.Second, time.Millisecond)}
+	}
+	if h.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// Add a second, since the reset time is truncated to seconds.
+			wait := time.Unix(reset, 0).Sub(now) + time.Second
+			return retryHint{retry: true, wait: max(wait, time.Millisecond)}
+		}
+	}
+	if strings.Contains(strings.ToLower(extractAPIMessage(body)), "secondary rate limit") {
+		return retryHint{retry: true, wait: secondaryRateLimitWait}
+	}
+	return retryHint{retry: status == http.StatusTooManyRequests}
+}
+
+// isIdempotent reports whether a request can safely be repeated. nprt only
+// sends GraphQL queries, never mutations.
+func isIdempotent(method, path string) bool {
+	return method == http.MethodGet || method == http.MethodHead ||
+		(method == http.MethodPost && path == graphQLPath)
+}
+
+// doRequestWithBody performs an HTTP request with a custom Accept header and
+// an optional JSON payload, retrying idempotent requests according to the
+// client's RetryPolicy.
+func (c *Client) doRequestWithBody(ctx context.Context, method, path, accept string, payload []byte) ([]byte, error) {
+	body, _, err := c.doRequestWithHeaders(ctx, method, path, accept, payload)
+	return body, err
+}
+
+// doRequestWithHeaders is doRequestWithBody, but also returns the headers of
+// the final response.
+func (c *Client) doRequestWithHeaders(ctx context.Context, method, path, accept string, payload []byte) ([]byte, http.Header, error) {
+	retryable := isIdempotent(method, path)
+
+	for attempt := 0; ; attempt++ {
+		body, header, hint, err := c.doAttempt(ctx, method, path, accept, payload)
+		if err == nil || !retryable {
+			return body, header, err
+		}
+
+		wait, ok := c.Retry.delay(attempt, hint)
+		if !ok {
+			return nil, header, err
+		}
+
+		c.log.Debug("retrying request",
+			zap.String("path", path),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("wait", wait),
+			zap.Error(err))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, header, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -1,23 +1,27 @@
 // Package github provides a client for interacting with the GitHub API
-// to fetch pull request and commit information from NixOS/nixpkgs.
+// to fetch pull request and commit information from NixOS/nixpkgs and other
+// repositories.
 package github
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 // RelatedPR represents a pull request that is cross-referenced from an issue.
 type RelatedPR struct {
-	Number int
-	Title  string
-	URL    string
-	State  string // "open", "closed", or "merged" (derived from merged_at presence)
+	Number int    `json:"pr"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	State  string `json:"state"` // "open", "closed", or "merged" (derived from merged_at presence)
 }
 
 // timelineEvent represents a single event from the issue timeline API.
@@ -40,6 +44,7 @@ type crossReferenceIssue struct {
 	HTMLURL     string                    `json:"html_url"`
 	PullRequest *crossReferencePR         `json:"pull_request,omitempty"`
 	Repository  *crossReferenceRepository `json:"repository,omitempty"`
+	Labels      []Label                   `json:"labels,omitempty"`
 }
 
 // crossReferencePR contains PR-specific fields from the cross-reference.
@@ -58,15 +63,38 @@ type crossReferenceRepository struct {
 const DefaultTimelinePages = 3
 
 // GetRelatedPRs fetches cross-referenced PRs from an issue's timeline.
-// Only returns PRs from the NixOS/nixpkgs repository.
+// Only returns PRs from the client's repository.
 // maxPages controls how many pages of timeline events to fetch (100 events per page).
 // Returns nil (not error) if the timeline cannot be fetched.
 func (c *Client) GetRelatedPRs(ctx context.Context, issueNumber int, maxPages int) []RelatedPR {
+	var related []RelatedPR
+	for _, issue := range c.fetchCrossReferencedPRs(ctx, issueNumber, maxPages) {
+		state := issue.State
+		if issue.PullRequest.MergedAt != nil && *issue.PullRequest.MergedAt != "" {
+			state = StateMerged
+		}
+
+		related = append(related, RelatedPR{
+			Number: issue.Number,
+			Title:  issue.Title,
+			URL:    issue.HTMLURL,
+			State:  state,
+		})
+	}
+
+	return related
+}
+
+// fetchCrossReferencedPRs pages through an issue's timeline and returns the
+// de-duplicated pull requests of the client's repository that
+// cross-reference it, in timeline order. Fetch errors end pagination early
+// rather than failing.
+func (c *Client) fetchCrossReferencedPRs(ctx context.Context, issueNumber int, maxPages int) []*crossReferenceIssue {
 	if maxPages <= 0 {
 		maxPages = DefaultTimelinePages
 	}
 
-	var related []RelatedPR
+	var related []*crossReferenceIssue
 	seen := make(map[int]bool)
 	pagesFetched := 0
 	totalEvents := 0
@@ -76,7 +104,7 @@ func (c *Client) GetRelatedPRs(ctx context.Context, issueNumber int, maxPages in
 		zap.Int("max_pages", maxPages))
 
 	for page := 1; page <= maxPages; page++ {
-		path := fmt.Sprintf("/repos/NixOS/nixpkgs/issues/%d/timeline?per_page=100&page=%d", issueNumber, page)
+		path := c.repoPath("issues/%d/timeline?per_page=100&page=%d", issueNumber, page)
 
 		body, err := c.doRequestWithAccept(ctx, http.MethodGet, path, "application/vnd.github+json")
 		if err != nil {
@@ -119,7 +147,7 @@ func (c *Client) GetRelatedPRs(ctx context.Context, issueNumber int, maxPages in
 				continue
 			}
 
-			if issue.Repository != nil && issue.Repository.FullName != "NixOS/nixpkgs" {
+			if issue.Repository != nil && !strings.EqualFold(issue.Repository.FullName, c.Repo.String()) {
 				continue
 			}
 
@@ -128,17 +156,7 @@ func (c *Client) GetRelatedPRs(ctx context.Context, issueNumber int, maxPages in
 			}
 			seen[issue.Number] = true
 
-			state := issue.State
-			if issue.PullRequest.MergedAt != nil && *issue.PullRequest.MergedAt != "" {
-				state = StateMerged
-			}
-
-			related = append(related, RelatedPR{
-				Number: issue.Number,
-				Title:  issue.Title,
-				URL:    issue.HTMLURL,
-				State:  state,
-			})
+			related = append(related, issue)
 		}
 	}
 
@@ -152,17 +170,37 @@ func (c *Client) GetRelatedPRs(ctx context.Context, issueNumber int, maxPages in
 }
 
 // doRequestWithAccept performs an HTTP request with a custom Accept header.
+// GET requests are made conditional when Validators holds a previous
+// response; a 304 Not Modified then returns the stored body, and does not
+// count against the rate limit.
 func (c *Client) doRequestWithAccept(ctx context.Context, method, path, accept string) ([]byte, error) {
+	return c.doRequestWithBody(ctx, method, path, accept, nil)
+}
+
+// doAttempt performs a single HTTP request with a custom Accept header and
+// an optional JSON payload, and returns the response body and headers. On
+// failure, it also reports whether and when the request may be retried.
+func (c *Client) doAttempt(ctx context.Context, method, path, accept string, payload []byte) ([]byte, http.Header, retryHint, error) {
 	url := c.BaseURL + path
+	if path == graphQLPath {
+		url = c.graphQLURL()
+	}
 
 	c.log.Debug("request", zap.String("method", method), zap.String("url", url))
 
-	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, retryHint{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Accept", accept)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	if c.UserAgent != "" {
 		req.Header.Set("User-Agent", c.UserAgent)
 	} else {
@@ -172,38 +210,64 @@ func (c *Client) doRequestWithAccept(ctx context.Context, method, path, accept s
 		req.Header.Set("Authorization", "Bearer "+c.Token)
 	}
 
+	var (
+		key    string
+		stored Validator
+		have   bool
+	)
+	if c.Validators != nil && method == http.MethodGet {
+		key = validatorKey(c.Token, accept, url)
+		if stored, have = c.Validators.Get(key); have {
+			setConditionalHeaders(req, stored)
+		}
+	}
+
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("network error talking to GitHub: %w", err)
+		// Connection resets and timeouts are transient, but a cancelled
+		// context is not.
+		return nil, nil, retryHint{retry: ctx.Err() == nil}, fmt.Errorf("network error talking to GitHub: %w", err)
 	}
 	defer resp.Body.Close()
 
 	c.log.Debug("response", zap.Int("status_code", resp.StatusCode), zap.String("status", resp.Status))
+	c.recordRateLimit(resp.Header)
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, resp.Header, retryHint{retry: ctx.Err() == nil}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotModified && have {
+		c.log.Debug("not modified, using stored response", zap.String("url", url))
+		return stored.Body, resp.Header, retryHint{}, nil
 	}
 
 	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		hint := rateLimitHint(resp.StatusCode, resp.Header, body, time.Now())
 		if resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.StatusCode == http.StatusTooManyRequests {
-			return nil, &APIError{
-				StatusCode: resp.StatusCode,
-				Message:    "GitHub API rate limit exceeded. Try again later or set GITHUB_TOKEN.",
-			}
+			return nil, resp.Header, hint, rateLimitError(resp.StatusCode, resp.Header)
 		}
-		return nil, &APIError{
-			StatusCode: resp.StatusCode,
-			Message:    extractAPIMessage(body),
+		// Only rate limits ask to be retried; other 403s are permission errors.
+		return nil, resp.Header, hint, &APIError{
+			StatusCode:  resp.StatusCode,
+			Message:     extractAPIMessage(body),
+			RateLimited: hint.retry,
 		}
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, &APIError{
+		return nil, resp.Header, retryHint{retry: resp.StatusCode >= 500}, &APIError{
 			StatusCode: resp.StatusCode,
 			Message:    extractAPIMessage(body),
 		}
 	}
 
-	return body, nil
+	if key != "" {
+		if v, ok := responseValidator(resp.Header, body); ok {
+			c.Validators.Put(key, v)
+		}
+	}
+
+	return body, resp.Header, retryHint{}, nil
 }
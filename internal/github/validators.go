@@ -0,0 +1,158 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/cache"
+)
+
+// ValidatorTTL is how long DiskValidators keeps a stored response. Entries
+// are refreshed whenever GitHub sends a new body, so this only bounds how
+// long responses for URLs that are no longer requested stay on disk.
+const ValidatorTTL = 7 * 24 * time.Hour
+
+// Validator is a stored response body together with the ETag and
+// Last-Modified headers GitHub sent with it. The body is trimmed of the
+// fields no Client method decodes.
+type Validator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Body         []byte `json:"body"`
+}
+
+// ValidatorStore keeps the most recent validator for each request. Keys
+// identify a request by its token, Accept header and URL.
+type ValidatorStore interface {
+	Get(key string) (Validator, bool)
+	Put(key string, v Validator)
+}
+
+// MemoryValidators is a ValidatorStore that lives for a single process,
+// which is enough for watch loops to revalidate instead of re-downloading.
+type MemoryValidators struct {
+	mu         sync.Mutex
+	validators map[string]Validator
+}
+
+// NewMemoryValidators creates an empty in-memory ValidatorStore.
+func NewMemoryValidators() *MemoryValidators {
+	return &MemoryValidators{validators: make(map[string]Validator)}
+}
+
+// Get returns the validator stored under key.
+func (m *MemoryValidators) Get(key string) (Validator, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.validators[key]
+	return v, ok
+}
+
+// Put stores v under key.
+func (m *MemoryValidators) Put(key string, v Validator) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.validators[key] = v
+}
+
+// DiskValidators is a ValidatorStore backed by a cache.Store, so validators
+// survive across runs.
+type DiskValidators struct {
+	store *cache.Store
+	log   *zap.Logger
+}
+
+// NewDiskValidators creates a ValidatorStore that persists to store.
+func NewDiskValidators(store *cache.Store, log *zap.Logger) *DiskValidators {
+	return &DiskValidators{store: store, log: log.Named("validators")}
+}
+
+// Get returns the validator stored under key.
+func (d *DiskValidators) Get(key string) (Validator, bool) {
+	var v Validator
+	ok := d.store.Get("etag/"+key, &v)
+	return v, ok
+}
+
+// Put stores v under key. Write failures only cost a future revalidation,
+// so they are logged rather than returned.
+func (d *DiskValidators) Put(key string, v Validator) {
+	if err := d.store.Put("etag/"+key, v, ValidatorTTL); err != nil {
+		d.log.Debug("failed to store validator", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// validatorKey identifies a request for a ValidatorStore. The Accept header
+// is part of the key because it changes the response body, and the token
+// because it changes what the response may contain. The token is hashed so
+// that it is not written to disk.
+func validatorKey(token, accept, url string) string {
+	identity := "anonymous"
+	if token != "" {
+		sum := sha256.Sum256([]byte(token))
+		identity = hex.EncodeToString(sum[:8])
+	}
+	return identity + " " + accept + " " + url
+}
+
+// unusedFields are fields of GitHub responses that no Client method decodes
+// but that can make up most of a response: a comparison of distant commits
+// lists hundreds of commits and files.
+var unusedFields = []string{"commits", "files", "base_commit", "merge_base_commit"}
+
+// trimBody removes unusedFields from a JSON object body so that they are not
+// stored. Fields with other names are kept, as are unusedFields that are not
+// objects or arrays, such as the commit count of a pull request. Bodies that
+// are not JSON objects are returned as is.
+func trimBody(body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+	trimmed := false
+	for _, name := range unusedFields {
+		if value, ok := fields[name]; ok && (bytes.HasPrefix(value, []byte("[")) || bytes.HasPrefix(value, []byte("{"))) {
+			delete(fields, name)
+			trimmed = true
+		}
+	}
+	if !trimmed {
+		return body
+	}
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// setConditionalHeaders adds If-None-Match and If-Modified-Since from v.
+func setConditionalHeaders(req *http.Request, v Validator) {
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+// responseValidator returns the validator for a successful response, or
+// false if the response carries neither an ETag nor a Last-Modified header.
+func responseValidator(h http.Header, body []byte) (Validator, bool) {
+	v := Validator{
+		ETag:         h.Get("ETag"),
+		LastModified: h.Get("Last-Modified"),
+	}
+	if v.ETag == "" && v.LastModified == "" {
+		return v, false
+	}
+	v.Body = trimBody(body)
+	return v, true
+}
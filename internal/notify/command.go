@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// CommandHook runs a shell command for every change. Details about the PR
+// and the change are passed in NPRT_* environment variables.
+type CommandHook struct {
+	Command string
+}
+
+func (h *CommandHook) String() string {
+	return "exec:" + h.Command
+}
+
+// Notify runs the command and waits for it to finish. A non-zero exit
+// status is reported as an error including the command's stderr.
+func (h *CommandHook) Notify(ctx context.Context, event Event) error {
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", h.Command)
+	cmd.Env = append(os.Environ(), EventEnv(event)...)
+	cmd.Stdout = os.Stderr
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// EventEnv returns the environment variables describing event.
+func EventEnv(event Event) []string {
+	s := event.Status
+	return []string{
+		"NPRT_REPO=" + s.Repo,
+		"NPRT_PR=" + strconv.Itoa(s.Number),
+		"NPRT_PR_TITLE=" + s.Title,
+		"NPRT_PR_AUTHOR=" + s.Author,
+		"NPRT_PR_STATE=" + string(s.State),
+		"NPRT_PR_URL=" + s.URL,
+		"NPRT_MERGE_COMMIT=" + s.MergeCommit,
+		"NPRT_COMMIT=" + s.Commit,
+		"NPRT_CHANNEL=" + event.Change.Name,
+		"NPRT_BRANCH=" + event.Change.Branch,
+		"NPRT_OLD_STATUS=" + string(event.Change.From),
+		"NPRT_NEW_STATUS=" + string(event.Change.To),
+	}
+}
@@ -0,0 +1,79 @@
+// Package notify delivers channel status changes to user-configured hooks:
+// external commands and HTTP webhooks.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+)
+
+// Event describes a single channel status change of a PR.
+type Event struct {
+	Status *core.PRStatus
+	Change core.ChannelChange
+}
+
+// Hook is notified about channel status changes.
+type Hook interface {
+	Notify(ctx context.Context, event Event) error
+	// String describes the hook for logs and error messages.
+	String() string
+}
+
+// ParseHook parses an --on-change specification:
+//
+//	exec:<command>   run command through the shell
+//	ntfy:<url>       POST a plain-text ntfy message to url
+//	json:<url>       POST the PR status as JSON to url
+//	<url>            same as json:<url>
+func ParseHook(spec string) (Hook, error) {
+	kind, rest, found := strings.Cut(spec, ":")
+	if found {
+		switch kind {
+		case "exec":
+			if strings.TrimSpace(rest) == "" {
+				return nil, fmt.Errorf("invalid hook %q: missing command", spec)
+			}
+			return &CommandHook{Command: rest}, nil
+		case "ntfy", "json":
+			if !isHTTPURL(rest) {
+				return nil, fmt.Errorf("invalid hook %q: expected an http(s) URL after %s:", spec, kind)
+			}
+			return NewWebhook(rest, WebhookFormat(kind)), nil
+		case "http", "https":
+			return NewWebhook(spec, FormatJSON), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid hook %q: must be exec:<command>, ntfy:<url>, json:<url> or an http(s) URL", spec)
+}
+
+func isHTTPURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// Dispatch notifies every hook about every change, in order. Failures are
+// logged and returned together but do not stop other notifications.
+func Dispatch(ctx context.Context, hooks []Hook, status *core.PRStatus, changes []core.ChannelChange, log *zap.Logger) []error {
+	log = log.Named("notify")
+
+	var errs []error
+	for _, change := range changes {
+		event := Event{Status: status, Change: change}
+		for _, hook := range hooks {
+			log.Debug("notifying hook",
+				zap.String("hook", hook.String()),
+				zap.String("channel", change.Name),
+				zap.String("to", string(change.To)))
+			if err := hook.Notify(ctx, event); err != nil {
+				log.Debug("hook failed", zap.String("hook", hook.String()), zap.Error(err))
+				errs = append(errs, fmt.Errorf("hook %s: %w", hook, err))
+			}
+		}
+	}
+	return errs
+}
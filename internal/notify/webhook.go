@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+)
+
+// WebhookFormat selects the request body sent by a Webhook.
+type WebhookFormat string
+
+const (
+	// FormatJSON posts the full core.PRStatus as JSON.
+	FormatJSON WebhookFormat = "json"
+	// FormatNtfy posts a plain-text message with ntfy headers.
+	FormatNtfy WebhookFormat = "ntfy"
+)
+
+const (
+	DefaultWebhookRetries = 3
+	DefaultWebhookBackoff = time.Second
+	DefaultWebhookTimeout = 10 * time.Second
+)
+
+// Webhook POSTs every change to a URL, retrying failed deliveries with
+// exponential backoff.
+type Webhook struct {
+	URL        string
+	Format     WebhookFormat
+	HTTPClient *http.Client
+	// Retries is the number of additional attempts after a failure.
+	Retries int
+	// Backoff is the delay before the first retry; it doubles each time.
+	Backoff time.Duration
+}
+
+// NewWebhook creates a Webhook with the default retry policy.
+func NewWebhook(url string, format WebhookFormat) *Webhook {
+	return &Webhook{
+		URL:        url,
+		Format:     format,
+		HTTPClient: &http.Client{Timeout: DefaultWebhookTimeout},
+		Retries:    DefaultWebhookRetries,
+		Backoff:    DefaultWebhookBackoff,
+	}
+}
+
+func (h *Webhook) String() string {
+	return string(h.Format) + ":" + h.URL
+}
+
+// Notify delivers event. Network errors, 429 and 5xx responses are retried;
+// other non-2xx responses fail immediately.
+func (h *Webhook) Notify(ctx context.Context, event Event) error {
+	body, header, err := h.encode(event)
+	if err != nil {
+		return err
+	}
+
+	backoff := h.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := h.post(ctx, body, header)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= h.Retries {
+			return err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
+// post sends a single request and reports whether a failure is retryable.
+func (h *Webhook) post(ctx context.Context, body []byte, header http.Header) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = header.Clone()
+
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook returned %s", resp.Status)
+}
+
+func (h *Webhook) encode(event Event) ([]byte, http.Header, error) {
+	header := make(http.Header)
+	header.Set("X-Nprt-PR", strconv.Itoa(event.Status.Number))
+	header.Set("X-Nprt-Channel", event.Change.Name)
+	header.Set("X-Nprt-Status", string(event.Change.To))
+
+	switch h.Format {
+	case FormatNtfy:
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+		header.Set("Title", fmt.Sprintf("%s: %s", event.Status.Subject(), event.Change.Name))
+		header.Set("Click", event.Status.URL)
+		if event.Change.To == core.StatusPresent {
+			header.Set("Tags", "white_check_mark")
+		}
+		return []byte(NtfyMessage(event)), header, nil
+	default:
+		header.Set("Content-Type", "application/json")
+		body, err := json.Marshal(event.Status)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode status: %w", err)
+		}
+		return body, header, nil
+	}
+}
+
+// NtfyMessage returns the plain-text message sent to ntfy-style endpoints.
+func NtfyMessage(event Event) string {
+	s := event.Status
+	subject := s.Subject()
+	if s.Title != "" {
+		subject += fmt.Sprintf(" (%s)", s.Title)
+	}
+
+	switch event.Change.To {
+	case core.StatusPresent:
+		return fmt.Sprintf("%s reached %s", subject, event.Change.Name)
+	case core.StatusNotPresent:
+		return fmt.Sprintf("%s is no longer in %s", subject, event.Change.Name)
+	default:
+		return fmt.Sprintf("%s: %s is %s", subject, event.Change.Name, event.Change.To)
+	}
+}
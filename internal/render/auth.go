@@ -0,0 +1,86 @@
+package render
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+// RenderAuthStatus outputs whether a token is configured, who it belongs to,
+// its scopes and the remaining budget of each rate limit resource.
+func (r *Renderer) RenderAuthStatus(status *github.AuthStatus, now time.Time) error {
+	r.writeErr = nil
+
+	if status.TokenSet {
+		token := r.colorize("set", colorGreen)
+		if status.TokenSource != "" {
+			token += " (from " + sanitize(status.TokenSource) + ")"
+		}
+		r.printf("Token:   %s\n", token)
+		r.printf("Login:   %s\n", sanitize(status.Login))
+		scopes := "none"
+		if len(status.Scopes) > 0 {
+			scopes = strings.Join(status.Scopes, ", ")
+		}
+		r.printf("Scopes:  %s\n", sanitize(scopes))
+	} else {
+		r.printf("Token:   %s\n", r.colorize("not set", colorYellow))
+	}
+
+	names := make([]string, 0, len(status.RateLimits))
+	for name := range status.RateLimits {
+		names = append(names, name)
+	}
+	// The budgets nprt uses come first.
+	slices.SortFunc(names, func(a, b string) int {
+		if pa, pb := rateLimitPriority(a), rateLimitPriority(b); pa != pb {
+			return pa - pb
+		}
+		return strings.Compare(a, b)
+	})
+
+	r.println()
+	for _, name := range names {
+		rl := status.RateLimits[name]
+		remaining := r.colorize(strconv.Itoa(rl.Remaining), colorGreen)
+		if rl.Limit > 0 && rl.Remaining*10 < rl.Limit {
+			remaining = r.colorize(strconv.Itoa(rl.Remaining), colorRed)
+		}
+		r.printf("%-22s %s/%d remaining", name, remaining, rl.Limit)
+		if reset := rl.ResetMessage(now); reset != "" && rl.Remaining < rl.Limit {
+			r.printf(", %s", reset)
+		}
+		r.println()
+	}
+
+	return r.writeErr
+}
+
+// RenderAuthStatusJSON outputs the auth status as pretty-printed JSON.
+func (r *Renderer) RenderAuthStatusJSON(status *github.AuthStatus) error {
+	encoder := json.NewEncoder(r.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(status)
+}
+
+func rateLimitPriority(name string) int {
+	switch name {
+	case "core":
+		return 0
+	case "graphql":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (r *Renderer) colorize(s, color string) string {
+	if !r.useColor {
+		return s
+	}
+	return color + s + colorReset
+}
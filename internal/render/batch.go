@@ -0,0 +1,101 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+)
+
+// RenderMatrix outputs a batch of PR results as a table with one row per PR
+// and one column per channel. Channels that were not checked for a PR are
+// shown as "-"; PRs that failed show their error instead of channel cells.
+func (r *Renderer) RenderMatrix(results []core.BatchResult) error {
+	r.writeErr = nil
+	columns := core.MatrixColumns(results)
+
+	prWidth := len("PR")
+	for _, res := range results {
+		prWidth = max(prWidth, len(res.Label()))
+	}
+
+	header := []string{pad("PR", prWidth)}
+	dividerLen := prWidth
+	for _, name := range columns {
+		header = append(header, name)
+		dividerLen += 2 + len(name)
+	}
+	header = append(header, "TITLE")
+	dividerLen += 2 + len("TITLE")
+	r.println(strings.Join(header, "  "))
+	r.println(strings.Repeat("-", dividerLen))
+
+	for _, res := range results {
+		pr := r.formatPRLabel(res.Label(), res.URL, prWidth)
+		if res.Err != nil {
+			msg, _, _ := strings.Cut(res.Err.Error(), "\n")
+			r.printf("%s  %s\n", pr, FormatError(sanitize(msg), r.useColor))
+			continue
+		}
+
+		statuses := make(map[string]core.ChannelStatus, len(res.Status.Channels))
+		for _, ch := range res.Status.Channels {
+			statuses[ch.Name] = ch.Status
+		}
+
+		cells := []string{pr}
+		for _, name := range columns {
+			status, ok := statuses[name]
+			if !ok {
+				cells = append(cells, pad("-", len(name)))
+				continue
+			}
+			cells = append(cells, r.formatChannelStatus(status)+strings.Repeat(" ", len(name)-1))
+		}
+
+		icon, stateColor := r.getPRStateIconAndColor(res.Status.State)
+		if r.useColor {
+			icon = stateColor + icon + colorReset
+		}
+		cells = append(cells, icon+" "+sanitize(res.Status.Title))
+		r.println(strings.Join(cells, "  "))
+	}
+
+	return r.writeErr
+}
+
+// formatPRLabel formats "#N" or a commit padded to width, linking to the PR
+// or commit when hyperlinks are enabled. Padding is applied outside the link
+// so escape sequences do not affect alignment.
+func (r *Renderer) formatPRLabel(text, url string, width int) string {
+	padding := strings.Repeat(" ", width-len(text))
+	if r.useHyperlinks && url != "" {
+		text = wrapHyperlink(text, url)
+	}
+	return text + padding
+}
+
+func pad(s string, width int) string {
+	return fmt.Sprintf("%-*s", width, s)
+}
+
+// RenderBatchJSON outputs batch results as a pretty-printed JSON array.
+func (r *Renderer) RenderBatchJSON(results []core.BatchResult) error {
+	encoder := json.NewEncoder(r.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+// RenderResultJSON outputs a single result as pretty-printed JSON: the PR
+// status, or the error of a failed check.
+func (r *Renderer) RenderResultJSON(result core.BatchResult) error {
+	encoder := json.NewEncoder(r.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// RenderNDJSON outputs a single batch result as one line of JSON.
+func (r *Renderer) RenderNDJSON(result core.BatchResult) error {
+	return json.NewEncoder(r.writer).Encode(result)
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+)
+
+// csvHeader names the columns of CSV and TSV output. Columns are only ever
+// appended, so scripts can rely on their positions.
+var csvHeader = []string{
+	"repo", "pr", "commit", "title", "state", "channel", "branch", "status", "via_backport", "error",
+}
+
+// RenderCSV outputs results with a header row and one row per PR and
+// channel, separated by commas or, if tab is set, tabs. A PR that could not
+// be checked gets a single row with its error.
+func (r *Renderer) RenderCSV(results []core.BatchResult, tab bool) error {
+	w := csv.NewWriter(r.writer)
+	if tab {
+		w.Comma = '\t'
+	}
+	if err := w.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, res := range results {
+		pr := ""
+		if res.Number != 0 {
+			pr = strconv.Itoa(res.Number)
+		}
+		if res.Err != nil {
+			msg, _, _ := strings.Cut(res.Err.Error(), "\n")
+			if err := w.Write([]string{res.Repo, pr, res.Commit, "", "", "", "", "", "", msg}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s := res.Status
+		if s.Number != 0 {
+			pr = strconv.Itoa(s.Number)
+		}
+		for _, ch := range s.Channels {
+			backport := ""
+			if ch.ViaBackport != 0 {
+				backport = strconv.Itoa(ch.ViaBackport)
+			}
+			row := []string{s.Repo, pr, s.Commit, sanitize(s.Title), string(s.State), ch.Name, ch.Branch, string(ch.Status), backport, ""}
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,80 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+)
+
+// Format is an output format selected with --format.
+type Format string
+
+const (
+	FormatTable    Format = "table"
+	FormatJSON     Format = "json"
+	FormatMarkdown Format = "markdown"
+	FormatCSV      Format = "csv"
+	FormatTSV      Format = "tsv"
+	FormatYAML     Format = "yaml"
+)
+
+// ParseFormat parses a --format value.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatTable, FormatJSON, FormatMarkdown, FormatCSV, FormatTSV, FormatYAML:
+		return f, nil
+	case "md":
+		return FormatMarkdown, nil
+	default:
+		return "", fmt.Errorf("invalid format %q: must be table, json, markdown, csv, tsv, or yaml", s)
+	}
+}
+
+// RenderStatusAs outputs the status of a single PR in format f.
+func (r *Renderer) RenderStatusAs(f Format, status *core.PRStatus) error {
+	switch f {
+	case FormatJSON:
+		return r.RenderJSON(status)
+	case FormatMarkdown:
+		return r.RenderMarkdown(status)
+	case FormatCSV, FormatTSV:
+		return r.RenderCSV([]core.BatchResult{{Repo: status.Repo, Number: status.Number, Commit: status.Commit, Status: status}}, f == FormatTSV)
+	case FormatYAML:
+		return r.RenderYAML(status)
+	default:
+		return r.RenderTable(status)
+	}
+}
+
+// RenderBatchAs outputs the results of a batch in format f.
+func (r *Renderer) RenderBatchAs(f Format, results []core.BatchResult) error {
+	switch f {
+	case FormatJSON:
+		return r.RenderBatchJSON(results)
+	case FormatMarkdown:
+		return r.RenderMarkdownMatrix(results)
+	case FormatCSV, FormatTSV:
+		return r.RenderCSV(results, f == FormatTSV)
+	case FormatYAML:
+		return r.RenderYAML(results)
+	default:
+		return r.RenderMatrix(results)
+	}
+}
+
+// RenderIssueAs outputs the status of an issue in format f. CSV and TSV hold
+// the rows of its merged PRs.
+func (r *Renderer) RenderIssueAs(f Format, status *core.IssueStatus) error {
+	switch f {
+	case FormatJSON:
+		return r.RenderIssueJSON(status)
+	case FormatMarkdown:
+		return r.RenderIssueMarkdown(status)
+	case FormatCSV, FormatTSV:
+		return r.RenderCSV(status.PRs, f == FormatTSV)
+	case FormatYAML:
+		return r.RenderYAML(status)
+	default:
+		return r.RenderIssueStatus(status)
+	}
+}
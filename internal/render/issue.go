@@ -1,8 +1,10 @@
 package render
 
 import (
+	"encoding/json"
 	"fmt"
 
+	"github.com/thatsneat-dev/nprt/internal/core"
 	"github.com/thatsneat-dev/nprt/internal/github"
 )
 
@@ -25,12 +27,51 @@ func (r *Renderer) RenderIssueWarning(info IssueWarning) error {
 	r.println()
 
 	if len(info.RelatedPRs) > 0 {
-		r.renderRelatedPRsTable(info.RelatedPRs)
+		r.renderRelatedPRsTable("Related pull requests:", info.RelatedPRs)
 	}
 
 	return r.writeErr
 }
 
+// RenderIssueStatus outputs the issue headline followed by a matrix of its
+// merged related PRs and a list of the related PRs that are not merged.
+func (r *Renderer) RenderIssueStatus(status *core.IssueStatus) error {
+	r.writeErr = nil
+	r.renderIssueLine(IssueWarning{Number: status.Number, Title: status.Title, State: status.State, URL: status.URL})
+	r.println()
+
+	if len(status.PRs) == 0 {
+		r.println("No merged pull requests reference this issue.")
+	} else if err := r.RenderMatrix(status.PRs); err != nil {
+		return err
+	}
+
+	var unmerged []github.RelatedPR
+	for _, pr := range status.Related {
+		if pr.State != github.StateMerged {
+			unmerged = append(unmerged, pr)
+		}
+	}
+	if len(unmerged) > 0 {
+		r.println()
+		r.renderRelatedPRsTable("Unmerged related pull requests:", unmerged)
+	}
+
+	return r.writeErr
+}
+
+// RenderIssueJSON outputs the issue status as pretty-printed JSON.
+func (r *Renderer) RenderIssueJSON(status *core.IssueStatus) error {
+	encoder := json.NewEncoder(r.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(status)
+}
+
+// RenderIssueNDJSON outputs the issue status as a single line of JSON.
+func (r *Renderer) RenderIssueNDJSON(status *core.IssueStatus) error {
+	return json.NewEncoder(r.writer).Encode(status)
+}
+
 func (r *Renderer) renderWarningLine() {
 	msg := "WARNING: input is an issue, not a pull request"
 	if r.useColor {
@@ -71,8 +112,8 @@ func (r *Renderer) getIssueStateIconAndColor(state string) (icon, color string)
 	return icon, color
 }
 
-func (r *Renderer) renderRelatedPRsTable(prs []github.RelatedPR) {
-	r.println("Related pull requests:")
+func (r *Renderer) renderRelatedPRsTable(heading string, prs []github.RelatedPR) {
+	r.println(heading)
 	r.println()
 
 	maxNumLen := 2
@@ -0,0 +1,157 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+// RenderMarkdown outputs the PR status as a GitHub-flavored Markdown table
+// with one row per channel, for pasting into comments and chat messages.
+func (r *Renderer) RenderMarkdown(status *core.PRStatus) error {
+	r.writeErr = nil
+	r.println(markdownHeadline(status))
+	r.println()
+	r.println("| Channel | Status |")
+	r.println("| --- | --- |")
+	for _, ch := range status.Channels {
+		cell := markdownStatus(ch.Status)
+		if ch.ViaBackport != 0 {
+			cell += " via backport " + markdownLink(fmt.Sprintf("#%d", ch.ViaBackport), ch.ViaBackportURL)
+		}
+		r.printf("| %s | %s |\n", markdownEscape(ch.Name), cell)
+	}
+	return r.writeErr
+}
+
+// RenderMarkdownMatrix outputs a batch of PR results as a Markdown table with
+// one row per PR and one column per channel.
+func (r *Renderer) RenderMarkdownMatrix(results []core.BatchResult) error {
+	r.writeErr = nil
+	columns := core.MatrixColumns(results)
+
+	header := []string{"PR"}
+	for _, name := range columns {
+		header = append(header, markdownEscape(name))
+	}
+	header = append(header, "Title")
+	r.println("| " + strings.Join(header, " | ") + " |")
+	r.println(strings.Repeat("| --- ", len(header)) + "|")
+
+	for _, res := range results {
+		cells := []string{markdownLink(res.Label(), res.URL)}
+		if res.Err != nil {
+			msg, _, _ := strings.Cut(res.Err.Error(), "\n")
+			for range columns {
+				cells = append(cells, "")
+			}
+			cells = append(cells, "⚠️ "+markdownEscape(msg))
+			r.println("| " + strings.Join(cells, " | ") + " |")
+			continue
+		}
+
+		statuses := make(map[string]core.ChannelStatus, len(res.Status.Channels))
+		for _, ch := range res.Status.Channels {
+			statuses[ch.Name] = ch.Status
+		}
+		for _, name := range columns {
+			status, ok := statuses[name]
+			if !ok {
+				cells = append(cells, "-")
+				continue
+			}
+			cells = append(cells, markdownStatus(status))
+		}
+		cells = append(cells, markdownEscape(res.Status.Title))
+		r.println("| " + strings.Join(cells, " | ") + " |")
+	}
+	return r.writeErr
+}
+
+// RenderIssueMarkdown outputs the issue headline, the Markdown matrix of its
+// merged related PRs and a list of the related PRs that are not merged.
+func (r *Renderer) RenderIssueMarkdown(status *core.IssueStatus) error {
+	r.writeErr = nil
+	headline := markdownLink(fmt.Sprintf("Issue #%d", status.Number), status.URL)
+	if status.Title != "" {
+		headline += " (" + markdownEscape(status.Title) + ")"
+	}
+	r.println(headline)
+	r.println()
+
+	if len(status.PRs) == 0 {
+		r.println("No merged pull requests reference this issue.")
+	} else if err := r.RenderMarkdownMatrix(status.PRs); err != nil {
+		return err
+	}
+
+	first := true
+	for _, pr := range status.Related {
+		if pr.State == github.StateMerged {
+			continue
+		}
+		if first {
+			r.println()
+			r.println("Unmerged related pull requests:")
+			r.println()
+			first = false
+		}
+		r.printf("- %s %s (%s)\n", markdownLink(fmt.Sprintf("#%d", pr.Number), pr.URL), markdownEscape(pr.Title), pr.State)
+	}
+	return r.writeErr
+}
+
+// markdownHeadline returns the linked subject of status with its title and
+// author.
+func markdownHeadline(status *core.PRStatus) string {
+	url := status.URL
+	if status.Number == 0 {
+		url = status.CommitURL
+	}
+	headline := "**" + markdownLink(status.Subject(), url) + "**"
+	if status.Title != "" {
+		headline += ": " + markdownEscape(status.Title)
+	}
+	if status.Author != "" {
+		headline += " by @" + markdownEscape(status.Author)
+	}
+	if status.Commit != "" && status.Number != 0 {
+		headline += " (commit " + markdownLink(core.ShortSHA(status.Commit), status.CommitURL) + ")"
+	}
+	return headline
+}
+
+// markdownStatus returns the emoji for a channel status.
+func markdownStatus(status core.ChannelStatus) string {
+	switch status {
+	case core.StatusPresent:
+		return "✅"
+	case core.StatusNotPresent:
+		return "❌"
+	default:
+		return "❓"
+	}
+}
+
+// markdownLink links text to url, or returns text alone without a URL.
+func markdownLink(text, url string) string {
+	if url == "" {
+		return text
+	}
+	return "[" + text + "](" + url + ")"
+}
+
+// markdownEscapes escapes characters that would end a table cell or start
+// Markdown formatting in untrusted text. Underscores are left alone: GitHub
+// does not emphasize within words, and escaping them would break mentions.
+var markdownEscapes = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "*", `\*`, "`", "\\`",
+	"[", `\[`, "]", `\]`, "<", `\<`, "\n", " ", "\r", " ",
+)
+
+// markdownEscape sanitizes s and escapes it for a Markdown table cell.
+func markdownEscape(s string) string {
+	return markdownEscapes.Replace(sanitize(s))
+}
@@ -2,7 +2,9 @@ package render
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/thatsneat-dev/nprt/internal/core"
 )
@@ -12,7 +14,9 @@ func (r *Renderer) RenderTable(status *core.PRStatus) error {
 	r.writeErr = nil
 	r.renderPRStatusLine(status)
 	r.renderAuthorLine(status)
+	r.renderCommitLine(status)
 	r.println()
+	r.renderPipeline(status)
 
 	maxNameLen := len("CHANNEL")
 	for _, ch := range status.Channels {
@@ -30,16 +34,96 @@ func (r *Renderer) RenderTable(status *core.PRStatus) error {
 	rowFmt := fmt.Sprintf("%%-%ds  %%s\n", maxNameLen)
 	for _, ch := range status.Channels {
 		icon := r.formatChannelStatus(ch.Status)
-		r.printf(rowFmt, ch.Name, fmt.Sprintf("  %s  ", icon))
+		cell := fmt.Sprintf("  %s  ", icon) + r.formatChannelNote(ch)
+		r.printf(rowFmt, ch.Name, cell)
 	}
+	r.renderMissingBackports(status)
 
 	return r.writeErr
 }
 
+// staleAge is the head age from which a channel is highlighted as possibly
+// stuck in the wide table. Channels usually advance every few days at most.
+const staleAge = 3 * 24 * time.Hour
+
+// RenderWideTable outputs the PR status like RenderTable, with columns for
+// how many commits each channel is behind, its head commit and how long ago
+// that commit was made.
+func (r *Renderer) RenderWideTable(status *core.PRStatus) error {
+	r.writeErr = nil
+	r.renderPRStatusLine(status)
+	r.renderAuthorLine(status)
+	r.renderCommitLine(status)
+	r.println()
+	r.renderPipeline(status)
+
+	header := []string{"CHANNEL", "STATUS", "BEHIND", "HEAD", "AGE"}
+	rows := make([][]string, len(status.Channels))
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = len(h)
+	}
+	for i, ch := range status.Channels {
+		behind, head, age := "-", "-", "-"
+		if ch.CommitsBehind != 0 {
+			behind = strconv.Itoa(ch.CommitsBehind)
+		}
+		if ch.Head != "" {
+			head = core.ShortSHA(ch.Head)
+			age = formatAge(time.Duration(ch.HeadAgeSeconds) * time.Second)
+		}
+		// The status icon is placed under STATUS separately, as it may be
+		// colored.
+		rows[i] = []string{sanitize(ch.Name), "", behind, head, age}
+		for j, cell := range rows[i] {
+			widths[j] = max(widths[j], len(cell))
+		}
+	}
+
+	r.printf("%-*s  %s  %*s  %-*s  %s\n", widths[0], header[0], header[1], widths[2], header[2], widths[3], header[3], header[4])
+	r.println(strings.Repeat("-", widths[0]+widths[1]+widths[2]+widths[3]+widths[4]+8))
+	for i, ch := range status.Channels {
+		row := rows[i]
+		age := row[4]
+		if r.useColor && ch.Head != "" {
+			color := colorGray
+			if time.Duration(ch.HeadAgeSeconds)*time.Second >= staleAge {
+				color = colorYellow
+			}
+			age = color + age + colorReset
+		}
+		line := fmt.Sprintf("%-*s    %s     %*s  %-*s  %s", widths[0], row[0], r.formatChannelStatus(ch.Status), widths[2], row[2], widths[3], row[3], age)
+		if note := r.formatChannelNote(ch); note != "" {
+			line += strings.Repeat(" ", widths[4]-len(row[4])+2) + note
+		}
+		r.println(line)
+	}
+	r.renderMissingBackports(status)
+
+	return r.writeErr
+}
+
+// formatAge formats the age of a commit compactly, e.g. "40m", "5h" or "3d".
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "<1m"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age/time.Minute))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dd", int(age/(24*time.Hour)))
+	}
+}
+
 func (r *Renderer) renderPRStatusLine(status *core.PRStatus) {
 	icon, stateColor := r.getPRStateIconAndColor(status.State)
-	text := fmt.Sprintf("PR #%d", status.Number)
-	url := fmt.Sprintf("https://github.com/NixOS/nixpkgs/pull/%d", status.Number)
+	text := status.Subject()
+	url := status.URL
+	if status.Number == 0 {
+		url = status.CommitURL
+	}
 
 	if status.Title != "" {
 		text = fmt.Sprintf("%s (%s)", text, sanitize(status.Title))
@@ -59,6 +143,70 @@ func (r *Renderer) renderAuthorLine(status *core.PRStatus) {
 	}
 }
 
+// renderCommitLine names the checked commit when a commit was given instead
+// of a PR, since it need not be the PR's merge commit.
+func (r *Renderer) renderCommitLine(status *core.PRStatus) {
+	if status.Commit == "" || status.Number == 0 {
+		return
+	}
+	commit := core.ShortSHA(status.Commit)
+	if r.useHyperlinks && status.CommitURL != "" {
+		commit = wrapHyperlink(commit, status.CommitURL)
+	}
+	if r.useColor {
+		r.printf("%scommit: %s%s\n", colorGray, commit, colorReset)
+	} else {
+		r.printf("commit: %s\n", commit)
+	}
+}
+
+// renderPipeline outputs the channels in propagation order, e.g.
+// "master ✓ → nixos-unstable-small ✗ | nixpkgs-unstable ✓ → nixos-unstable ✗",
+// followed by the next hops the PR is waiting on.
+func (r *Renderer) renderPipeline(status *core.PRStatus) {
+	if len(status.Pipeline) == 0 {
+		return
+	}
+
+	statuses := make(map[string]core.ChannelStatus)
+	for _, ch := range status.Channels {
+		statuses[ch.Name] = ch.Status
+	}
+
+	stages := make([]string, len(status.Pipeline))
+	for i, stage := range status.Pipeline {
+		hops := make([]string, len(stage))
+		for j, name := range stage {
+			hops[j] = name + " " + r.formatChannelStatus(statuses[name])
+		}
+		stages[i] = strings.Join(hops, " | ")
+	}
+	r.println(strings.Join(stages, " → "))
+
+	if len(status.WaitingOn) > 0 {
+		next := strings.Join(status.WaitingOn, ", ")
+		if r.useColor {
+			next = colorYellow + next + colorReset
+		}
+		r.printf("next hop: %s\n", next)
+	}
+	r.println()
+}
+
+// renderMissingBackports outputs the releases the PR's backport labels ask
+// for that have no backport PR yet.
+func (r *Renderer) renderMissingBackports(status *core.PRStatus) {
+	if len(status.MissingBackports) == 0 {
+		return
+	}
+	releases := strings.Join(status.MissingBackports, ", ")
+	if r.useColor {
+		releases = colorYellow + releases + colorReset
+	}
+	r.println()
+	r.printf("no backport PR yet for: %s\n", releases)
+}
+
 // getPRStateIconAndColor returns the icon and color for a given PR state.
 func (r *Renderer) getPRStateIconAndColor(state core.PRState) (icon, color string) {
 	switch state {
@@ -81,6 +229,42 @@ func (r *Renderer) getPRStateIconAndColor(state core.PRState) (icon, color strin
 	return icon, color
 }
 
+// formatChannelNote describes how and when the change reached a channel, if
+// known: through a backport PR, and when it landed.
+func (r *Renderer) formatChannelNote(ch core.ChannelResult) string {
+	var notes []string
+	if ch.ViaBackport != 0 {
+		notes = append(notes, r.formatBackportNote(ch.ViaBackport, ch.ViaBackportURL))
+	}
+	if !ch.LandedAt.IsZero() {
+		notes = append(notes, r.formatLandingNote(ch.LandedAt, ch.LandedRevision))
+	}
+	return strings.Join(notes, ", ")
+}
+
+// formatLandingNote describes when the change landed in a channel and with
+// which commit, in UTC so that it can be quoted in announcements as is.
+func (r *Renderer) formatLandingNote(at time.Time, revision string) string {
+	note := fmt.Sprintf("landed %s in %s", at.UTC().Format("2006-01-02 15:04 MST"), core.ShortSHA(revision))
+	if r.useColor {
+		note = colorGray + note + colorReset
+	}
+	return note
+}
+
+// formatBackportNote describes a channel that contains the change only
+// through a backport PR, linking to the backport when hyperlinks are enabled.
+func (r *Renderer) formatBackportNote(number int, url string) string {
+	note := fmt.Sprintf("via backport #%d", number)
+	if r.useHyperlinks && url != "" {
+		note = wrapHyperlink(note, url)
+	}
+	if r.useColor {
+		note = colorGray + note + colorReset
+	}
+	return note
+}
+
 func (r *Renderer) formatChannelStatus(status core.ChannelStatus) string {
 	switch status {
 	case core.StatusPresent:
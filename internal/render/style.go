@@ -85,3 +85,11 @@ func FormatError(msg string, useColor bool) string {
 	}
 	return "Error: " + msg
 }
+
+// FormatWarning formats a warning message with yellow color if color is enabled.
+func FormatWarning(msg string, useColor bool) string {
+	if useColor {
+		return colorYellow + "Warning: " + msg + colorReset
+	}
+	return "Warning: " + msg
+}
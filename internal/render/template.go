@@ -0,0 +1,239 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+)
+
+// TemplateError is a parse or execution error of a --template, located by
+// line and column. Column is 0 if it is unknown. Name is the template file,
+// or "--template" for an inline template.
+type TemplateError struct {
+	Name   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *TemplateError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", e.Name, e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.Name, e.Line, e.Msg)
+}
+
+// templateColors maps the color names accepted by the color helper to their
+// escape sequences.
+var templateColors = map[string]string{
+	"bold":   colorBold,
+	"gray":   colorGray,
+	"green":  colorGreen,
+	"blue":   colorBlue,
+	"red":    colorRed,
+	"yellow": colorYellow,
+	"purple": colorPurple,
+}
+
+// Template is a user-defined output shape for a PRStatus, parsed from
+// --template or --template-file.
+type Template struct {
+	name string
+	text string
+	tmpl *template.Template
+}
+
+// ParseTemplate parses text as a text/template executed with a
+// *core.PRStatus. Besides the built-in functions, templates can use:
+//
+//	channel "name"    the ChannelResult of a channel; Status is empty if the
+//	                  channel was not checked
+//	present x         whether a channel (by name or ChannelResult) or a
+//	                  ChannelStatus is present
+//	icon x            ✓, ✗ or ? for a channel or ChannelStatus, or the icon
+//	                  of a PRState
+//	color "red" s     s in a color (bold, gray, green, blue, red, yellow,
+//	                  purple) when color is enabled
+//	hyperlink url s   s linked to url when hyperlinks are enabled
+//	join sep list     the elements of list separated by sep
+//	json x            x encoded as JSON
+//	short sha         a commit SHA abbreviated to 12 characters
+func ParseTemplate(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(templateFuncs(nil, nil)).Parse(text)
+	if err != nil {
+		return nil, templateError(name, text, err)
+	}
+	return &Template{name: name, text: text, tmpl: tmpl}, nil
+}
+
+// RenderTemplate executes t with status. Nothing is added to the output, so
+// templates end with a newline only if they contain one.
+func (r *Renderer) RenderTemplate(t *Template, status *core.PRStatus) error {
+	var out strings.Builder
+	// The helpers depend on the renderer and the status, so they are bound
+	// anew for every execution.
+	if err := t.tmpl.Funcs(templateFuncs(r, status)).Execute(&out, status); err != nil {
+		return templateError(t.name, t.text, err)
+	}
+	_, err := fmt.Fprint(r.writer, out.String())
+	return err
+}
+
+// templateFuncs returns the template helpers for rendering status with r.
+// Both are nil while parsing, when only the names matter.
+func templateFuncs(r *Renderer, status *core.PRStatus) template.FuncMap {
+	channel := func(name string) core.ChannelResult {
+		if status != nil {
+			for _, ch := range status.Channels {
+				if ch.Name == name {
+					return ch
+				}
+			}
+		}
+		return core.ChannelResult{Name: name}
+	}
+	channelStatus := func(x any) (core.ChannelStatus, error) {
+		switch v := x.(type) {
+		case string:
+			return channel(v).Status, nil
+		case core.ChannelResult:
+			return v.Status, nil
+		case *core.ChannelResult:
+			if v == nil {
+				return "", nil
+			}
+			return v.Status, nil
+		case core.ChannelStatus:
+			return v, nil
+		default:
+			return "", fmt.Errorf("expected a channel name, channel or channel status, got %T", x)
+		}
+	}
+
+	return template.FuncMap{
+		"channel": channel,
+		"present": func(x any) (bool, error) {
+			s, err := channelStatus(x)
+			return s == core.StatusPresent, err
+		},
+		"icon": func(x any) (string, error) {
+			if state, ok := x.(core.PRState); ok {
+				icon, _ := r.getPRStateIconAndColor(state)
+				return icon, nil
+			}
+			s, err := channelStatus(x)
+			switch s {
+			case core.StatusPresent:
+				return iconPresent, err
+			case core.StatusNotPresent:
+				return iconNotPresent, err
+			default:
+				return iconUnknown, err
+			}
+		},
+		"color": func(name string, s any) (string, error) {
+			code, ok := templateColors[name]
+			if !ok {
+				return "", fmt.Errorf("unknown color %q", name)
+			}
+			text := fmt.Sprint(s)
+			if r == nil || !r.useColor {
+				return text, nil
+			}
+			return code + text + colorReset, nil
+		},
+		"hyperlink": func(url string, s any) string {
+			text := fmt.Sprint(s)
+			if r == nil || !r.useHyperlinks || url == "" {
+				return text
+			}
+			return wrapHyperlink(text, url)
+		},
+		"join": func(sep string, list any) (string, error) {
+			v := reflect.ValueOf(list)
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				return "", fmt.Errorf("join expects a list, got %T", list)
+			}
+			parts := make([]string, v.Len())
+			for i := range parts {
+				elem := v.Index(i).Interface()
+				if ch, ok := elem.(core.ChannelResult); ok {
+					elem = ch.Name
+				}
+				parts[i] = fmt.Sprint(elem)
+			}
+			return strings.Join(parts, sep), nil
+		},
+		"json": func(x any) (string, error) {
+			data, err := json.Marshal(x)
+			return string(data), err
+		},
+		"short": core.ShortSHA,
+	}
+}
+
+// templateLocationRegex matches the "line:" or "line:column:" that
+// text/template puts after the template name in its errors.
+var templateLocationRegex = regexp.MustCompile(`^(\d+):(?:(\d+):)?\s*`)
+
+// templateQuotedRegex matches the token a parse error complains about.
+var templateQuotedRegex = regexp.MustCompile(`"([^"]+)"|<([^>]+)>`)
+
+// templateError converts an error of text/template into a TemplateError.
+// Execution errors carry a column; for parse errors, the column of the token
+// named in the message is looked up in the template text.
+func templateError(name, text string, err error) error {
+	msg := err.Error()
+	var execErr template.ExecError
+	if errors.As(err, &execErr) {
+		msg = execErr.Err.Error()
+	}
+	rest, ok := strings.CutPrefix(msg, "template: "+name+":")
+	if !ok {
+		return err
+	}
+	m := templateLocationRegex.FindStringSubmatch(rest)
+	if m == nil {
+		return err
+	}
+
+	te := &TemplateError{Name: name, Msg: rest[len(m[0]):]}
+	te.Line, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		// text/template counts columns from 0.
+		te.Column, _ = strconv.Atoi(m[2])
+		te.Column++
+	} else {
+		te.Column = guessTemplateColumn(text, te.Line, te.Msg)
+	}
+	// Execution errors repeat the template name before the failing action.
+	te.Msg = strings.TrimPrefix(te.Msg, fmt.Sprintf("executing %q at ", name))
+	return te
+}
+
+// guessTemplateColumn returns the 1-based column of the token quoted in msg
+// on the given line of text, or of the first action on that line, or 0.
+func guessTemplateColumn(text string, line int, msg string) int {
+	lines := strings.Split(text, "\n")
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+	src := lines[line-1]
+	if m := templateQuotedRegex.FindStringSubmatch(msg); m != nil {
+		token := m[1] + m[2]
+		if i := strings.Index(src, token); i >= 0 {
+			return i + 1
+		}
+	}
+	if i := strings.Index(src, "{{"); i >= 0 {
+		return i + 1
+	}
+	return 0
+}
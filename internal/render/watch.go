@@ -0,0 +1,72 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+)
+
+// LiveView redraws a block of output in place on a terminal by moving the
+// cursor back over the previously drawn lines before writing new content.
+type LiveView struct {
+	writer io.Writer
+	lines  int
+}
+
+// NewLiveView creates a LiveView writing to writer, which should be a TTY.
+func NewLiveView(writer io.Writer) *LiveView {
+	return &LiveView{writer: writer}
+}
+
+// Redraw replaces the previously drawn block with content.
+func (v *LiveView) Redraw(content []byte) error {
+	var buf bytes.Buffer
+	if v.lines > 0 {
+		// Move to the start of the previous block and clear to the end of
+		// the screen.
+		fmt.Fprintf(&buf, "\033[%dF\033[J", v.lines)
+	}
+	buf.Write(content)
+	v.lines = bytes.Count(content, []byte("\n"))
+
+	_, err := v.writer.Write(buf.Bytes())
+	return err
+}
+
+// RenderWatchFooter outputs the time of the last check and of the next one.
+func (r *Renderer) RenderWatchFooter(checked time.Time, next time.Duration, until []string) error {
+	r.writeErr = nil
+	r.println()
+	target := "all channels"
+	if len(until) > 0 {
+		target = strings.Join(until, ", ")
+	}
+	line := fmt.Sprintf("last checked %s · waiting for %s", checked.Format("15:04:05"), target)
+	if next > 0 {
+		line += fmt.Sprintf(" · next check in %s", next.Round(time.Second))
+	}
+	if r.useColor {
+		line = colorGray + line + colorReset
+	}
+	r.println(line)
+	return r.writeErr
+}
+
+// RenderChanges outputs one line per channel status change, prefixed with
+// the time of the check, e.g. "12:00:00 nixos-unstable: not_present → present".
+func (r *Renderer) RenderChanges(at time.Time, changes []core.ChannelChange) error {
+	r.writeErr = nil
+	for _, ch := range changes {
+		r.printf("%s %s: %s → %s %s\n",
+			at.Format("15:04:05"),
+			ch.Name,
+			ch.From,
+			ch.To,
+			r.formatChannelStatus(ch.To))
+	}
+	return r.writeErr
+}
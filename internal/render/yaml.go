@@ -0,0 +1,144 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RenderYAML outputs v as YAML. v is encoded as JSON first, so the YAML has
+// the same fields, in the same order, as the JSON output.
+func (r *Renderer) RenderYAML(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := decodeYAMLNode(dec)
+	if err != nil {
+		return err
+	}
+
+	r.writeErr = nil
+	if node.scalar != "" {
+		r.println(node.scalar)
+		return r.writeErr
+	}
+	for _, line := range node.lines() {
+		r.println(line)
+	}
+	return r.writeErr
+}
+
+// yamlNode is a JSON value in document order: a scalar already formatted as
+// YAML, or an object or array. Objects keep their keys in keys.
+type yamlNode struct {
+	scalar   string
+	isObject bool
+	keys     []string
+	children []yamlNode
+}
+
+// decodeYAMLNode reads the next JSON value from dec.
+func decodeYAMLNode(dec *json.Decoder) (yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return yamlNode{}, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		node := yamlNode{isObject: t == '{'}
+		for dec.More() {
+			if node.isObject {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return yamlNode{}, err
+				}
+				node.keys = append(node.keys, yamlString(keyTok.(string)))
+			}
+			child, err := decodeYAMLNode(dec)
+			if err != nil {
+				return yamlNode{}, err
+			}
+			node.children = append(node.children, child)
+		}
+		// Consume the closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return yamlNode{}, err
+		}
+		if len(node.children) == 0 {
+			node.scalar = "[]"
+			if node.isObject {
+				node.scalar = "{}"
+			}
+		}
+		return node, nil
+	case string:
+		return yamlNode{scalar: yamlString(t)}, nil
+	case json.Number:
+		return yamlNode{scalar: t.String()}, nil
+	case bool:
+		return yamlNode{scalar: fmt.Sprint(t)}, nil
+	case nil:
+		return yamlNode{scalar: "null"}, nil
+	default:
+		return yamlNode{}, fmt.Errorf("unexpected JSON token %v", tok)
+	}
+}
+
+// lines returns the block lines of a non-empty object or array, indented
+// relative to the node.
+func (n yamlNode) lines() []string {
+	var out []string
+	for i, child := range n.children {
+		if n.isObject {
+			if child.scalar != "" {
+				out = append(out, n.keys[i]+": "+child.scalar)
+				continue
+			}
+			out = append(out, n.keys[i]+":")
+			for _, line := range child.lines() {
+				out = append(out, "  "+line)
+			}
+			continue
+		}
+
+		if child.scalar != "" {
+			out = append(out, "- "+child.scalar)
+			continue
+		}
+		for j, line := range child.lines() {
+			if j == 0 {
+				out = append(out, "- "+line)
+			} else {
+				out = append(out, "  "+line)
+			}
+		}
+	}
+	return out
+}
+
+// yamlPlainRegex matches strings that can be written without quotes.
+var yamlPlainRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_./+-]*$`)
+
+// yamlString formats s as a plain scalar if that is unambiguous, and as a
+// double-quoted scalar otherwise. JSON string escapes are valid in YAML
+// double-quoted scalars.
+func yamlString(s string) string {
+	if yamlPlainRegex.MatchString(s) {
+		switch strings.ToLower(s) {
+		case "true", "false", "null", "yes", "no", "on", "off", "y", "n":
+		default:
+			return s
+		}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
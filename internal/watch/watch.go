@@ -0,0 +1,204 @@
+// Package watch repeatedly checks a pull request until it reaches a set of
+// channels or a timeout expires.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+const (
+	DefaultInterval = 5 * time.Minute
+	DefaultJitter   = 30 * time.Second
+	DefaultTimeout  = 24 * time.Hour
+
+	// MinInterval keeps watch loops from hammering the GitHub API.
+	MinInterval = 10 * time.Second
+)
+
+// ErrTimeout is returned when the timeout expires before the PR reaches
+// every target channel.
+var ErrTimeout = errors.New("timed out")
+
+// CheckFunc performs a single check of the watched PR.
+type CheckFunc func(ctx context.Context) (*core.PRStatus, error)
+
+// Options configures a watch loop.
+type Options struct {
+	// Interval is the base delay between checks.
+	Interval time.Duration
+	// Jitter randomly shifts each delay by up to this much in either
+	// direction so that many watchers do not poll in lockstep.
+	Jitter time.Duration
+	// Timeout bounds the whole watch; zero means no limit.
+	Timeout time.Duration
+	// Until lists the channels that must all be present to stop watching.
+	// If empty, every checked channel must be present.
+	Until []string
+	// RateLimit reports the latest rate limit budget. When the budget is
+	// exhausted, the next check is postponed until the budget resets.
+	RateLimit func() (github.RateLimit, bool)
+	// OnUpdate is called after every successful check with the previous
+	// status, which is nil for the first check.
+	OnUpdate func(prev, cur *core.PRStatus)
+	// OnRetry is called when a check fails with an error that is retried.
+	OnRetry func(err error, wait time.Duration)
+}
+
+// Run checks the PR until every channel in opts.Until is present and
+// returns the final status. It returns ErrTimeout (wrapped, together with
+// the last status) when the timeout expires first, and any non-transient
+// check error immediately.
+func Run(ctx context.Context, check CheckFunc, opts Options, log *zap.Logger) (*core.PRStatus, error) {
+	log = log.Named("watch")
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	var last *core.PRStatus
+	for {
+		status, err := check(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return last, timeoutOr(ctx, err)
+			}
+			wait, ok := retryDelay(err, opts)
+			if !ok {
+				return last, err
+			}
+			log.Debug("check failed, retrying", zap.Error(err), zap.Duration("wait", wait))
+			if opts.OnRetry != nil {
+				opts.OnRetry(err, wait)
+			}
+			if err := sleep(ctx, wait); err != nil {
+				return last, timeoutOr(ctx, err)
+			}
+			continue
+		}
+
+		if opts.OnUpdate != nil {
+			opts.OnUpdate(last, status)
+		}
+		last = status
+
+		if core.HasChannels(status, targets(status, opts.Until)) {
+			log.Debug("all target channels present", zap.Strings("until", opts.Until))
+			return status, nil
+		}
+
+		wait := nextDelay(opts)
+		if rl, ok := rateLimitWait(opts); ok && rl > wait {
+			wait = rl
+		}
+		log.Debug("waiting for next check", zap.Duration("wait", wait))
+		if err := sleep(ctx, wait); err != nil {
+			return last, timeoutOr(ctx, err)
+		}
+	}
+}
+
+// targets returns the channels to wait for: the given names, or every
+// checked channel if none were given.
+func targets(status *core.PRStatus, until []string) []string {
+	if len(until) > 0 {
+		return until
+	}
+	names := make([]string, len(status.Channels))
+	for i, ch := range status.Channels {
+		names[i] = ch.Name
+	}
+	return names
+}
+
+// timeoutOr maps a context deadline to ErrTimeout and leaves other errors,
+// including cancellation by the user, untouched.
+func timeoutOr(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w waiting for channels", ErrTimeout)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// nextDelay returns the interval shifted by a random jitter, never less
+// than zero.
+func nextDelay(opts Options) time.Duration {
+	d := opts.Interval
+	if opts.Jitter > 0 {
+		d += time.Duration(rand.Int64N(int64(2*opts.Jitter))) - opts.Jitter
+	}
+	return max(d, 0)
+}
+
+// rateLimitWait returns how long to wait for the rate limit to reset when
+// the remaining budget is exhausted.
+func rateLimitWait(opts Options) (time.Duration, bool) {
+	if opts.RateLimit == nil {
+		return 0, false
+	}
+	rl, ok := opts.RateLimit()
+	if !ok || rl.Remaining > 0 || rl.Reset.IsZero() {
+		return 0, false
+	}
+	wait := time.Until(rl.Reset)
+	if wait <= 0 {
+		return 0, false
+	}
+	// Give GitHub a moment past the reset time before trying again.
+	return wait + time.Second, true
+}
+
+// retryDelay reports whether a failed check is worth retrying and how long
+// to wait first. Network errors and server errors are retried at the normal
+// interval; rate limiting is retried once the budget resets. Missing PRs,
+// authentication failures and other client errors are permanent.
+func retryDelay(err error, opts Options) (time.Duration, bool) {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return nextDelay(opts), true
+	}
+
+	var apiErr *github.APIError
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+
+	switch {
+	case apiErr.StatusCode >= 500:
+		return nextDelay(opts), true
+	case apiErr.StatusCode == http.StatusForbidden || apiErr.StatusCode == http.StatusTooManyRequests:
+		if wait, ok := rateLimitWait(opts); ok {
+			return wait, true
+		}
+		if apiErr.StatusCode == http.StatusTooManyRequests {
+			return nextDelay(opts), true
+		}
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
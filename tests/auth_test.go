@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/github"
+	"github.com/thatsneat-dev/nprt/internal/render"
+)
+
+const rateLimitBody = `{"resources": {
+	"core": {"limit": 5000, "remaining": 4990, "used": 10, "reset": 1750000000},
+	"graphql": {"limit": 5000, "remaining": 5000, "used": 0, "reset": 1750000000}
+}}`
+
+func TestGetAuthStatus_WithToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+				t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+			}
+			w.Header().Set("X-OAuth-Scopes", "repo, read:org")
+			w.Write([]byte(`{"login": "someone"}`))
+		case "/rate_limit":
+			w.Write([]byte(rateLimitBody))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient("secret", "", zap.NewNop())
+	client.BaseURL = server.URL
+
+	status, err := client.GetAuthStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetAuthStatus returned error: %v", err)
+	}
+	if !status.TokenSet || status.Login != "someone" {
+		t.Errorf("TokenSet = %v, Login = %q, want true, someone", status.TokenSet, status.Login)
+	}
+	if len(status.Scopes) != 2 || status.Scopes[0] != "repo" || status.Scopes[1] != "read:org" {
+		t.Errorf("Scopes = %v, want [repo read:org]", status.Scopes)
+	}
+	core := status.RateLimits["core"]
+	if core.Limit != 5000 || core.Remaining != 4990 || core.Used != 10 || core.Reset.Unix() != 1750000000 {
+		t.Errorf("core rate limit = %+v", core)
+	}
+}
+
+func TestGetAuthStatus_WithoutToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rate_limit" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Write([]byte(rateLimitBody))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+
+	status, err := client.GetAuthStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetAuthStatus returned error: %v", err)
+	}
+	if status.TokenSet || status.Login != "" {
+		t.Errorf("TokenSet = %v, Login = %q, want false, empty", status.TokenSet, status.Login)
+	}
+}
+
+func TestClient_RecordsRateLimitHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4321")
+		w.Header().Set("X-RateLimit-Used", "679")
+		w.Header().Set("X-RateLimit-Reset", "1750000000")
+		w.Header().Set("X-RateLimit-Resource", "core")
+		w.Write([]byte(`{"status": "ahead", "ahead_by": 1, "behind_by": 0}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+
+	if _, err := client.CompareCommitWithBranch(context.Background(), "abc123", "master"); err != nil {
+		t.Fatalf("CompareCommitWithBranch returned error: %v", err)
+	}
+
+	rl, ok := client.RateLimit()
+	if !ok {
+		t.Fatal("RateLimit should be recorded")
+	}
+	want := github.RateLimit{Limit: 5000, Remaining: 4321, Used: 679, Reset: time.Unix(1750000000, 0), Resource: "core"}
+	if rl != want {
+		t.Errorf("RateLimit = %+v, want %+v", rl, want)
+	}
+}
+
+func TestRateLimitError_IncludesReset(t *testing.T) {
+	reset := time.Now().Add(42 * time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Used", "60")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+
+	_, err := client.GetPullRequest(context.Background(), 1)
+	if err == nil {
+		t.Fatal("GetPullRequest should have returned error")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "60/60 used") || !strings.Contains(msg, "resets at "+reset.Format("15:04")) {
+		t.Errorf("error should include usage and reset time: %v", msg)
+	}
+}
+
+func TestRenderAuthStatus(t *testing.T) {
+	now := time.Unix(1750000000, 0).Add(-30 * time.Minute)
+	status := &github.AuthStatus{
+		TokenSet: true,
+		Login:    "someone",
+		Scopes:   []string{},
+		RateLimits: map[string]github.RateLimit{
+			"search":  {Limit: 30, Remaining: 30},
+			"graphql": {Limit: 5000, Remaining: 5000},
+			"core":    {Limit: 5000, Remaining: 4990, Used: 10, Reset: time.Unix(1750000000, 0)},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := render.NewRenderer(&buf, false, false).RenderAuthStatus(status, now); err != nil {
+		t.Fatalf("RenderAuthStatus returned error: %v", err)
+	}
+	output := buf.String()
+
+	for _, want := range []string{"Token:   set", "Login:   someone", "Scopes:  none", "4990/5000 remaining, resets at", "(in 30m)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output should contain %q:\n%s", want, output)
+		}
+	}
+	if core, graphql, search := strings.Index(output, "core"), strings.Index(output, "graphql"), strings.Index(output, "search"); !(core < graphql && graphql < search) {
+		t.Errorf("core and graphql should be listed first:\n%s", output)
+	}
+}
+
+func TestCLI_AuthStatusUsesConfigFile(t *testing.T) {
+	bin := buildNprt(t)
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(rateLimitBody))
+	}))
+	defer server.Close()
+
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, "nprt"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	conf := "[defaults]\napi-url = \"" + server.URL + "\"\n"
+	if err := os.WriteFile(filepath.Join(home, "nprt", "config.toml"), []byte(conf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(bin, "auth", "status", "--json")
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + home, "XDG_CONFIG_HOME=" + home}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("nprt auth status: %v\n%s", err, out)
+	}
+	if len(paths) != 1 || paths[0] != "/rate_limit" {
+		t.Errorf("config api-url saw requests %v, want [/rate_limit]\n%s", paths, out)
+	}
+}
@@ -0,0 +1,276 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+// newBackportServer simulates PR #100 merged to master with a merged bot
+// backport #200 to release-25.05, an unrelated cross-reference #300, and a
+// "[25.05]"-titled PR #400 that actually targets master.
+func newBackportServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pulls/100"):
+			w.Write([]byte(`{
+				"number": 100,
+				"title": "hello: 1.0 -> 1.1",
+				"state": "closed",
+				"merged": true,
+				"merge_commit_sha": "1111111111111111",
+				"base": {"ref": "master"},
+				"labels": [{"name": "backport release-25.05"}]
+			}`))
+		case strings.HasSuffix(r.URL.Path, "/pulls/200"):
+			w.Write([]byte(`{
+				"number": 200,
+				"title": "[Backport release-25.05] hello: 1.0 -> 1.1",
+				"state": "closed",
+				"merged": true,
+				"merge_commit_sha": "2222222222222222",
+				"base": {"ref": "release-25.05"}
+			}`))
+		case strings.HasSuffix(r.URL.Path, "/pulls/400"):
+			w.Write([]byte(`{
+				"number": 400,
+				"title": "[25.05] misleading title",
+				"state": "closed",
+				"merged": true,
+				"merge_commit_sha": "4444444444444444",
+				"base": {"ref": "master"}
+			}`))
+		case strings.HasSuffix(r.URL.Path, "/issues/100/timeline"):
+			if r.URL.Query().Get("page") != "1" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[
+				{"event": "cross-referenced", "source": {"issue": {
+					"number": 200,
+					"title": "[Backport release-25.05] hello: 1.0 -> 1.1",
+					"state": "closed",
+					"html_url": "https://github.com/NixOS/nixpkgs/pull/200",
+					"pull_request": {"merged_at": "2025-06-01T00:00:00Z"},
+					"repository": {"full_name": "NixOS/nixpkgs"}
+				}}},
+				{"event": "cross-referenced", "source": {"issue": {
+					"number": 300,
+					"title": "unrelated follow-up",
+					"state": "open",
+					"html_url": "https://github.com/NixOS/nixpkgs/pull/300",
+					"pull_request": {},
+					"repository": {"full_name": "NixOS/nixpkgs"}
+				}}},
+				{"event": "cross-referenced", "source": {"issue": {
+					"number": 400,
+					"title": "[25.05] misleading title",
+					"state": "closed",
+					"html_url": "https://github.com/NixOS/nixpkgs/pull/400",
+					"pull_request": {"merged_at": "2025-06-01T00:00:00Z"},
+					"repository": {"full_name": "NixOS/nixpkgs"}
+				}}}
+			]`))
+		case strings.Contains(r.URL.Path, "/compare/1111111111111111...master"):
+			w.Write([]byte(`{"status": "ahead", "ahead_by": 3, "behind_by": 0}`))
+		case strings.Contains(r.URL.Path, "/compare/1111111111111111..."):
+			w.Write([]byte(`{"status": "diverged", "ahead_by": 3, "behind_by": 7}`))
+		case strings.Contains(r.URL.Path, "/compare/2222222222222222...nixos-25.05-small"):
+			w.Write([]byte(`{"status": "ahead", "ahead_by": 1, "behind_by": 0}`))
+		case strings.Contains(r.URL.Path, "/compare/2222222222222222..."):
+			w.Write([]byte(`{"status": "behind", "ahead_by": 0, "behind_by": 1}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestBackportTargets(t *testing.T) {
+	pr := &github.PullRequest{Labels: []github.Label{
+		{Name: "backport release-25.05"},
+		{Name: "backport staging-24.11"},
+		{Name: "10.rebuild-linux: 1-10"},
+		{Name: "backport nonsense"},
+	}}
+
+	targets := github.BackportTargets(pr)
+	if len(targets) != 2 || targets[0] != "25.05" || targets[1] != "24.11" {
+		t.Errorf("BackportTargets = %v, want [25.05 24.11]", targets)
+	}
+}
+
+func TestFindBackports(t *testing.T) {
+	server := newBackportServer(t)
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+
+	pr, err := client.GetPullRequest(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("GetPullRequest returned error: %v", err)
+	}
+
+	backports, err := client.FindBackports(context.Background(), pr)
+	if err != nil {
+		t.Fatalf("FindBackports returned error: %v", err)
+	}
+	if len(backports) != 1 {
+		t.Fatalf("expected 1 backport, got %d: %+v", len(backports), backports)
+	}
+
+	bp := backports[0]
+	if bp.Number != 200 {
+		t.Errorf("backport number = %d, want 200", bp.Number)
+	}
+	if bp.Release != "25.05" {
+		t.Errorf("backport release = %q, want 25.05", bp.Release)
+	}
+	if bp.MergeCommitSHA != "2222222222222222" {
+		t.Errorf("backport merge commit = %q, want 2222222222222222", bp.MergeCommitSHA)
+	}
+}
+
+func TestCheckPR_PresentViaBackport(t *testing.T) {
+	server := newBackportServer(t)
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+
+	channels := []config.Channel{
+		{Name: "master", Branch: "master"},
+		{Name: "nixos-unstable", Branch: "nixos-unstable"},
+		{Name: "nixos-25.05-small", Branch: "nixos-25.05-small", Release: "25.05"},
+		{Name: "nixos-25.05", Branch: "nixos-25.05", Release: "25.05"},
+	}
+
+	status, err := checker.CheckPR(context.Background(), 100, channels)
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+
+	byName := make(map[string]core.ChannelResult)
+	for _, ch := range status.Channels {
+		byName[ch.Name] = ch
+	}
+
+	if got := byName["master"]; got.Status != core.StatusPresent || got.ViaBackport != 0 {
+		t.Errorf("master = %+v, want present without backport", got)
+	}
+	if got := byName["nixos-unstable"]; got.Status != core.StatusNotPresent {
+		t.Errorf("nixos-unstable = %+v, want not_present (unstable channels ignore backports)", got)
+	}
+	if got := byName["nixos-25.05-small"]; got.Status != core.StatusPresent || got.ViaBackport != 200 {
+		t.Errorf("nixos-25.05-small = %+v, want present via backport #200", got)
+	}
+	if got := byName["nixos-25.05"]; got.Status != core.StatusNotPresent || got.ViaBackport != 0 {
+		t.Errorf("nixos-25.05 = %+v, want not_present", got)
+	}
+}
+
+// newLabelledBackportServer simulates PR #500, labelled for backports to
+// release-25.05 and release-24.11. Its timeline references #501, a hand-made
+// backport to release-25.05 that reuses its title without the bot's prefix,
+// #502, with the same title but targeting master, and #503, a backport to
+// release-23.11, which is not labelled.
+func newLabelledBackportServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	pulls := map[string]string{
+		"500": `{"number": 500, "title": "zlib: fix CVE-2025-0001", "state": "closed", "merged": true,
+			"merge_commit_sha": "5000000000000000", "base": {"ref": "master"},
+			"labels": [{"name": "backport release-25.05"}, {"name": "backport release-24.11"}]}`,
+		"501": `{"number": 501, "title": "zlib: fix CVE-2025-0001 (25.05)", "state": "closed", "merged": true,
+			"merge_commit_sha": "5010000000000000", "base": {"ref": "release-25.05"}}`,
+		"502": `{"number": 502, "title": "zlib: fix CVE-2025-0001 again", "state": "open",
+			"base": {"ref": "master"}}`,
+		"503": `{"number": 503, "title": "zlib: fix CVE-2025-0001 on 23.11", "state": "open",
+			"base": {"ref": "release-23.11"}}`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/repos/NixOS/nixpkgs/")
+		switch {
+		case strings.HasPrefix(path, "pulls/"):
+			w.Write([]byte(pulls[strings.TrimPrefix(path, "pulls/")]))
+		case path == "issues/500/timeline":
+			if r.URL.Query().Get("page") != "1" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			var events []string
+			for _, n := range []string{"501", "502", "503"} {
+				events = append(events, `{"event": "cross-referenced", "source": {"issue": {
+					"number": `+n+`, "title": "zlib: fix CVE-2025-0001", "state": "open",
+					"html_url": "https://github.com/NixOS/nixpkgs/pull/`+n+`",
+					"pull_request": {}, "repository": {"full_name": "NixOS/nixpkgs"}}}}`)
+			}
+			w.Write([]byte("[" + strings.Join(events, ",") + "]"))
+		case strings.HasPrefix(path, "compare/5010000000000000...nixos-25.05"):
+			w.Write([]byte(`{"status": "ahead", "behind_by": 0}`))
+		case strings.HasPrefix(path, "compare/"):
+			w.Write([]byte(`{"status": "diverged", "behind_by": 2}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestFindBackports_Labels(t *testing.T) {
+	server := newLabelledBackportServer(t)
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+
+	pr, err := client.GetPullRequest(context.Background(), 500)
+	if err != nil {
+		t.Fatalf("GetPullRequest returned error: %v", err)
+	}
+	backports, err := client.FindBackports(context.Background(), pr)
+	if err != nil {
+		t.Fatalf("FindBackports returned error: %v", err)
+	}
+	if len(backports) != 1 || backports[0].Number != 501 || backports[0].Release != "25.05" {
+		t.Fatalf("backports = %+v, want only #501 for 25.05", backports)
+	}
+
+	if missing := github.MissingBackports(pr, backports); len(missing) != 1 || missing[0] != "24.11" {
+		t.Errorf("MissingBackports = %v, want [24.11]", missing)
+	}
+}
+
+func TestCheckPR_MissingBackports(t *testing.T) {
+	server := newLabelledBackportServer(t)
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+
+	channels := []config.Channel{
+		{Name: "nixos-25.05", Branch: "nixos-25.05", Release: "25.05"},
+		{Name: "nixos-24.11", Branch: "nixos-24.11", Release: "24.11"},
+	}
+	status, err := checker.CheckPR(context.Background(), 500, channels)
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+	if got := status.Channels[0]; got.Name != "nixos-25.05" || got.ViaBackport != 501 {
+		t.Errorf("nixos-25.05 = %+v, want present via backport #501", got)
+	}
+	if len(status.MissingBackports) != 1 || status.MissingBackports[0] != "24.11" {
+		t.Errorf("MissingBackports = %v, want [24.11]", status.MissingBackports)
+	}
+}
@@ -0,0 +1,295 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+	"github.com/thatsneat-dev/nprt/internal/render"
+)
+
+// newBatchServer serves merged PRs 1-9 that are present everywhere, and 404
+// for everything else. It records how often each PR was fetched and the
+// highest number of concurrent PR fetches.
+func newBatchServer(t *testing.T) (*httptest.Server, map[string]int, *atomic.Int32) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		fetches  = make(map[string]int)
+		inflight atomic.Int32
+		peak     atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/compare/"):
+			w.Write([]byte(`{"status": "ahead", "ahead_by": 1, "behind_by": 0}`))
+		case strings.Contains(r.URL.Path, "/pulls/"):
+			n := inflight.Add(1)
+			defer inflight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			number := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			mu.Lock()
+			fetches[number]++
+			mu.Unlock()
+			if len(number) != 1 || number == "0" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message": "Not Found"}`))
+				return
+			}
+			fmt.Fprintf(w, `{"number": %s, "title": "PR %s", "state": "closed", "merged": true,
+				"merge_commit_sha": "abcdef0123456789", "base": {"ref": "master"}}`, number, number)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, fetches, &peak
+}
+
+func prRefs(numbers ...int) []config.PRRef {
+	refs := make([]config.PRRef, len(numbers))
+	for i, n := range numbers {
+		refs[i] = config.PRRef{Number: n}
+	}
+	return refs
+}
+
+func TestCheckPRs_DedupesAndBoundsWorkers(t *testing.T) {
+	server, fetches, peak := newBatchServer(t)
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+
+	refs := prRefs(3, 1, 2, 3, 4, 5, 6, 1)
+	var streamed int
+	results := checker.CheckPRs(context.Background(), refs, config.GetDefaultChannels(), 2, func(core.BatchResult) {
+		streamed++
+	})
+
+	var got []int
+	for _, res := range results {
+		got = append(got, res.Number)
+		if res.Err != nil {
+			t.Errorf("PR #%d: unexpected error %v", res.Number, res.Err)
+		}
+	}
+	if want := []int{3, 1, 2, 4, 5, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("result order = %v, want %v", got, want)
+	}
+	if streamed != 6 {
+		t.Errorf("onResult calls = %d, want 6", streamed)
+	}
+	if fetches["3"] != 1 || fetches["1"] != 1 {
+		t.Errorf("duplicate PRs fetched more than once: %v", fetches)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("peak concurrent PR fetches = %d, want at most 2", p)
+	}
+}
+
+func TestCheckPRs_KeepsErrors(t *testing.T) {
+	server, _, _ := newBatchServer(t)
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+
+	results := checker.CheckPRs(context.Background(), prRefs(1, 42), config.GetDefaultChannels(), 4, nil)
+
+	if len(results) != 2 {
+		t.Fatalf("results = %d, want 2", len(results))
+	}
+	if results[0].Err != nil || results[0].Status == nil {
+		t.Errorf("PR #1: Status = %v, Err = %v, want status", results[0].Status, results[0].Err)
+	}
+	if results[1].Err == nil {
+		t.Error("PR #42: expected error")
+	}
+}
+
+func TestCheckIssue_ChecksMergedPRs(t *testing.T) {
+	server, fetches, _ := newBatchServer(t)
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+
+	issue := &github.NotPullRequestError{
+		Number: 100,
+		Title:  "hello crashes",
+		State:  "closed",
+		RelatedPRs: []github.RelatedPR{
+			{Number: 2, State: github.StateMerged},
+			{Number: 3, State: "open"},
+			{Number: 1, State: github.StateMerged},
+		},
+	}
+	status := checker.CheckIssue(context.Background(), issue, config.GetDefaultChannels(), 2)
+
+	if status.Number != 100 || status.Title != "hello crashes" || len(status.Related) != 3 {
+		t.Errorf("status = %+v, want issue #100 with 3 related PRs", status)
+	}
+	if len(status.PRs) != 2 || status.PRs[0].Number != 2 || status.PRs[1].Number != 1 {
+		t.Fatalf("PRs = %+v, want checks of #2 and #1", status.PRs)
+	}
+	for _, res := range status.PRs {
+		if res.Err != nil || res.Status == nil {
+			t.Errorf("PR #%d: Status = %v, Err = %v, want status", res.Number, res.Status, res.Err)
+		}
+	}
+	if fetches["3"] != 0 {
+		t.Error("unmerged PR #3 should not be checked")
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"issue":100`, `"related":[{"pr":2,`, `"prs":[{"schema_version":1,"repo":"NixOS/nixpkgs","pr":2,`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON = %s, want it to contain %s", data, want)
+		}
+	}
+}
+
+func TestRenderIssueStatus(t *testing.T) {
+	t.Setenv("NO_NERD_FONTS", "1")
+
+	status := &core.IssueStatus{
+		Number: 100,
+		Title:  "hello crashes",
+		State:  "closed",
+		Related: []github.RelatedPR{
+			{Number: 1, Title: "hello: fix crash", State: github.StateMerged},
+			{Number: 3, Title: "hello: other fix", State: "open"},
+		},
+		PRs: []core.BatchResult{
+			{Number: 1, Status: &core.PRStatus{
+				Number:   1,
+				Title:    "hello: fix crash",
+				State:    core.PRStateMerged,
+				Channels: []core.ChannelResult{{Name: "master", Status: core.StatusPresent}},
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := render.NewRenderer(&buf, false, false).RenderIssueStatus(status); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"● Issue #100 (hello crashes)",
+		"",
+		"PR  master  TITLE",
+		"-----------------",
+		"#1  ✓       ● hello: fix crash",
+		"",
+		"Unmerged related pull requests:",
+		"",
+		"  ●  #3  hello: other fix",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("RenderIssueStatus() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBatchResult_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	results := []core.BatchResult{
+		{Number: 1, Status: &core.PRStatus{Number: 1, State: core.PRStateMerged, Channels: []core.ChannelResult{}}},
+		{Number: 2, Err: fmt.Errorf("boom")},
+		{Commit: "3f2a9c1", Err: fmt.Errorf("boom")},
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"schema_version":1,"pr":1,"state":"merged","channels":[]},` +
+		`{"schema_version":1,"pr":2,"error":{"code":"error","message":"boom"}},` +
+		`{"schema_version":1,"commit":"3f2a9c1","error":{"code":"error","message":"boom"}}]`
+	if string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+}
+
+func TestMatrixColumns(t *testing.T) {
+	t.Parallel()
+
+	results := []core.BatchResult{
+		{Number: 1, Status: &core.PRStatus{
+			Pipeline: [][]string{{"master"}, {"nixos-unstable-small"}},
+			Channels: []core.ChannelResult{{Name: "nixos-unstable-small"}, {Name: "master"}, {Name: "nixos-25.11"}},
+		}},
+		{Number: 2, Err: fmt.Errorf("boom")},
+		{Number: 3, Status: &core.PRStatus{
+			Channels: []core.ChannelResult{{Name: "nixpkgs-unstable"}, {Name: "master"}},
+		}},
+	}
+
+	want := []string{"master", "nixos-unstable-small", "nixos-25.11", "nixpkgs-unstable"}
+	if got := core.MatrixColumns(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("MatrixColumns() = %v, want %v", got, want)
+	}
+}
+
+func TestRenderMatrix(t *testing.T) {
+	t.Setenv("NO_NERD_FONTS", "1")
+
+	results := []core.BatchResult{
+		{Number: 1, Status: &core.PRStatus{
+			Number: 1,
+			Title:  "hello: 1.0 -> 2.0",
+			State:  core.PRStateMerged,
+			Channels: []core.ChannelResult{
+				{Name: "master", Status: core.StatusPresent},
+				{Name: "nixos-unstable", Status: core.StatusNotPresent},
+			},
+		}},
+		{Number: 123, Status: &core.PRStatus{
+			Number:   123,
+			Title:    "open PR",
+			State:    core.PRStateOpen,
+			Channels: []core.ChannelResult{{Name: "master", Status: core.StatusNotPresent}},
+		}},
+		{Number: 42, Err: fmt.Errorf("no PR or issue #42 exists in NixOS/nixpkgs")},
+	}
+
+	var buf bytes.Buffer
+	if err := render.NewRenderer(&buf, false, false).RenderMatrix(results); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"PR    master  nixos-unstable  TITLE",
+		"-----------------------------------",
+		"#1    ✓       ✗               ● hello: 1.0 -> 2.0",
+		"#123  ✗       -               ● open PR",
+		"#42   Error: no PR or issue #42 exists in NixOS/nixpkgs",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Errorf("RenderMatrix() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thatsneat-dev/nprt/internal/cache"
+)
+
+func TestStore_PutGet(t *testing.T) {
+	store := cache.New(t.TempDir())
+
+	if err := store.Put("key", []string{"a", "b"}, 0); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	var got []string
+	if !store.Get("key", &got) {
+		t.Fatal("Get should find stored value")
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Get = %v, want [a b]", got)
+	}
+}
+
+func TestStore_Missing(t *testing.T) {
+	store := cache.New(t.TempDir())
+
+	var got string
+	if store.Get("missing", &got) {
+		t.Error("Get should return false for missing key")
+	}
+}
+
+func TestStore_Expired(t *testing.T) {
+	store := cache.New(t.TempDir())
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	store.Now = func() time.Time { return now }
+
+	if err := store.Put("key", "value", time.Hour); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	var got string
+	if !store.Get("key", &got) {
+		t.Fatal("Get should find unexpired value")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if store.Get("key", &got) {
+		t.Error("Get should not return expired value")
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	store := cache.New(t.TempDir())
+
+	if err := store.Put("key", "value", 0); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+	if err := store.Delete("key"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+
+	var got string
+	if store.Get("key", &got) {
+		t.Error("Get should return false after Delete")
+	}
+	if err := store.Delete("key"); err != nil {
+		t.Errorf("Delete of missing key should not error: %v", err)
+	}
+}
+
+func TestDefaultDir_XDGCacheHome(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		t.Fatalf("DefaultDir returned error: %v", err)
+	}
+	if dir != "/tmp/xdg-cache/nprt" {
+		t.Errorf("DefaultDir = %q, want %q", dir, "/tmp/xdg-cache/nprt")
+	}
+}
+
+func TestStore_Refresh(t *testing.T) {
+	store := cache.New(t.TempDir())
+
+	if err := store.Put("key", "old", 0); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	store.Refresh = true
+	var got string
+	if store.Get("key", &got) {
+		t.Error("Get should miss when Refresh is set")
+	}
+	if err := store.Put("key", "new", 0); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	store.Refresh = false
+	if !store.Get("key", &got) || got != "new" {
+		t.Errorf("Get = %q, want %q", got, "new")
+	}
+}
+
+func TestStore_Namespace(t *testing.T) {
+	dir := t.TempDir()
+	public := cache.New(dir)
+	enterprise := cache.New(dir)
+	enterprise.Namespace = "https://github.example.com/"
+
+	if err := public.Put("key", "public", 0); err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	if enterprise.Get("key", &got) {
+		t.Error("Get should not see entries of another namespace")
+	}
+
+	if err := enterprise.Put("key", "enterprise", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !public.Get("key", &got) || got != "public" {
+		t.Errorf("public Get = %q, want the entry to be kept", got)
+	}
+	if !enterprise.Get("key", &got) || got != "enterprise" {
+		t.Errorf("enterprise Get = %q, want %q", got, "enterprise")
+	}
+}
+
+func TestStore_StatsAndClear(t *testing.T) {
+	store := cache.New(t.TempDir())
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	store.Now = func() time.Time { return now }
+
+	for key, ttl := range map[string]time.Duration{
+		"pulls/NixOS/nixpkgs/1":          0,
+		"compare/NixOS/nixpkgs/a...b":    0,
+		"compare/NixOS/nixpkgs/a...c":    time.Minute,
+		"branches/NixOS/nixpkgs/release": time.Hour,
+	} {
+		if err := store.Put(key, "value", ttl); err != nil {
+			t.Fatalf("Put returned error: %v", err)
+		}
+	}
+	now = now.Add(30 * time.Minute)
+
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatalf("Stats returned error: %v", err)
+	}
+	if stats.Entries != 4 {
+		t.Errorf("Entries = %d, want 4", stats.Entries)
+	}
+	if stats.Expired != 1 {
+		t.Errorf("Expired = %d, want 1", stats.Expired)
+	}
+	if stats.ByKind["compare"] != 2 || stats.ByKind["pulls"] != 1 || stats.ByKind["branches"] != 1 {
+		t.Errorf("ByKind = %v, want compare:2 pulls:1 branches:1", stats.ByKind)
+	}
+	if stats.Bytes == 0 {
+		t.Error("Bytes should be non-zero")
+	}
+
+	removed, err := store.Clear()
+	if err != nil {
+		t.Fatalf("Clear returned error: %v", err)
+	}
+	if removed != 4 {
+		t.Errorf("Clear removed %d entries, want 4", removed)
+	}
+
+	stats, err = store.Stats()
+	if err != nil {
+		t.Fatalf("Stats returned error: %v", err)
+	}
+	if stats.Entries != 0 {
+		t.Errorf("Entries after Clear = %d, want 0", stats.Entries)
+	}
+}
+
+func TestStore_StatsNamespaced(t *testing.T) {
+	dir := t.TempDir()
+	enterprise := cache.New(dir)
+	enterprise.Namespace = "https://github.example.com/"
+	for _, key := range []string{"pulls/NixOS/nixpkgs/1", "compare/NixOS/nixpkgs/a...b"} {
+		if err := enterprise.Put(key, "value", 0); err != nil {
+			t.Fatalf("Put returned error: %v", err)
+		}
+	}
+	if err := cache.New(dir).Put("pulls/NixOS/nixpkgs/2", "value", 0); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	// nprt cache stats reads the directory without a namespace.
+	for _, store := range []*cache.Store{cache.New(dir), enterprise} {
+		stats, err := store.Stats()
+		if err != nil {
+			t.Fatalf("Stats returned error: %v", err)
+		}
+		if len(stats.ByKind) != 2 || stats.ByKind["pulls"] != 2 || stats.ByKind["compare"] != 1 {
+			t.Errorf("ByKind with namespace %q = %v, want pulls:2 compare:1", store.Namespace, stats.ByKind)
+		}
+	}
+}
+
+func TestStore_StatsMissingDir(t *testing.T) {
+	store := cache.New(t.TempDir() + "/missing")
+
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatalf("Stats returned error: %v", err)
+	}
+	if stats.Entries != 0 {
+		t.Errorf("Entries = %d, want 0", stats.Entries)
+	}
+}
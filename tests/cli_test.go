@@ -175,3 +175,56 @@ func TestParseFlagName(t *testing.T) {
 		})
 	}
 }
+
+func TestStringList(t *testing.T) {
+	t.Parallel()
+
+	var hooks cli.StringList
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&hooks, "on-change", "")
+
+	args := cli.ReorderArgs(fs, []string{"123", "--on-change", "exec:true", "--on-change=ntfy:https://ntfy.sh/x"})
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	want := cli.StringList{"exec:true", "ntfy:https://ntfy.sh/x"}
+	if !reflect.DeepEqual(hooks, want) {
+		t.Errorf("hooks = %v, want %v", hooks, want)
+	}
+	if !reflect.DeepEqual(fs.Args(), []string{"123"}) {
+		t.Errorf("args = %v, want [123]", fs.Args())
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		flag      string
+		args      []string
+		wantValue string
+		wantFound bool
+	}{
+		{"not given", "color", []string{"476497", "--json"}, "", false},
+		{"separate value", "color", []string{"476497", "--color", "never"}, "never", true},
+		{"equals syntax", "color", []string{"--color=always", "476497"}, "always", true},
+		{"empty value", "color", []string{"--color=", "476497"}, "", true},
+		{"last occurrence wins", "color", []string{"--color", "never", "--color=always"}, "always", true},
+		{"value of another flag", "color", []string{"--channels", "--color", "476497"}, "", false},
+		{"after double dash", "color", []string{"--", "--color", "never"}, "", false},
+		{"bool flag", "json", []string{"476497", "--json"}, "true", true},
+		{"bool flag with value", "json", []string{"--json=false", "476497"}, "false", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			value, found := cli.FlagValue(testFlagSet(), tt.args, tt.flag)
+			if value != tt.wantValue || found != tt.wantFound {
+				t.Errorf("FlagValue(%v) = %q, %v, want %q, %v", tt.args, value, found, tt.wantValue, tt.wantFound)
+			}
+		})
+	}
+}
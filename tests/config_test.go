@@ -2,6 +2,7 @@ package tests
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/thatsneat-dev/nprt/internal/config"
@@ -22,8 +23,8 @@ func TestParsePRInput_ValidNumber(t *testing.T) {
 		if err != nil {
 			t.Errorf("ParsePRInput(%q) returned error: %v", tc.input, err)
 		}
-		if result != tc.expected {
-			t.Errorf("ParsePRInput(%q) = %d, want %d", tc.input, result, tc.expected)
+		if result.Number != tc.expected {
+			t.Errorf("ParsePRInput(%q) = %d, want %d", tc.input, result.Number, tc.expected)
 		}
 	}
 }
@@ -45,8 +46,8 @@ func TestParsePRInput_ValidURL(t *testing.T) {
 		if err != nil {
 			t.Errorf("ParsePRInput(%q) returned error: %v", tc.input, err)
 		}
-		if result != tc.expected {
-			t.Errorf("ParsePRInput(%q) = %d, want %d", tc.input, result, tc.expected)
+		if result.Number != tc.expected {
+			t.Errorf("ParsePRInput(%q) = %d, want %d", tc.input, result.Number, tc.expected)
 		}
 	}
 }
@@ -55,10 +56,12 @@ func TestParsePRInput_Invalid(t *testing.T) {
 	tests := []string{
 		"",
 		"abc",
+		"abc123",
+		"3f2a9c1g",
 		"-1",
 		"0",
-		"https://github.com/other/repo/pull/123",
-		"https://github.com/other/repo/issues/123",
+		"https://github.com/other/pull/123",
+		"https://github.com/-other/repo/issues/123",
 		"github.com/NixOS/nixpkgs/pull/123",
 	}
 
@@ -70,6 +73,120 @@ func TestParsePRInput_Invalid(t *testing.T) {
 	}
 }
 
+func TestParsePRInput_Commit(t *testing.T) {
+	tests := []struct {
+		input  string
+		commit string
+		repo   string
+	}{
+		{"3f2a9c1", "3f2a9c1", ""},
+		{"3F2A9C1E5B7D", "3f2a9c1e5b7d", ""},
+		{"3f2a9c1e5b7d00000000000000000000000000ab", "3f2a9c1e5b7d00000000000000000000000000ab", ""},
+		{"https://github.com/NixOS/nixpkgs/commit/3f2a9c1e5b7d", "3f2a9c1e5b7d", "NixOS/nixpkgs"},
+		{"https://github.com/nix-community/home-manager/commits/3f2a9c1/", "3f2a9c1", "nix-community/home-manager"},
+	}
+
+	for _, tc := range tests {
+		result, err := config.ParsePRInput(tc.input)
+		if err != nil {
+			t.Fatalf("ParsePRInput(%q) returned error: %v", tc.input, err)
+		}
+		if result.Commit != tc.commit || result.Number != 0 || result.Repo.String() != tc.repo {
+			t.Errorf("ParsePRInput(%q) = %+v, want commit %q in %q", tc.input, result, tc.commit, tc.repo)
+		}
+	}
+
+	// Seven or more digits are still a PR number.
+	if result, err := config.ParsePRInput("4764970"); err != nil || result.Number != 4764970 || result.Commit != "" {
+		t.Errorf("ParsePRInput(\"4764970\") = %+v, %v, want PR 4764970", result, err)
+	}
+}
+
+func TestParsePRInput_CopyPasteForms(t *testing.T) {
+	tests := []struct {
+		input  string
+		kind   config.RefKind
+		repo   string
+		number int
+		commit string
+	}{
+		{"#123", config.RefPR, "", 123, ""},
+		{"NixOS/nixpkgs#123", config.RefPR, "NixOS/nixpkgs", 123, ""},
+		{"nix-community/home-manager#6000", config.RefPR, "nix-community/home-manager", 6000, ""},
+		{"https://github.com/NixOS/nixpkgs/pull/123/files", config.RefPR, "NixOS/nixpkgs", 123, ""},
+		{"https://github.com/NixOS/nixpkgs/pull/123#issuecomment-2589001234", config.RefPR, "NixOS/nixpkgs", 123, ""},
+		{"https://github.com/NixOS/nixpkgs/pull/123/commits/3f2a9c1e5b7d", config.RefPR, "NixOS/nixpkgs", 123, ""},
+		{"https://github.com/NixOS/nixpkgs/pull/123?notification_referrer_id=abc", config.RefPR, "NixOS/nixpkgs", 123, ""},
+		{"https://github.com/NixOS/nixpkgs/pull/123.diff", config.RefPR, "NixOS/nixpkgs", 123, ""},
+		{"http://github.com/NixOS/nixpkgs/pull/123", config.RefPR, "NixOS/nixpkgs", 123, ""},
+		{"https://www.github.com/NixOS/nixpkgs/pull/123", config.RefPR, "NixOS/nixpkgs", 123, ""},
+		{"https://GitHub.com/NixOS/nixpkgs/pull/123", config.RefPR, "NixOS/nixpkgs", 123, ""},
+		{"https://github.com/NixOS/nixpkgs/issues/456#event-1", config.RefIssue, "NixOS/nixpkgs", 456, ""},
+		{"https://github.com/NixOS/nixpkgs/commit/3F2A9C1?diff=split", config.RefCommit, "NixOS/nixpkgs", 0, "3f2a9c1"},
+		{"476497", config.RefPR, "", 476497, ""},
+		{"3f2a9c1", config.RefCommit, "", 0, "3f2a9c1"},
+	}
+
+	for _, tc := range tests {
+		result, err := config.ParsePRInput(tc.input)
+		if err != nil {
+			t.Errorf("ParsePRInput(%q) returned error: %v", tc.input, err)
+			continue
+		}
+		if result.Kind != tc.kind || result.Repo.String() != tc.repo || result.Number != tc.number || result.Commit != tc.commit {
+			t.Errorf("ParsePRInput(%q) = %+v, want %s %q #%d %q", tc.input, result, tc.kind, tc.repo, tc.number, tc.commit)
+		}
+	}
+}
+
+func TestParsePRInput_PreciseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"https://gitlab.com/NixOS/nixpkgs/pull/123", "URL is on gitlab.com, not github.com; use --web-url"},
+		{"https://github.com.example.org/NixOS/nixpkgs/pull/123", "URL is on github.com.example.org"},
+		{"https://github.com/NixOS/nixpkgs", "does not point to a pull request, issue or commit"},
+		{"https://github.com/NixOS/nixpkgs/pulls/123", "does not point to a pull request, issue or commit"},
+		{"https://github.com/NixOS/nixpkgs/pull/abc", `"abc" in URL is not a PR or issue number`},
+		{"https://github.com/NixOS/nixpkgs/commit/xyz", `"xyz" in URL is not a commit SHA`},
+		{"ftp://github.com/NixOS/nixpkgs/pull/123", `unsupported URL scheme "ftp"`},
+		{"github.com/NixOS/nixpkgs/pull/123", "URLs must start with https://"},
+		{"NixOS/nixpkgs#abc", `"abc" after # is not a PR number`},
+		{"-bad/repo#1", "invalid repository"},
+		{"0", "must be a positive integer"},
+	}
+
+	for _, tc := range tests {
+		_, err := config.ParsePRInput(tc.input)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("ParsePRInput(%q) error = %v, want it to contain %q", tc.input, err, tc.want)
+		}
+	}
+}
+
+func TestParsePRInput_InfersRepo(t *testing.T) {
+	tests := []struct {
+		input string
+		repo  string
+	}{
+		{"476497", ""},
+		{"https://github.com/NixOS/nixpkgs/pull/476497", "NixOS/nixpkgs"},
+		{"https://github.com/nix-community/home-manager/pull/123", "nix-community/home-manager"},
+		{"https://github.com/LnL7/nix-darwin/issues/45/", "LnL7/nix-darwin"},
+	}
+
+	for _, tc := range tests {
+		result, err := config.ParsePRInput(tc.input)
+		if err != nil {
+			t.Fatalf("ParsePRInput(%q) returned error: %v", tc.input, err)
+		}
+		if got := result.Repo.String(); got != tc.repo {
+			t.Errorf("ParsePRInput(%q).Repo = %q, want %q", tc.input, got, tc.repo)
+		}
+	}
+}
+
 func TestShouldUseColor_InvalidMode(t *testing.T) {
 	invalidModes := []string{"invalid", "yes", "true", "on"}
 	for _, mode := range invalidModes {
@@ -128,6 +245,28 @@ func TestShouldUseHyperlinks_ValidModes(t *testing.T) {
 	}
 }
 
+func TestShouldCheckIssues(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected bool
+	}{
+		{"check", true},
+		{"list", false},
+	}
+	for _, tc := range tests {
+		result, err := config.ShouldCheckIssues(tc.mode)
+		if err != nil {
+			t.Errorf("ShouldCheckIssues(%q) returned error: %v", tc.mode, err)
+		}
+		if result != tc.expected {
+			t.Errorf("ShouldCheckIssues(%q) = %v, want %v", tc.mode, result, tc.expected)
+		}
+	}
+	if _, err := config.ShouldCheckIssues("always"); err == nil {
+		t.Error("ShouldCheckIssues(\"always\") should have returned error")
+	}
+}
+
 func TestShouldUseHyperlinks_NoHyperlinksEnv(t *testing.T) {
 	t.Setenv("NO_HYPERLINKS", "1")
 	result, err := config.ShouldUseHyperlinks("auto")
@@ -173,7 +312,7 @@ func TestShouldUseHyperlinks_IgnoresNoColor(t *testing.T) {
 }
 
 func TestParseChannels_Default(t *testing.T) {
-	channels, err := config.ParseChannels("")
+	channels, err := config.ParseChannels("", nil)
 	if err != nil {
 		t.Fatalf("ParseChannels(\"\") returned error: %v", err)
 	}
@@ -183,7 +322,7 @@ func TestParseChannels_Default(t *testing.T) {
 }
 
 func TestParseChannels_Custom(t *testing.T) {
-	channels, err := config.ParseChannels("master,nixos-unstable")
+	channels, err := config.ParseChannels("master,nixos-unstable", nil)
 	if err != nil {
 		t.Fatalf("ParseChannels returned error: %v", err)
 	}
@@ -199,14 +338,14 @@ func TestParseChannels_Custom(t *testing.T) {
 }
 
 func TestParseChannels_InvalidChannel(t *testing.T) {
-	_, err := config.ParseChannels("nonexistent-channel")
+	_, err := config.ParseChannels("nonexistent-channel", nil)
 	if err == nil {
 		t.Error("ParseChannels should return error for invalid channel")
 	}
 }
 
 func TestParseChannels_Whitespace(t *testing.T) {
-	channels, err := config.ParseChannels(" master , nixos-unstable ")
+	channels, err := config.ParseChannels(" master , nixos-unstable ", nil)
 	if err != nil {
 		t.Fatalf("ParseChannels returned error: %v", err)
 	}
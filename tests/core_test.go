@@ -2,10 +2,12 @@ package tests
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -251,3 +253,265 @@ func TestCheckPR_ChannelError(t *testing.T) {
 		t.Error("bad-branch not found in results")
 	}
 }
+
+func TestCheckPR_OtherRepoChecksBaseBranch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/home-manager/pulls/7"):
+			w.Write([]byte(`{
+				"number": 7,
+				"state": "closed",
+				"merged": true,
+				"merge_commit_sha": "abc123def456789012",
+				"base": {"ref": "release-25.05"}
+			}`))
+		case strings.HasSuffix(r.URL.Path, "/home-manager/compare/abc123def456789012...release-25.05"):
+			w.Write([]byte(`{"status": "ahead", "ahead_by": 3, "behind_by": 0}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	repo := config.Repo{Owner: "nix-community", Name: "home-manager"}
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	client.Repo = repo
+	checker := core.NewChecker(client, zap.NewNop())
+	checker.Repo = repo
+
+	status, err := checker.CheckPR(context.Background(), 7, nil)
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+	if status.Repo != "nix-community/home-manager" {
+		t.Errorf("Repo = %q, want nix-community/home-manager", status.Repo)
+	}
+	if len(status.Channels) != 1 || status.Channels[0].Name != "release-25.05" || status.Channels[0].Status != core.StatusPresent {
+		t.Errorf("Channels = %+v, want release-25.05 present", status.Channels)
+	}
+}
+
+func TestCheckPR_EnterpriseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"number": 300, "state": "open", "merged": false, "base": {"ref": "master"}}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+	checker.Host = config.Host{APIURL: server.URL, WebURL: "https://github.example.com"}
+
+	status, err := checker.CheckPR(context.Background(), 300, []config.Channel{{Name: "master", Branch: "master"}})
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+	if want := "https://github.example.com/NixOS/nixpkgs/pull/300"; status.URL != want {
+		t.Errorf("URL = %q, want %q", status.URL, want)
+	}
+}
+
+func TestCheckCommit_ShowsPullRequest(t *testing.T) {
+	const sha = "3f2a9c1e5b7d0000000000000000000000000000"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/NixOS/nixpkgs/commits/3f2a9c1":
+			if accept := r.Header.Get("Accept"); accept != "application/vnd.github.sha" {
+				t.Errorf("Accept = %q, want application/vnd.github.sha", accept)
+			}
+			w.Write([]byte(sha))
+		case "/repos/NixOS/nixpkgs/commits/" + sha + "/pulls":
+			w.Write([]byte(`[
+				{"number": 8, "title": "closed", "state": "closed", "merged_at": null, "base": {"ref": "master"}},
+				{"number": 9, "title": "hello: 1.0 -> 2.0", "user": {"login": "someone"}, "state": "closed",
+				 "merged_at": "2025-01-01T00:00:00Z", "merge_commit_sha": "9999999999999999", "base": {"ref": "master"}}
+			]`))
+		case "/repos/NixOS/nixpkgs/compare/" + sha + "...master":
+			w.Write([]byte(`{"status": "behind", "ahead_by": 0, "behind_by": 2}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+
+	status, err := checker.CheckCommit(context.Background(), "3f2a9c1", []config.Channel{{Name: "master", Branch: "master"}})
+	if err != nil {
+		t.Fatalf("CheckCommit returned error: %v", err)
+	}
+	if status.Number != 9 || status.Title != "hello: 1.0 -> 2.0" || status.Author != "someone" || status.State != core.PRStateMerged {
+		t.Errorf("status = %+v, want merged PR #9 by someone", status)
+	}
+	if status.Commit != sha {
+		t.Errorf("Commit = %q, want %q", status.Commit, sha)
+	}
+	if want := "https://github.com/NixOS/nixpkgs/commit/" + sha; status.CommitURL != want {
+		t.Errorf("CommitURL = %q, want %q", status.CommitURL, want)
+	}
+	if len(status.Channels) != 1 || status.Channels[0].Status != core.StatusNotPresent {
+		t.Errorf("Channels = %+v, want master not present", status.Channels)
+	}
+}
+
+func TestCheckCommit_WithoutPullRequest(t *testing.T) {
+	const sha = "3f2a9c1e5b7d0000000000000000000000000000"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pulls"):
+			w.Write([]byte(`[]`))
+		case strings.Contains(r.URL.Path, "/commits/"):
+			w.Write([]byte(sha))
+		case strings.Contains(r.URL.Path, "/compare/"):
+			w.Write([]byte(`{"status": "identical", "ahead_by": 0, "behind_by": 0}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	repo := config.Repo{Owner: "nix-community", Name: "home-manager"}
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	client.Repo = repo
+	checker := core.NewChecker(client, zap.NewNop())
+	checker.Repo = repo
+
+	if _, err := checker.CheckCommit(context.Background(), sha, nil); err == nil || !strings.Contains(err.Error(), "--channels") {
+		t.Errorf("CheckCommit without channels error = %v, want a hint to use --channels", err)
+	}
+
+	status, err := checker.CheckCommit(context.Background(), sha, []config.Channel{{Name: "master", Branch: "master"}})
+	if err != nil {
+		t.Fatalf("CheckCommit returned error: %v", err)
+	}
+	if got := status.Subject(); got != "Commit 3f2a9c1e5b7d" {
+		t.Errorf("Subject() = %q, want Commit 3f2a9c1e5b7d", got)
+	}
+	if len(status.Channels) != 1 || status.Channels[0].Status != core.StatusPresent {
+		t.Errorf("Channels = %+v, want master present", status.Channels)
+	}
+}
+
+func TestCheckCommit_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message": "No commit found for SHA: deadbeef"}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+
+	_, err := checker.CheckCommit(context.Background(), "deadbeef", nil)
+	var nf *github.CommitNotFoundError
+	if !errors.As(err, &nf) || nf.SHA != "deadbeef" {
+		t.Errorf("error = %v, want CommitNotFoundError for deadbeef", err)
+	}
+}
+
+func TestCheckPR_Details(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pulls/100"):
+			w.Write([]byte(`{"number": 100, "state": "closed", "merged": true,
+				"merge_commit_sha": "abc123def456789012", "base": {"ref": "master"}}`))
+		case strings.Contains(r.URL.Path, "/compare/"):
+			if strings.HasSuffix(r.URL.Path, "...master") {
+				w.Write([]byte(`{"status": "ahead", "ahead_by": 10, "behind_by": 0}`))
+			} else {
+				w.Write([]byte(`{"status": "diverged", "ahead_by": 3, "behind_by": 7}`))
+			}
+		case strings.HasSuffix(r.URL.Path, "/branches/nixos-unstable"):
+			w.Write([]byte(`{"commit": {"sha": "feedface00000000", "commit": {"committer": {"date": "2026-01-01T00:00:00Z"}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+	channels := []config.Channel{
+		{Name: "master", Branch: "master"},
+		{Name: "nixos-unstable", Branch: "nixos-unstable"},
+	}
+
+	status, err := checker.CheckPR(context.Background(), 100, channels)
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+	unstable := status.Channels[1]
+	if unstable.CommitsBehind != 7 {
+		t.Errorf("CommitsBehind = %d, want 7", unstable.CommitsBehind)
+	}
+	if unstable.Head != "" {
+		t.Errorf("Head = %q without Details, want none", unstable.Head)
+	}
+
+	checker.Details = true
+	checker.Now = func() time.Time { return time.Date(2026, 1, 3, 12, 0, 0, 0, time.UTC) }
+	status, err = checker.CheckPR(context.Background(), 100, channels)
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+	unstable = status.Channels[1]
+	if unstable.Head != "feedface00000000" || unstable.HeadAgeSeconds != 60*60*60 {
+		t.Errorf("nixos-unstable = %+v, want head feedface00000000 committed 60h ago", unstable)
+	}
+	if master := status.Channels[0]; master.Head != "" || master.Status != core.StatusPresent {
+		t.Errorf("master = %+v, want present without a head (lookup failed)", master)
+	}
+}
+
+func TestCheckPR_Landing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pulls/100"):
+			w.Write([]byte(`{"number": 100, "state": "closed", "merged": true,
+				"merge_commit_sha": "abc123def456789012", "base": {"ref": "master"}}`))
+		case strings.HasSuffix(r.URL.Path, "/branches/master"):
+			w.Write([]byte(`{"commit": {"sha": "abc123def456789012"}}`))
+		case strings.HasSuffix(r.URL.Path, "...master"), strings.HasSuffix(r.URL.Path, "...abc123def456789012"):
+			w.Write([]byte(`{"status": "identical", "behind_by": 0}`))
+		case strings.HasSuffix(r.URL.Path, "...nixos-unstable"):
+			w.Write([]byte(`{"status": "diverged", "behind_by": 7}`))
+		case strings.HasSuffix(r.URL.Path, "/commits") && r.URL.Query().Get("sha") == "abc123def456789012~0":
+			w.Write([]byte(`[{"sha": "abc123def456789012", "commit": {"committer": {"date": "2026-01-02T03:04:05Z"}}}]`))
+		default:
+			// The first parent of the merge commit does not contain it.
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+	checker.Landing = true
+	channels := []config.Channel{
+		{Name: "master", Branch: "master"},
+		{Name: "nixos-unstable", Branch: "nixos-unstable"},
+	}
+
+	status, err := checker.CheckPR(context.Background(), 100, channels)
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+	master := status.Channels[0]
+	want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if master.LandedRevision != "abc123def456789012" || !master.LandedAt.Equal(want) {
+		t.Errorf("master = %+v, want landed with abc123def456789012 at %s", master, want)
+	}
+	if unstable := status.Channels[1]; unstable.LandedRevision != "" || !unstable.LandedAt.IsZero() {
+		t.Errorf("nixos-unstable = %+v, want no landing", unstable)
+	}
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+)
+
+func TestDiffChannels(t *testing.T) {
+	prev := &core.PRStatus{Channels: []core.ChannelResult{
+		{Name: "master", Status: core.StatusPresent},
+		{Name: "nixos-unstable", Status: core.StatusNotPresent},
+	}}
+	cur := &core.PRStatus{Channels: []core.ChannelResult{
+		{Name: "master", Status: core.StatusPresent},
+		{Name: "nixos-unstable", Status: core.StatusPresent},
+		{Name: "nixpkgs-unstable", Status: core.StatusNotPresent},
+	}}
+
+	changes := core.DiffChannels(prev, cur)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d: %+v", len(changes), changes)
+	}
+	if changes[0].Name != "nixos-unstable" || changes[0].From != core.StatusNotPresent || changes[0].To != core.StatusPresent {
+		t.Errorf("first change = %+v, want nixos-unstable not_present -> present", changes[0])
+	}
+	if changes[1].Name != "nixpkgs-unstable" || changes[1].From != core.StatusUnknown {
+		t.Errorf("second change = %+v, want nixpkgs-unstable from unknown", changes[1])
+	}
+}
+
+func TestDiffChannels_NoPrevious(t *testing.T) {
+	cur := &core.PRStatus{Channels: []core.ChannelResult{{Name: "master", Status: core.StatusPresent}}}
+	if changes := core.DiffChannels(nil, cur); changes != nil {
+		t.Errorf("DiffChannels(nil, cur) = %+v, want nil", changes)
+	}
+}
+
+func TestHasChannels(t *testing.T) {
+	status := &core.PRStatus{Channels: []core.ChannelResult{
+		{Name: "master", Status: core.StatusPresent},
+		{Name: "nixos-unstable", Status: core.StatusUnknown},
+	}}
+
+	if !core.HasChannels(status, []string{"master"}) {
+		t.Error("HasChannels should be true for present channel")
+	}
+	if core.HasChannels(status, []string{"master", "nixos-unstable"}) {
+		t.Error("HasChannels should be false when a channel is unknown")
+	}
+	if core.HasChannels(status, []string{"nixos-25.05"}) {
+		t.Error("HasChannels should be false for unchecked channel")
+	}
+}
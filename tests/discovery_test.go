@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/cache"
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+func newMatchingRefsServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/git/matching-refs/heads/nixos-"):
+			w.Write([]byte(`[
+				{"ref": "refs/heads/nixos-25.05"},
+				{"ref": "refs/heads/nixos-25.05-small"},
+				{"ref": "refs/heads/nixos-24.11"},
+				{"ref": "refs/heads/nixos-unstable"}
+			]`))
+		case strings.HasSuffix(r.URL.Path, "/git/matching-refs/heads/nixpkgs-"):
+			w.Write([]byte(`[{"ref": "refs/heads/nixpkgs-25.05-darwin"}]`))
+		case strings.HasSuffix(r.URL.Path, "/git/matching-refs/heads/release-"):
+			w.Write([]byte(`[{"ref": "refs/heads/release-25.05"}]`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestListBranches(t *testing.T) {
+	var requests atomic.Int32
+	server := newMatchingRefsServer(t, &requests)
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+
+	branches, err := client.ListBranches(context.Background(), "nixos-")
+	if err != nil {
+		t.Fatalf("ListBranches returned error: %v", err)
+	}
+	if len(branches) != 4 {
+		t.Fatalf("ListBranches returned %d branches, want 4", len(branches))
+	}
+	if branches[0] != "nixos-25.05" {
+		t.Errorf("first branch = %q, want nixos-25.05 (refs/heads/ prefix stripped)", branches[0])
+	}
+}
+
+func TestDiscoverChannels_UsesCache(t *testing.T) {
+	var requests atomic.Int32
+	server := newMatchingRefsServer(t, &requests)
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	store := cache.New(t.TempDir())
+
+	channels, err := core.DiscoverChannels(context.Background(), client, store, zap.NewNop())
+	if err != nil {
+		t.Fatalf("DiscoverChannels returned error: %v", err)
+	}
+	if len(channels) != 5 {
+		t.Fatalf("DiscoverChannels returned %d channels, want 5", len(channels))
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 requests on first discovery, got %d", requests.Load())
+	}
+
+	cached, err := core.DiscoverChannels(context.Background(), client, store, zap.NewNop())
+	if err != nil {
+		t.Fatalf("DiscoverChannels (cached) returned error: %v", err)
+	}
+	if len(cached) != len(channels) {
+		t.Errorf("cached discovery returned %d channels, want %d", len(cached), len(channels))
+	}
+	if requests.Load() != 3 {
+		t.Errorf("cached discovery should not hit the API, got %d total requests", requests.Load())
+	}
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+func TestNewErrorInfo_Codes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want core.ErrorCode
+	}{
+		{"not found", &github.NotFoundError{Repo: config.DefaultRepo, Number: 1}, core.ErrorNotFound},
+		{"commit not found", &github.CommitNotFoundError{Repo: config.DefaultRepo, SHA: "3f2a9c1"}, core.ErrorNotFound},
+		{"issue", &github.NotPullRequestError{Number: 2}, core.ErrorIsIssue},
+		{"rate limited", &github.APIError{StatusCode: 403, Message: "rate limit", RateLimited: true}, core.ErrorRateLimited},
+		{"too many requests", &github.APIError{StatusCode: 429}, core.ErrorRateLimited},
+		{"forbidden", &github.APIError{StatusCode: 403, Message: "Resource not accessible"}, core.ErrorAuth},
+		{"bad credentials", &github.APIError{StatusCode: 401, Message: "Bad credentials"}, core.ErrorAuth},
+		{"server error", &github.APIError{StatusCode: 502}, core.ErrorAPI},
+		{"network", fmt.Errorf("network error talking to GitHub: %w", &net.OpError{Op: "dial", Err: errors.New("refused")}), core.ErrorNetwork},
+		{"wrapped", fmt.Errorf("fetching PR: %w", &github.NotFoundError{Number: 3}), core.ErrorNotFound},
+		{"usage", &core.UsageError{Message: "invalid PR number"}, core.ErrorUsage},
+		{"other", errors.New("boom"), core.ErrorOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := core.NewErrorInfo(tt.err).Code; got != tt.want {
+				t.Errorf("NewErrorInfo(%v).Code = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewErrorInfo_Issue(t *testing.T) {
+	info := core.NewErrorInfo(&github.NotPullRequestError{
+		Number:     2,
+		Title:      "bug",
+		RelatedPRs: []github.RelatedPR{{Number: 3, State: github.StateMerged}},
+	})
+	if info.Issue == nil || info.Issue.Number != 2 || len(info.Issue.RelatedPRs) != 1 {
+		t.Fatalf("Issue = %+v, want issue #2 with one related PR", info.Issue)
+	}
+	if strings.Contains(info.Message, "\n") {
+		t.Errorf("Message = %q, want a single line", info.Message)
+	}
+
+	info = core.NewErrorInfo(&github.NotPullRequestError{Number: 4})
+	if info.Issue.RelatedPRs == nil {
+		t.Error("RelatedPRs = nil, want an empty list")
+	}
+}
+
+func TestCLI_JSONErrors(t *testing.T) {
+	bin := buildNprt(t)
+	schema := loadSchema(t)
+
+	// PR 100 is merged but not in nixos-unstable; PR 404 does not exist.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pulls/100"):
+			w.Write([]byte(`{"number": 100, "state": "closed", "merged": true,
+				"merge_commit_sha": "abc123", "base": {"ref": "master"}}`))
+		case strings.Contains(r.URL.Path, "/git/matching-refs/"):
+			w.Write([]byte(`[]`))
+		case strings.Contains(r.URL.Path, "/compare/"):
+			w.Write([]byte(`{"status": "diverged", "behind_by": 3}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	home := t.TempDir()
+	env := []string{"PATH=" + os.Getenv("PATH"), "HOME=" + home, "XDG_CONFIG_HOME=" + home, "XDG_CACHE_HOME=" + home}
+	common := []string{"--no-cache", "--retries=0", "--api-url", server.URL}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantExit int
+		wantCode core.ErrorCode
+		wantPR   int
+	}{
+		{"invalid PR", []string{"--json", "notapr"}, 2, core.ErrorUsage, 0},
+		{"invalid channels", []string{"--json", "--channels", "bogus", "100"}, 2, core.ErrorUsage, 100},
+		{"unknown flag", []string{"--ndjson", "--bogus", "100"}, 2, core.ErrorUsage, 0},
+		{"invalid option", []string{"--format=json", "--retries=20", "100"}, 2, core.ErrorUsage, 0},
+		{"watch usage", []string{"watch", "--json", "--interval=1s", "100"}, 2, core.ErrorUsage, 0},
+		{"watch failure", []string{"watch", "--json", "404"}, 1, core.ErrorNotFound, 404},
+		{"watch timeout", []string{"watch", "--json", "--channels=nixos-unstable", "--timeout=1s", "100"}, 1, core.ErrorOther, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if args[0] == "watch" {
+				args = append([]string{"watch"}, append(common, args[1:]...)...)
+			} else {
+				args = append(common, args...)
+			}
+			cmd := exec.Command(bin, args...)
+			cmd.Env = env
+			var stderr strings.Builder
+			cmd.Stderr = &stderr
+			out, _ := cmd.Output()
+			if got := cmd.ProcessState.ExitCode(); got != tt.wantExit {
+				t.Errorf("exit code = %d, want %d\n%s", got, tt.wantExit, stderr.String())
+			}
+
+			// Watch prints its documents one per line, the failure last.
+			lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+			doc := []byte(lines[len(lines)-1])
+			if args[0] != "watch" {
+				doc = out
+			}
+			validateJSON(t, schema, doc)
+			var got struct {
+				PR    int            `json:"pr"`
+				Error core.ErrorInfo `json:"error"`
+			}
+			if err := json.Unmarshal(doc, &got); err != nil {
+				t.Fatalf("stdout is not a JSON document: %v\n%s", err, out)
+			}
+			if got.Error.Code != tt.wantCode || got.PR != tt.wantPR {
+				t.Errorf("error = %q for PR %d, want %q for PR %d\n%s", got.Error.Code, got.PR, tt.wantCode, tt.wantPR, out)
+			}
+			if !strings.Contains(stderr.String(), got.Error.Message) {
+				t.Errorf("stderr = %q, want the message %q", stderr.String(), got.Error.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+)
+
+const sampleConfig = `# nprt settings
+[defaults]
+color = "never"  # plain output
+timeline-pages = 5
+json = true
+on-change = ["exec:echo '#1'", 'ntfy:https://ntfy.sh/x']
+
+[channels]
+tracking = "release-25.05"
+"staging-tracking" = "staging"
+
+[groups]
+prod = [
+  "nixos-25.05",
+  "nixos-25.05-small", # small channel
+]
+watched = "prod, tracking"
+`
+
+func TestParseFile(t *testing.T) {
+	f, err := config.ParseFile(sampleConfig)
+	if err != nil {
+		t.Fatalf("ParseFile returned error: %v", err)
+	}
+
+	wantDefaults := map[string][]string{
+		"color":          {"never"},
+		"timeline-pages": {"5"},
+		"json":           {"true"},
+		"on-change":      {"exec:echo '#1'", "ntfy:https://ntfy.sh/x"},
+	}
+	if !reflect.DeepEqual(f.Defaults, wantDefaults) {
+		t.Errorf("Defaults = %v, want %v", f.Defaults, wantDefaults)
+	}
+
+	wantChannels := []config.Channel{
+		{Name: "tracking", Branch: "release-25.05"},
+		{Name: "staging-tracking", Branch: "staging"},
+	}
+	if !reflect.DeepEqual(f.Channels, wantChannels) {
+		t.Errorf("Channels = %v, want %v", f.Channels, wantChannels)
+	}
+
+	wantGroups := map[string][]string{
+		"prod":    {"nixos-25.05", "nixos-25.05-small"},
+		"watched": {"prod", "tracking"},
+	}
+	if !reflect.DeepEqual(f.Groups, wantGroups) {
+		t.Errorf("Groups = %v, want %v", f.Groups, wantGroups)
+	}
+}
+
+func TestParseFile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"top-level key", "color = \"never\"\n", "must be in a [defaults]"},
+		{"unknown table", "[colors]\nnever = true\n", "unknown table [colors]"},
+		{"unquoted dotted key", "[channels]\nmy-25.05 = \"release-25.05\"\n", "line 2: invalid key my-25.05; keys with dots must be quoted"},
+		{"unquoted string", "[defaults]\ncolor = never\n", "line 2:"},
+		{"duplicate key", "[defaults]\njson = true\njson = false\n", "line 3: key json is already defined"},
+		{"duplicate table", "[defaults]\njson = true\n[defaults]\ncolor = \"never\"\n", "line 3:"},
+		{"unterminated array", "[groups]\nprod = [\"master\"\n", "array is incomplete"},
+		{"inline table", "[defaults]\nretries = { count = 3 }\n", "line 2: inline tables are not supported"},
+		{"array of tables", "[[channels]]\nmine = \"master\"\n", "line 1: arrays of tables are not supported"},
+		{"nested array", "[groups]\nprod = [[\"master\"]]\n", "line 2: nested arrays are not supported"},
+		{"float", "[defaults]\nretries = 1.5\n", "line 2: floats are not supported"},
+		{"date", "[defaults]\nsince = 2025-05-01\n", "line 2: dates and times are not supported"},
+		{"shadows default channel", "[channels]\nmaster = \"main\"\n", "already a default channel"},
+		{"shadows preset", "[groups]\nstable = \"master\"\n", "already a preset"},
+		{"shadows stable channel", "[channels]\n\"nixos-25.05\" = \"nixos-25.05\"\n", "already a stable channel"},
+		{"channel and group", "[channels]\nmine = \"master\"\n[groups]\nmine = \"master\"\n", "both a channel and a group"},
+		{"group cycle", "[groups]\na = \"b\"\nb = [\"master\", \"a\"]\n", "contains itself"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := config.ParseFile(tc.data)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("ParseFile error = %v, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_DefaultPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	path, err := config.DefaultConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "nprt", "config.toml"); path != want {
+		t.Errorf("DefaultConfigPath() = %q, want %q", path, want)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("[defaults]\njson = maybe\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = config.LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), path+": line 2:") {
+		t.Errorf("LoadFile error = %v, want it to name the file and line", err)
+	}
+}
+
+func TestParseChannels_CustomChannelsAndGroups(t *testing.T) {
+	f, err := config.ParseFile(sampleConfig + "local = [\"master\", \"tracking\"]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	channels, err := config.ParseChannels("local", f)
+	if err != nil {
+		t.Fatalf("ParseChannels returned error: %v", err)
+	}
+	var names []string
+	for _, ch := range channels {
+		names = append(names, ch.Name)
+	}
+	if want := []string{"master", "tracking"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ParseChannels(local) = %v, want %v", names, want)
+	}
+	if channels[1].Branch != "release-25.05" {
+		t.Errorf("tracking branch = %q, want release-25.05", channels[1].Branch)
+	}
+
+	_, err = config.ParseChannels("nonexistent", f)
+	if err == nil || !strings.Contains(err.Error(), "tracking") || !strings.Contains(err.Error(), "groups: local, prod, watched") {
+		t.Errorf("error should list custom channels and groups, got: %v", err)
+	}
+}
+
+func TestNeedsDiscovery_CustomChannelsAndGroups(t *testing.T) {
+	f, err := config.ParseFile(sampleConfig + "local = [\"master\", \"tracking\"]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"tracking", false},
+		{"local", false},
+		{"prod", true},
+		{"watched", true},
+	}
+	for _, tc := range tests {
+		if got := config.NeedsDiscovery(tc.input, f); got != tc.want {
+			t.Errorf("NeedsDiscovery(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestCatalog_Groups(t *testing.T) {
+	f, err := config.ParseFile(sampleConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog := config.NewCatalog(config.ReleaseChannels(discoveredBranches)).Extend(f)
+
+	channels, err := catalog.Parse("watched")
+	if err != nil {
+		t.Fatalf("Parse(watched) returned error: %v", err)
+	}
+	var names []string
+	for _, ch := range channels {
+		names = append(names, ch.Name)
+	}
+	if want := []string{"nixos-25.05-small", "nixos-25.05", "tracking"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Parse(watched) = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+	"github.com/thatsneat-dev/nprt/internal/render"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name, or rewrites the file with
+// -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run go test ./tests -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch:\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func formatStatus() *core.PRStatus {
+	return &core.PRStatus{
+		Repo:        "NixOS/nixpkgs",
+		Number:      475593,
+		URL:         "https://github.com/NixOS/nixpkgs/pull/475593",
+		Title:       "golang: 1.23.5 -> 1.23.6 | security",
+		Author:      "someone",
+		State:       core.PRStateMerged,
+		MergeCommit: "abc123def456",
+		BaseBranch:  "master",
+		Channels: []core.ChannelResult{
+			{Name: "master", Branch: "master", Status: core.StatusPresent},
+			{Name: "nixos-unstable", Branch: "nixos-unstable", Status: core.StatusNotPresent},
+			{Name: "nixos-25.05", Branch: "nixos-25.05", Status: core.StatusPresent, ViaBackport: 475700,
+				ViaBackportURL: "https://github.com/NixOS/nixpkgs/pull/475700"},
+			{Name: "nixos-24.11", Branch: "nixos-24.11", Status: core.StatusUnknown},
+		},
+	}
+}
+
+func formatBatch() []core.BatchResult {
+	return []core.BatchResult{
+		{Repo: "NixOS/nixpkgs", Number: 475593, URL: "https://github.com/NixOS/nixpkgs/pull/475593", Status: formatStatus()},
+		{Repo: "NixOS/nixpkgs", Commit: "3f2a9c1", URL: "https://github.com/NixOS/nixpkgs/commit/3f2a9c1", Status: &core.PRStatus{
+			Repo:      "NixOS/nixpkgs",
+			Commit:    "3f2a9c1e5b7d0000000000000000000000000000",
+			CommitURL: "https://github.com/NixOS/nixpkgs/commit/3f2a9c1e5b7d0000000000000000000000000000",
+			Channels:  []core.ChannelResult{{Name: "master", Branch: "master", Status: core.StatusPresent}},
+		}},
+		{Repo: "NixOS/nixpkgs", Number: 42, URL: "https://github.com/NixOS/nixpkgs/pull/42",
+			Err: &github.NotFoundError{Repo: config.DefaultRepo, Number: 42}},
+	}
+}
+
+func formatIssue() *core.IssueStatus {
+	return &core.IssueStatus{
+		Repo:   "NixOS/nixpkgs",
+		Number: 12345,
+		URL:    "https://github.com/NixOS/nixpkgs/issues/12345",
+		Title:  "golang: CVE-2025-0001",
+		State:  "closed",
+		Related: []github.RelatedPR{
+			{Number: 475593, Title: "golang: 1.23.5 -> 1.23.6 | security", URL: "https://github.com/NixOS/nixpkgs/pull/475593", State: github.StateMerged},
+			{Number: 475600, Title: "golang: backport fix", URL: "https://github.com/NixOS/nixpkgs/pull/475600", State: "open"},
+		},
+		PRs: formatBatch()[:1],
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"table", "json", "markdown", "csv", "tsv", "yaml"} {
+		if f, err := render.ParseFormat(name); err != nil || string(f) != name {
+			t.Errorf("ParseFormat(%q) = %q, %v", name, f, err)
+		}
+	}
+	if f, err := render.ParseFormat("md"); err != nil || f != render.FormatMarkdown {
+		t.Errorf("ParseFormat(\"md\") = %q, %v, want markdown", f, err)
+	}
+	if _, err := render.ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(\"xml\") should have returned error")
+	}
+}
+
+func TestFormats_Golden(t *testing.T) {
+	t.Setenv("NO_NERD_FONTS", "1")
+
+	// Every format is rendered for the status, batch and issue fixtures and
+	// compared with testdata/{status,batch,issue}.ext.
+	tests := []struct {
+		format render.Format
+		ext    string
+	}{
+		{render.FormatMarkdown, "md"},
+		{render.FormatCSV, "csv"},
+		{render.FormatTSV, "tsv"},
+		{render.FormatYAML, "yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			r := render.NewRenderer(&buf, false, false)
+
+			if err := r.RenderStatusAs(tt.format, formatStatus()); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "status."+tt.ext, buf.Bytes())
+
+			buf.Reset()
+			if err := r.RenderBatchAs(tt.format, formatBatch()); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "batch."+tt.ext, buf.Bytes())
+
+			buf.Reset()
+			if err := r.RenderIssueAs(tt.format, formatIssue()); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "issue."+tt.ext, buf.Bytes())
+		})
+	}
+}
+
+func TestCSV_OneRowPerChannel(t *testing.T) {
+	var buf bytes.Buffer
+	if err := render.NewRenderer(&buf, false, false).RenderCSV(formatBatch(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	// Header, 4 channels of the PR, 1 of the commit and 1 error row.
+	if len(records) != 7 {
+		t.Fatalf("rows = %d, want 7", len(records))
+	}
+	if records[0][0] != "repo" || records[0][len(records[0])-1] != "error" {
+		t.Errorf("header = %v, want repo first and error last", records[0])
+	}
+	if got := records[1][3]; got != "golang: 1.23.5 -> 1.23.6 | security" {
+		t.Errorf("title = %q, want it unescaped", got)
+	}
+}
+
+func TestRenderYAML_Quoting(t *testing.T) {
+	v := map[string]any{
+		"a_plain":  "nixos-unstable",
+		"b_bool":   "yes",
+		"c_number": "25.05",
+		"d_colon":  "a: b",
+		"e_empty":  "",
+		"f_list":   []string{},
+		"g_null":   nil,
+	}
+
+	var buf bytes.Buffer
+	if err := render.NewRenderer(&buf, false, false).RenderYAML(v); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"a_plain: nixos-unstable",
+		`b_bool: "yes"`,
+		`c_number: "25.05"`,
+		`d_colon: "a: b"`,
+		`e_empty: ""`,
+		"f_list: []",
+		"g_null: null",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("RenderYAML() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,268 @@
+package tests
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/git"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(cmd.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// newGitFixture creates an upstream repository where master has commits A and
+// B and nixos-unstable points at A, plus a clone of it. It returns the
+// upstream dir, the clone dir and the two commits.
+func newGitFixture(t *testing.T) (upstream, clone, a, b string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	root := t.TempDir()
+	upstream = filepath.Join(root, "upstream")
+	clone = filepath.Join(root, "clone")
+
+	runGit(t, root, "init", "--quiet", "--initial-branch=master", upstream)
+	runGit(t, upstream, "commit", "--quiet", "--allow-empty", "-m", "A")
+	a = runGit(t, upstream, "rev-parse", "HEAD")
+	runGit(t, upstream, "branch", "nixos-unstable")
+	runGit(t, upstream, "commit", "--quiet", "--allow-empty", "-m", "B")
+	b = runGit(t, upstream, "rev-parse", "HEAD")
+
+	runGit(t, root, "clone", "--quiet", upstream, clone)
+	return upstream, clone, a, b
+}
+
+func TestRepo_CompareCommitWithBranch(t *testing.T) {
+	_, clone, a, b := newGitFixture(t)
+	ctx := context.Background()
+
+	repo, err := git.Open(ctx, clone, config.DefaultHost, config.DefaultRepo, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if repo.Remote != "origin" {
+		t.Errorf("Remote = %q, want origin", repo.Remote)
+	}
+
+	res, err := repo.CompareCommitWithBranch(ctx, a, "nixos-unstable")
+	if err != nil {
+		t.Fatalf("compare A: %v", err)
+	}
+	if res.BehindBy != 0 {
+		t.Errorf("A in nixos-unstable: BehindBy = %d, want 0", res.BehindBy)
+	}
+
+	res, err = repo.CompareCommitWithBranch(ctx, b, "nixos-unstable")
+	if err != nil {
+		t.Fatalf("compare B: %v", err)
+	}
+	if res.BehindBy != 1 {
+		t.Errorf("B in nixos-unstable: BehindBy = %d, want 1", res.BehindBy)
+	}
+
+	if _, err := repo.CompareCommitWithBranch(ctx, a, "nixos-25.11"); err == nil {
+		t.Error("expected error for missing branch")
+	}
+	if _, err := repo.CompareCommitWithBranch(ctx, strings.Repeat("1", 40), "nixos-unstable"); err == nil {
+		t.Error("expected error for unknown commit without fetch")
+	}
+}
+
+func TestRepo_FetchBranches(t *testing.T) {
+	upstream, clone, _, b := newGitFixture(t)
+	ctx := context.Background()
+
+	repo, err := git.Open(ctx, clone, config.DefaultHost, config.DefaultRepo, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// The channel advances upstream after the clone was made.
+	runGit(t, upstream, "branch", "--force", "nixos-unstable", b)
+
+	if err := repo.FetchBranches(ctx, []string{"nixos-unstable"}); err != nil {
+		t.Fatalf("FetchBranches() without Fetch error = %v", err)
+	}
+	res, err := repo.CompareCommitWithBranch(ctx, b, "nixos-unstable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BehindBy == 0 {
+		t.Error("branch updated without Fetch set")
+	}
+
+	repo.Fetch = true
+	if err := repo.FetchBranches(ctx, []string{"nixos-unstable"}); err != nil {
+		t.Fatalf("FetchBranches() error = %v", err)
+	}
+	res, err = repo.CompareCommitWithBranch(ctx, b, "nixos-unstable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BehindBy != 0 {
+		t.Errorf("after fetch: BehindBy = %d, want 0", res.BehindBy)
+	}
+
+	// A commit that is missing after fetching is not on the branch.
+	res, err = repo.CompareCommitWithBranch(ctx, strings.Repeat("1", 40), "nixos-unstable")
+	if err != nil {
+		t.Fatalf("compare unknown commit after fetch: %v", err)
+	}
+	if res.BehindBy == 0 {
+		t.Error("unknown commit reported as present")
+	}
+}
+
+func TestOpen_NotARepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	if _, err := git.Open(context.Background(), t.TempDir(), config.DefaultHost, config.DefaultRepo, zap.NewNop()); err == nil {
+		t.Error("expected error for a directory that is not a repository")
+	}
+}
+
+// fakePRSource serves a single merged PR without touching the network.
+type fakePRSource struct {
+	pr *github.PullRequest
+}
+
+func (f fakePRSource) GetPullRequest(ctx context.Context, number int) (*github.PullRequest, error) {
+	return f.pr, nil
+}
+
+func (f fakePRSource) FindBackports(ctx context.Context, pr *github.PullRequest) ([]github.Backport, error) {
+	return nil, nil
+}
+
+func TestCheckPR_CombinedGitBackend(t *testing.T) {
+	_, clone, a, _ := newGitFixture(t)
+	ctx := context.Background()
+
+	repo, err := git.Open(ctx, clone, config.DefaultHost, config.DefaultRepo, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr := &github.PullRequest{Number: 7, Merged: true, MergeCommitSHA: a}
+	pr.Base.Ref = "master"
+	checker := core.NewChecker(core.Combine(fakePRSource{pr}, repo), zap.NewNop())
+
+	channels := []config.Channel{
+		{Name: "master", Branch: "master"},
+		{Name: "nixos-unstable", Branch: "nixos-unstable"},
+		{Name: "nixpkgs-unstable", Branch: "nixpkgs-unstable"},
+	}
+	status, err := checker.CheckPR(ctx, 7, channels)
+	if err != nil {
+		t.Fatalf("CheckPR() error = %v", err)
+	}
+
+	want := map[string]core.ChannelStatus{
+		"master":           core.StatusPresent,
+		"nixos-unstable":   core.StatusPresent,
+		"nixpkgs-unstable": core.StatusUnknown,
+	}
+	for _, ch := range status.Channels {
+		if ch.Status != want[ch.Name] {
+			t.Errorf("%s = %s, want %s", ch.Name, ch.Status, want[ch.Name])
+		}
+	}
+}
+
+func TestRepo_GetBranchHeads(t *testing.T) {
+	_, clone, a, b := newGitFixture(t)
+	ctx := context.Background()
+
+	repo, err := git.Open(ctx, clone, config.DefaultHost, config.DefaultRepo, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	heads, err := repo.GetBranchHeads(ctx, []string{"master", "nixos-unstable", "nixos-25.11"})
+	if err != nil {
+		t.Fatalf("GetBranchHeads() error = %v", err)
+	}
+	if heads[0].SHA != b || heads[0].Date.IsZero() {
+		t.Errorf("master = %+v, want %s with a date", heads[0], b)
+	}
+	if heads[1].SHA != a {
+		t.Errorf("nixos-unstable = %+v, want %s", heads[1], a)
+	}
+	if heads[2].Err == nil {
+		t.Error("missing branch should have an error")
+	}
+}
+
+func TestRepo_FindLanding(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	// master: A - B - M - D, where M merges staging (A - C).
+	dir := filepath.Join(t.TempDir(), "repo")
+	runGit(t, filepath.Dir(dir), "init", "--quiet", "--initial-branch=master", dir)
+	runGit(t, dir, "commit", "--quiet", "--allow-empty", "-m", "A")
+	runGit(t, dir, "checkout", "--quiet", "-b", "staging")
+	runGit(t, dir, "commit", "--quiet", "--allow-empty", "-m", "C")
+	c := runGit(t, dir, "rev-parse", "HEAD")
+	runGit(t, dir, "checkout", "--quiet", "master")
+	runGit(t, dir, "commit", "--quiet", "--allow-empty", "-m", "B")
+	b := runGit(t, dir, "rev-parse", "HEAD")
+	runGit(t, dir, "merge", "--quiet", "--no-ff", "--no-edit", "staging")
+	m := runGit(t, dir, "rev-parse", "HEAD")
+	runGit(t, dir, "commit", "--quiet", "--allow-empty", "-m", "D")
+	d := runGit(t, dir, "rev-parse", "HEAD")
+
+	ctx := context.Background()
+	repo, err := git.Open(ctx, dir, config.DefaultHost, config.DefaultRepo, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		commit string
+		want   string
+	}{
+		{"merged from another branch", c, m},
+		{"on the first-parent history", b, b},
+		{"the merge itself", m, m},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			landing, err := repo.FindLanding(ctx, tt.commit, "master", d)
+			if err != nil {
+				t.Fatalf("FindLanding() error = %v", err)
+			}
+			if landing.SHA != tt.want || landing.Date.IsZero() {
+				t.Errorf("FindLanding() = %+v, want %s with a date", landing, tt.want)
+			}
+		})
+	}
+
+	if _, err := repo.FindLanding(ctx, b, "staging", c); err == nil {
+		t.Error("FindLanding should fail for a branch without the commit")
+	}
+}
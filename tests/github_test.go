@@ -3,13 +3,20 @@ package tests
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/thatsneat-dev/nprt/internal/cache"
+	"github.com/thatsneat-dev/nprt/internal/config"
 	"github.com/thatsneat-dev/nprt/internal/github"
 )
 
@@ -73,6 +80,38 @@ func TestGetPullRequest_NotFound(t *testing.T) {
 	}
 }
 
+func TestGetPullRequest_OtherRepo(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.Contains(r.URL.Path, "/compare/") {
+			w.Write([]byte(`{"status": "behind", "ahead_by": 0, "behind_by": 1}`))
+			return
+		}
+		w.Write([]byte(`{"number": 42, "state": "closed", "merged": true, "merge_commit_sha": "abc123", "base": {"ref": "master"}}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	client.Repo = config.Repo{Owner: "nix-community", Name: "home-manager"}
+
+	if _, err := client.GetPullRequest(context.Background(), 42); err != nil {
+		t.Fatalf("GetPullRequest returned error: %v", err)
+	}
+	if _, err := client.CompareCommitWithBranch(context.Background(), "abc123", "release-25.05"); err != nil {
+		t.Fatalf("CompareCommitWithBranch returned error: %v", err)
+	}
+
+	want := []string{
+		"/repos/nix-community/home-manager/pulls/42",
+		"/repos/nix-community/home-manager/compare/abc123...release-25.05",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("request paths = %v, want %v", paths, want)
+	}
+}
+
 func TestGetPullRequest_IsIssueNotPR(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.Contains(r.URL.Path, "/pulls/") {
@@ -372,6 +411,8 @@ func TestGetPullRequest_IsIssueTimelineFails(t *testing.T) {
 
 	client := github.NewClient("", "", zap.NewNop())
 	client.BaseURL = server.URL
+	// Retrying the failing timeline is covered by the retry tests.
+	client.Retry = github.RetryPolicy{}
 
 	_, err := client.GetPullRequest(context.Background(), 12345)
 	if err == nil {
@@ -492,3 +533,398 @@ func TestGetRelatedPRs_MergedAtSetsMergedState(t *testing.T) {
 		t.Errorf("second PR state = %q, want %q (closed without merged_at should be closed)", related[1].State, "closed")
 	}
 }
+
+func TestGetPullRequest_CachesMergedPR(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"number": 1, "state": "closed", "merged": true, "merge_commit_sha": "abc123"}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	client.Cache = cache.New(t.TempDir())
+
+	for range 2 {
+		pr, err := client.GetPullRequest(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("GetPullRequest returned error: %v", err)
+		}
+		if pr.MergeCommitSHA != "abc123" {
+			t.Errorf("MergeCommitSHA = %q, want %q", pr.MergeCommitSHA, "abc123")
+		}
+	}
+	if requests != 1 {
+		t.Errorf("server saw %d requests, want 1", requests)
+	}
+}
+
+func TestCompareCommitWithBranch_NegativeCacheTTL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "behind", "ahead_by": 0, "behind_by": 5}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := cache.New(t.TempDir())
+	store.Now = func() time.Time { return now }
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	client.Cache = store
+
+	compare := func() {
+		t.Helper()
+		if _, err := client.CompareCommitWithBranch(context.Background(), "abc123", "nixos-unstable"); err != nil {
+			t.Fatalf("CompareCommitWithBranch returned error: %v", err)
+		}
+	}
+
+	compare()
+	compare()
+	if requests != 1 {
+		t.Errorf("server saw %d requests within TTL, want 1", requests)
+	}
+
+	now = now.Add(github.DefaultNegativeCacheTTL)
+	compare()
+	if requests != 2 {
+		t.Errorf("server saw %d requests after TTL, want 2", requests)
+	}
+
+	// With negative caching disabled, cached misses are not trusted.
+	client.NegativeCacheTTL = 0
+	compare()
+	if requests != 3 {
+		t.Errorf("server saw %d requests with negative caching disabled, want 3", requests)
+	}
+}
+
+func TestResolveCommit_CachesOnlyFullSHAs(t *testing.T) {
+	const sha = "3f2a9c1e5b7d0000000000000000000000000000"
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[strings.TrimPrefix(r.URL.Path, "/repos/NixOS/nixpkgs/commits/")]++
+		w.Write([]byte(sha))
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := cache.New(t.TempDir())
+	store.Now = func() time.Time { return now }
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	client.Cache = store
+
+	resolve := func(rev string) {
+		t.Helper()
+		full, err := client.ResolveCommit(context.Background(), rev)
+		if err != nil || full != sha {
+			t.Fatalf("ResolveCommit(%s) = %q, %v, want %s", rev, full, err, sha)
+		}
+	}
+
+	resolve("3f2a9c1")
+	resolve(sha)
+	resolve("3f2a9c1")
+	resolve(sha)
+	if requests["3f2a9c1"] != 1 || requests[sha] != 1 {
+		t.Errorf("server saw %v within TTL, want 1 request each", requests)
+	}
+
+	// An abbreviated SHA may name another commit later; a full one cannot.
+	now = now.Add(github.DefaultNegativeCacheTTL)
+	resolve("3f2a9c1")
+	resolve(sha)
+	if requests["3f2a9c1"] != 2 || requests[sha] != 1 {
+		t.Errorf("server saw %v after TTL, want 2 requests for 3f2a9c1 and 1 for the full SHA", requests)
+	}
+}
+
+func TestDoRequest_ConditionalRequests(t *testing.T) {
+	stores := map[string]func(t *testing.T) github.ValidatorStore{
+		"memory": func(t *testing.T) github.ValidatorStore {
+			return github.NewMemoryValidators()
+		},
+		"disk": func(t *testing.T) github.ValidatorStore {
+			return github.NewDiskValidators(cache.New(t.TempDir()), zap.NewNop())
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			var full, notModified int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				full++
+				w.Header().Set("ETag", `"v1"`)
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"status": "behind", "ahead_by": 0, "behind_by": 5}`))
+			}))
+			defer server.Close()
+
+			client := github.NewClient("", "", zap.NewNop())
+			client.BaseURL = server.URL
+			client.Validators = newStore(t)
+
+			for range 2 {
+				result, err := client.CompareCommitWithBranch(context.Background(), "abc123", "nixos-unstable")
+				if err != nil {
+					t.Fatalf("CompareCommitWithBranch returned error: %v", err)
+				}
+				if result.BehindBy != 5 {
+					t.Errorf("BehindBy = %d, want 5", result.BehindBy)
+				}
+			}
+			if full != 1 || notModified != 1 {
+				t.Errorf("server saw %d full and %d conditional responses, want 1 and 1", full, notModified)
+			}
+		})
+	}
+}
+
+func TestDoRequest_LastModified(t *testing.T) {
+	const lastModified = "Mon, 02 Jun 2025 10:00:00 GMT"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"number": 1, "state": "open"}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	client.Validators = github.NewMemoryValidators()
+
+	for range 2 {
+		pr, err := client.GetPullRequest(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("GetPullRequest returned error: %v", err)
+		}
+		if pr.State != "open" {
+			t.Errorf("State = %q, want %q", pr.State, "open")
+		}
+	}
+}
+
+// recordingValidators is a ValidatorStore that keeps what was stored.
+type recordingValidators struct {
+	*github.MemoryValidators
+	puts map[string]github.Validator
+}
+
+func (r *recordingValidators) Put(key string, v github.Validator) {
+	r.puts[key] = v
+	r.MemoryValidators.Put(key, v)
+}
+
+func TestDoRequest_StoredValidators(t *testing.T) {
+	var conditional int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"status": "behind", "ahead_by": 0, "behind_by": 5,
+			"merge_base_commit": {"sha": "abc123"},
+			"commits": [{"sha": "def456", "commit": {"message": "hello: 2.12 -> 2.13"}}],
+			"files": [{"filename": "pkgs/by-name/he/hello/package.nix", "patch": "@@ -1 +1 @@"}]}`))
+	}))
+	defer server.Close()
+
+	store := &recordingValidators{github.NewMemoryValidators(), make(map[string]github.Validator)}
+	compare := func(token string) {
+		t.Helper()
+		client := github.NewClient(token, "", zap.NewNop())
+		client.BaseURL = server.URL
+		client.Validators = store
+		result, err := client.CompareCommitWithBranch(context.Background(), "abc123", "nixos-unstable")
+		if err != nil {
+			t.Fatalf("CompareCommitWithBranch returned error: %v", err)
+		}
+		if result.BehindBy != 5 {
+			t.Errorf("BehindBy = %d, want 5", result.BehindBy)
+		}
+	}
+
+	compare("token-a")
+	if len(store.puts) != 1 {
+		t.Fatalf("stored %d validators, want 1", len(store.puts))
+	}
+	for key, v := range store.puts {
+		if strings.Contains(key, "token-a") {
+			t.Errorf("key %q contains the token", key)
+		}
+		body := string(v.Body)
+		for _, field := range []string{"commits", "files", "merge_base_commit"} {
+			if strings.Contains(body, field) {
+				t.Errorf("stored body %s contains %q", body, field)
+			}
+		}
+		if !strings.Contains(body, `"behind_by":5`) {
+			t.Errorf("stored body %s lacks behind_by", body)
+		}
+	}
+
+	// The trimmed body still answers a revalidated request.
+	compare("token-a")
+	if conditional != 1 {
+		t.Errorf("server saw %d conditional requests, want 1", conditional)
+	}
+
+	// Another token does not revalidate the response of the first.
+	compare("token-b")
+	if conditional != 1 || len(store.puts) != 2 {
+		t.Errorf("server saw %d conditional requests and %d validators were stored, want 1 and 2", conditional, len(store.puts))
+	}
+}
+
+func TestGetBranchHeads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/NixOS/nixpkgs/branches/nixos-unstable":
+			w.Write([]byte(`{"name": "nixos-unstable", "commit": {"sha": "0123456789abcdef",
+				"commit": {"committer": {"date": "2026-01-02T03:04:05Z"}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Branch not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+
+	heads, err := client.GetBranchHeads(context.Background(), []string{"nixos-unstable", "missing"})
+	if err != nil {
+		t.Fatalf("GetBranchHeads returned error: %v", err)
+	}
+	want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if heads[0].SHA != "0123456789abcdef" || !heads[0].Date.Equal(want) || heads[0].Err != nil {
+		t.Errorf("nixos-unstable = %+v, want 0123456789abcdef at %s", heads[0], want)
+	}
+	if heads[1].Err == nil {
+		t.Error("missing branch should have an error")
+	}
+}
+
+func TestGetBranchHeads_BoundedConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		branch := strings.TrimPrefix(r.URL.Path, "/repos/NixOS/nixpkgs/branches/")
+		w.Write([]byte(`{"commit": {"sha": "` + branch + `"}}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+
+	var branches []string
+	for i := range 10 {
+		branches = append(branches, fmt.Sprintf("b%d", i))
+	}
+	heads, err := client.GetBranchHeads(context.Background(), branches)
+	if err != nil {
+		t.Fatalf("GetBranchHeads returned error: %v", err)
+	}
+	for i, head := range heads {
+		if head.SHA != branches[i] || head.Err != nil {
+			t.Errorf("heads[%d] = %+v, want %s", i, head, branches[i])
+		}
+	}
+	if n := maxInFlight.Load(); n < 2 || n > 4 {
+		t.Errorf("server saw %d concurrent requests, want 2 to 4", n)
+	}
+}
+
+func TestFindLanding(t *testing.T) {
+	// The merge commit landed 5 first-parent commits below the head, in a
+	// history of 40 commits.
+	const landedDepth, historyLen = 5, 40
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		path := strings.TrimPrefix(r.URL.Path, "/repos/NixOS/nixpkgs/")
+		switch {
+		case strings.HasPrefix(path, "compare/merge...head"):
+			depth := 0
+			if _, n, ok := strings.Cut(path, "~"); ok {
+				depth, _ = strconv.Atoi(n)
+			}
+			switch {
+			case depth <= landedDepth:
+				w.Write([]byte(`{"status": "ahead", "behind_by": 0}`))
+			case depth <= historyLen:
+				w.Write([]byte(`{"status": "diverged", "behind_by": 1}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message": "Not Found"}`))
+			}
+		case path == "commits" && r.URL.Query().Get("sha") == "head~5":
+			w.Write([]byte(`[{"sha": "landed", "commit": {"committer": {"date": "2026-01-02T03:04:05Z"}}}]`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	client.Cache = cache.New(t.TempDir())
+
+	for range 2 {
+		landing, err := client.FindLanding(context.Background(), "merge", "nixos-unstable", "head")
+		if err != nil {
+			t.Fatalf("FindLanding returned error: %v", err)
+		}
+		want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		if landing.SHA != "landed" || !landing.Date.Equal(want) {
+			t.Errorf("FindLanding() = %+v, want landed at %s", landing, want)
+		}
+	}
+	// Depth 0, 1, 2, 4 and 8 while growing, 6 and 5 while bisecting, and
+	// the landing commit; the second call is cached.
+	if requests != 8 {
+		t.Errorf("server saw %d requests, want 8", requests)
+	}
+}
+
+func TestFindLanding_NotContained(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "diverged", "behind_by": 3}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+
+	if _, err := client.FindLanding(context.Background(), "merge", "nixos-unstable", "head"); err == nil {
+		t.Error("FindLanding should fail for a branch without the commit")
+	}
+}
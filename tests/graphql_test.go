@@ -0,0 +1,335 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+// graphQLPayload decodes the query and variables of a GraphQL request.
+func graphQLPayload(t *testing.T, r *http.Request) (string, map[string]any) {
+	t.Helper()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatalf("failed to read request body: %v", err)
+	}
+	var payload struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("failed to decode GraphQL request: %v", err)
+	}
+	return payload.Query, payload.Variables
+}
+
+func newGraphQLClient(serverURL string) *github.GraphQLClient {
+	client := github.NewClient("token", "", zap.NewNop())
+	client.BaseURL = serverURL
+	return github.NewGraphQLClient(client)
+}
+
+func TestGraphQLGetPullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/graphql" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer token")
+		}
+		_, vars := graphQLPayload(t, r)
+		if vars["number"] != float64(476497) {
+			t.Errorf("number variable = %v, want 476497", vars["number"])
+		}
+		w.Write([]byte(`{"data": {"repository": {"pullRequest": {
+			"number": 476497,
+			"title": "hello: 2.12 -> 2.13",
+			"state": "MERGED",
+			"isDraft": false,
+			"merged": true,
+			"mergeCommit": {"oid": "abc123def456"},
+			"author": {"login": "someone"},
+			"baseRefName": "staging",
+			"labels": {"nodes": [{"name": "backport release-25.05"}]}
+		}}}}`))
+	}))
+	defer server.Close()
+
+	pr, err := newGraphQLClient(server.URL).GetPullRequest(context.Background(), 476497)
+	if err != nil {
+		t.Fatalf("GetPullRequest returned error: %v", err)
+	}
+
+	if !pr.Merged || pr.State != github.StateClosed {
+		t.Errorf("Merged = %v, State = %q, want true, %q", pr.Merged, pr.State, github.StateClosed)
+	}
+	if pr.MergeCommitSHA != "abc123def456" {
+		t.Errorf("MergeCommitSHA = %q, want %q", pr.MergeCommitSHA, "abc123def456")
+	}
+	if pr.User.Login != "someone" || pr.Base.Ref != "staging" {
+		t.Errorf("author = %q, base = %q, want someone, staging", pr.User.Login, pr.Base.Ref)
+	}
+	if len(pr.Labels) != 1 || pr.Labels[0].Name != "backport release-25.05" {
+		t.Errorf("Labels = %v, want [backport release-25.05]", pr.Labels)
+	}
+}
+
+func TestGraphQLGetPullRequest_NotFoundFallsBackToREST(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/graphql":
+			w.Write([]byte(`{
+				"data": {"repository": {"pullRequest": null}},
+				"errors": [{"type": "NOT_FOUND", "path": ["repository", "pullRequest"], "message": "Could not resolve to a PullRequest with the number of 999999."}]
+			}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	_, err := newGraphQLClient(server.URL).GetPullRequest(context.Background(), 999999)
+
+	var notFoundErr *github.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("error should be NotFoundError, got: %T (%v)", err, err)
+	}
+}
+
+func TestGraphQL_RateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := newGraphQLClient(server.URL).GetPullRequest(context.Background(), 1)
+
+	var apiErr *github.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("error should be a 403 APIError, got: %T (%v)", err, err)
+	}
+}
+
+func TestGraphQLCompareCommitWithBranches(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query, vars := graphQLPayload(t, r)
+		if vars["commit"] != "abc123" {
+			t.Errorf("commit variable = %v, want abc123", vars["commit"])
+		}
+		if vars["b0"] != "refs/heads/master" || vars["b1"] != "refs/heads/nixos-unstable" || vars["b2"] != "refs/heads/missing" {
+			t.Errorf("branch variables = %v", vars)
+		}
+		if strings.Count(query, "compare(headRef: $commit)") != 3 {
+			t.Errorf("query should compare 3 branches:\n%s", query)
+		}
+		w.Write([]byte(`{"data": {"repository": {
+			"b0": {"compare": {"aheadBy": 0, "behindBy": 10, "status": "BEHIND"}},
+			"b1": {"compare": {"aheadBy": 5, "behindBy": 0, "status": "AHEAD"}},
+			"b2": null
+		}}}`))
+	}))
+	defer server.Close()
+
+	comparisons, err := newGraphQLClient(server.URL).CompareCommitWithBranches(
+		context.Background(), "abc123", []string{"master", "nixos-unstable", "missing"})
+	if err != nil {
+		t.Fatalf("CompareCommitWithBranches returned error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("server saw %d requests, want 1", requests)
+	}
+
+	if got := comparisons[0].Result; got == nil || got.BehindBy != 0 || got.Status != "ahead" {
+		t.Errorf("master = %+v, want behind_by 0 and status ahead", got)
+	}
+	if got := comparisons[1].Result; got == nil || got.BehindBy != 5 || got.Status != "behind" {
+		t.Errorf("nixos-unstable = %+v, want behind_by 5 and status behind", got)
+	}
+	if comparisons[2].Err == nil {
+		t.Error("missing branch should have an error")
+	}
+}
+
+func TestCheckPR_GraphQLBackend(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query, _ := graphQLPayload(t, r)
+		if strings.Contains(query, "pullRequest") {
+			w.Write([]byte(`{"data": {"repository": {"pullRequest": {
+				"number": 100, "title": "Test PR", "state": "MERGED", "merged": true,
+				"mergeCommit": {"oid": "abc123def456789012"}, "author": {"login": "testuser"},
+				"baseRefName": "master", "labels": {"nodes": []}
+			}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"repository": {
+			"b0": {"compare": {"aheadBy": 0, "behindBy": 10, "status": "BEHIND"}},
+			"b1": {"compare": {"aheadBy": 5, "behindBy": 0, "status": "AHEAD"}}
+		}}}`))
+	}))
+	defer server.Close()
+
+	checker := core.NewChecker(newGraphQLClient(server.URL), zap.NewNop())
+	channels := []config.Channel{
+		{Name: "master", Branch: "master"},
+		{Name: "nixos-unstable", Branch: "nixos-unstable"},
+	}
+
+	status, err := checker.CheckPR(context.Background(), 100, channels)
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("server saw %d requests, want 2", requests)
+	}
+
+	want := map[string]core.ChannelStatus{
+		"master":         core.StatusPresent,
+		"nixos-unstable": core.StatusNotPresent,
+	}
+	for _, ch := range status.Channels {
+		if ch.Status != want[ch.Name] {
+			t.Errorf("%s status = %q, want %q", ch.Name, ch.Status, want[ch.Name])
+		}
+	}
+}
+
+func TestCheckPR_GraphQLLanding(t *testing.T) {
+	var headQueries atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/repos/NixOS/nixpkgs/")
+		switch {
+		case r.URL.Path == "/graphql":
+			query, _ := graphQLPayload(t, r)
+			switch {
+			case strings.Contains(query, "pullRequest"):
+				w.Write([]byte(`{"data": {"repository": {"pullRequest": {
+					"number": 100, "title": "Test PR", "state": "MERGED", "merged": true,
+					"mergeCommit": {"oid": "abc123def456789012"}, "author": {"login": "testuser"},
+					"baseRefName": "master", "labels": {"nodes": []}
+				}}}}`))
+			case strings.Contains(query, "committedDate"):
+				headQueries.Add(1)
+				w.Write([]byte(`{"data": {"repository": {
+					"b0": {"target": {"oid": "head0", "committedDate": "2026-01-02T03:04:05Z"}},
+					"b1": {"target": {"oid": "head1", "committedDate": "2026-01-03T03:04:05Z"}}
+				}}}`))
+			default:
+				w.Write([]byte(`{"data": {"repository": {
+					"b0": {"compare": {"aheadBy": 0, "behindBy": 10, "status": "BEHIND"}},
+					"b1": {"compare": {"aheadBy": 0, "behindBy": 12, "status": "BEHIND"}}
+				}}}`))
+			}
+		case strings.HasPrefix(path, "compare/") && !strings.Contains(path, "~1"):
+			w.Write([]byte(`{"status": "ahead", "behind_by": 0}`))
+		case path == "commits":
+			head, _, _ := strings.Cut(r.URL.Query().Get("sha"), "~")
+			w.Write([]byte(`[{"sha": "` + head + `", "commit": {"committer": {"date": "2026-01-02T03:04:05Z"}}}]`))
+		case strings.HasPrefix(path, "branches/"):
+			t.Errorf("unexpected REST head lookup %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			// The first parent of each head does not contain the commit.
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	checker := core.NewChecker(newGraphQLClient(server.URL), zap.NewNop())
+	checker.Landing = true
+	channels := []config.Channel{
+		{Name: "master", Branch: "master"},
+		{Name: "nixos-unstable", Branch: "nixos-unstable"},
+	}
+
+	status, err := checker.CheckPR(context.Background(), 100, channels)
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+	if n := headQueries.Load(); n != 1 {
+		t.Errorf("server saw %d head queries, want 1", n)
+	}
+	for _, ch := range status.Channels {
+		if want := map[string]string{"master": "head0", "nixos-unstable": "head1"}[ch.Name]; ch.LandedRevision != want {
+			t.Errorf("%s landed with %q, want %q", ch.Name, ch.LandedRevision, want)
+		}
+	}
+}
+
+func TestGraphQLEnterpriseEndpoint(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/api/graphql" {
+			w.Write([]byte(`{"data": {"repository": {"pullRequest": {"number": 1, "state": "OPEN", "baseRefName": "master"}}}}`))
+			return
+		}
+		w.Write([]byte(`{"number": 1, "state": "open", "base": {"ref": "master"}}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("token", "", zap.NewNop())
+	client.BaseURL = server.URL + "/api/v3"
+
+	if _, err := client.GetPullRequest(context.Background(), 1); err != nil {
+		t.Fatalf("REST GetPullRequest returned error: %v", err)
+	}
+	if _, err := github.NewGraphQLClient(client).GetPullRequest(context.Background(), 1); err != nil {
+		t.Fatalf("GraphQL GetPullRequest returned error: %v", err)
+	}
+
+	want := []string{"/api/v3/repos/NixOS/nixpkgs/pulls/1", "/api/graphql"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("request paths = %v, want %v", paths, want)
+	}
+}
+
+func TestGraphQLGetBranchHeads(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query, vars := graphQLPayload(t, r)
+		if vars["b0"] != "refs/heads/master" || vars["b1"] != "refs/heads/missing" {
+			t.Errorf("branch variables = %v", vars)
+		}
+		if strings.Count(query, "committedDate") != 2 {
+			t.Errorf("query should look up 2 heads:\n%s", query)
+		}
+		w.Write([]byte(`{"data": {"repository": {
+			"b0": {"target": {"oid": "abcdef0123456789", "committedDate": "2026-01-02T03:04:05Z"}},
+			"b1": null
+		}}}`))
+	}))
+	defer server.Close()
+
+	heads, err := newGraphQLClient(server.URL).GetBranchHeads(context.Background(), []string{"master", "missing"})
+	if err != nil {
+		t.Fatalf("GetBranchHeads returned error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("server saw %d requests, want 1", requests)
+	}
+	if heads[0].SHA != "abcdef0123456789" || heads[0].Date.IsZero() {
+		t.Errorf("master = %+v, want abcdef0123456789 with a date", heads[0])
+	}
+	if heads[1].Err == nil {
+		t.Error("missing branch should have an error")
+	}
+}
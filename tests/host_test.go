@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+)
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiURL  string
+		webURL  string
+		want    config.Host
+		wantErr bool
+	}{
+		{"default", "", "", config.DefaultHost, false},
+		{"github.com web URL", "", "https://github.com/", config.DefaultHost, false},
+		{"github.com API URL", "https://api.github.com", "", config.DefaultHost, false},
+		{
+			"enterprise web URL", "", "https://github.example.com",
+			config.Host{APIURL: "https://github.example.com/api/v3", WebURL: "https://github.example.com"}, false,
+		},
+		{
+			"enterprise API URL", "https://github.example.com/api/v3/", "",
+			config.Host{APIURL: "https://github.example.com/api/v3", WebURL: "https://github.example.com"}, false,
+		},
+		{
+			"API proxy", "http://localhost:8080", "",
+			config.Host{APIURL: "http://localhost:8080", WebURL: "http://localhost:8080"}, false,
+		},
+		{
+			"both", "https://api.example.com", "https://example.com",
+			config.Host{APIURL: "https://api.example.com", WebURL: "https://example.com"}, false,
+		},
+		{"no scheme", "", "github.example.com", config.Host{}, true},
+		{"query", "https://github.example.com/api/v3?x=1", "", config.Host{}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := config.ParseHost(tc.apiURL, tc.webURL)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseHost error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseHost = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHost_GraphQLURL(t *testing.T) {
+	if got, want := config.DefaultHost.GraphQLURL(), "https://api.github.com/graphql"; got != want {
+		t.Errorf("GraphQLURL() = %q, want %q", got, want)
+	}
+	host := config.Host{APIURL: "https://github.example.com/api/v3", WebURL: "https://github.example.com"}
+	if got, want := host.GraphQLURL(), "https://github.example.com/api/graphql"; got != want {
+		t.Errorf("enterprise GraphQLURL() = %q, want %q", got, want)
+	}
+}
+
+func TestHost_PullURL(t *testing.T) {
+	if got, want := config.DefaultHost.PullURL("nix-community/home-manager", 42), "https://github.com/nix-community/home-manager/pull/42"; got != want {
+		t.Errorf("PullURL = %q, want %q", got, want)
+	}
+	if got, want := config.DefaultHost.PullURL("", 42), "https://github.com/NixOS/nixpkgs/pull/42"; got != want {
+		t.Errorf("PullURL without a repository = %q, want %q", got, want)
+	}
+	host := config.Host{APIURL: "https://github.example.com/api/v3", WebURL: "https://github.example.com"}
+	if got, want := host.PullURL("NixOS/nixpkgs", 42), "https://github.example.com/NixOS/nixpkgs/pull/42"; got != want {
+		t.Errorf("enterprise PullURL = %q, want %q", got, want)
+	}
+}
+
+func TestHost_ParsePRInput(t *testing.T) {
+	host, err := config.ParseHost("", "https://github.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := host.ParsePRInput("https://github.example.com/NixOS/nixpkgs/pull/123")
+	if err != nil {
+		t.Fatalf("ParsePRInput returned error: %v", err)
+	}
+	if ref.Number != 123 || ref.Repo != config.DefaultRepo {
+		t.Errorf("ParsePRInput = %+v, want NixOS/nixpkgs#123", ref)
+	}
+
+	if _, err := host.ParsePRInput("https://github.com/NixOS/nixpkgs/pull/123"); err == nil {
+		t.Error("ParsePRInput should reject URLs of another host")
+	}
+	if _, err := host.ParsePRInput("https://github.example.com.evil/NixOS/nixpkgs/pull/123"); err == nil {
+		t.Error("ParsePRInput should reject hosts that only share a prefix")
+	}
+}
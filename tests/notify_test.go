@@ -0,0 +1,268 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/notify"
+)
+
+func testEvent() notify.Event {
+	status := statusWith(core.StatusPresent, core.StatusPresent)
+	status.Title = "hello: 1.0 -> 2.0"
+	status.MergeCommit = "abc123"
+	return notify.Event{
+		Status: status,
+		Change: core.ChannelChange{
+			Name:   "nixos-unstable",
+			Branch: "nixos-unstable",
+			From:   core.StatusNotPresent,
+			To:     core.StatusPresent,
+		},
+	}
+}
+
+type recordedRequest struct {
+	header http.Header
+	body   string
+}
+
+// newHookServer records every request and answers the first failures
+// requests with 503.
+func newHookServer(t *testing.T, failures int) (*httptest.Server, func() []recordedRequest) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		requests []recordedRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, recordedRequest{header: r.Header, body: string(body)})
+		n := len(requests)
+		mu.Unlock()
+		if n <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []recordedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recordedRequest(nil), requests...)
+	}
+}
+
+func TestParseHook(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{"exec:echo hi", "exec:echo hi", false},
+		{"https://example.com/hook", "json:https://example.com/hook", false},
+		{"json:http://localhost:8080", "json:http://localhost:8080", false},
+		{"ntfy:https://ntfy.sh/nprt", "ntfy:https://ntfy.sh/nprt", false},
+		{"exec:", "", true},
+		{"ntfy:ntfy.sh/nprt", "", true},
+		{"slack:https://example.com", "", true},
+		{"echo hi", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+			hook, err := notify.ParseHook(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseHook(%q) = %v, want error", tt.spec, hook)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseHook(%q) error = %v", tt.spec, err)
+			}
+			if hook.String() != tt.want {
+				t.Errorf("ParseHook(%q) = %q, want %q", tt.spec, hook.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhook_JSON(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newHookServer(t, 0)
+	hook := notify.NewWebhook(server.URL, notify.FormatJSON)
+
+	if err := hook.Notify(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	got := requests()
+	if len(got) != 1 {
+		t.Fatalf("requests = %d, want 1", len(got))
+	}
+	if ct := got[0].header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if ch := got[0].header.Get("X-Nprt-Channel"); ch != "nixos-unstable" {
+		t.Errorf("X-Nprt-Channel = %q, want nixos-unstable", ch)
+	}
+
+	var status core.PRStatus
+	if err := json.Unmarshal([]byte(got[0].body), &status); err != nil {
+		t.Fatalf("body is not a PRStatus: %v", err)
+	}
+	if status.Number != 1 || len(status.Channels) != 2 {
+		t.Errorf("body = %+v, want PR #1 with 2 channels", status)
+	}
+}
+
+func TestWebhook_Ntfy(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newHookServer(t, 0)
+	hook := notify.NewWebhook(server.URL, notify.FormatNtfy)
+
+	if err := hook.Notify(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	got := requests()
+	if len(got) != 1 {
+		t.Fatalf("requests = %d, want 1", len(got))
+	}
+	want := "PR #1 (hello: 1.0 -> 2.0) reached nixos-unstable"
+	if got[0].body != want {
+		t.Errorf("body = %q, want %q", got[0].body, want)
+	}
+	if title := got[0].header.Get("Title"); title != "PR #1: nixos-unstable" {
+		t.Errorf("Title = %q, want %q", title, "PR #1: nixos-unstable")
+	}
+	if tags := got[0].header.Get("Tags"); tags != "white_check_mark" {
+		t.Errorf("Tags = %q, want white_check_mark", tags)
+	}
+}
+
+func TestWebhook_Retries(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newHookServer(t, 2)
+	hook := notify.NewWebhook(server.URL, notify.FormatJSON)
+	hook.Backoff = time.Millisecond
+
+	if err := hook.Notify(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if n := len(requests()); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+}
+
+func TestWebhook_GivesUp(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newHookServer(t, 10)
+	hook := notify.NewWebhook(server.URL, notify.FormatJSON)
+	hook.Backoff = time.Millisecond
+	hook.Retries = 2
+
+	err := hook.Notify(context.Background(), testEvent())
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Notify() error = %v, want 503 error", err)
+	}
+	if n := len(requests()); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+}
+
+func TestWebhook_NoRetryOnClientError(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	hook := notify.NewWebhook(server.URL, notify.FormatJSON)
+	hook.Backoff = time.Millisecond
+
+	if err := hook.Notify(context.Background(), testEvent()); err == nil {
+		t.Error("Notify() error = nil, want error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestCommandHook_Env(t *testing.T) {
+	t.Parallel()
+
+	out := filepath.Join(t.TempDir(), "env")
+	hook := &notify.CommandHook{
+		Command: `printf '%s %s %s %s' "$NPRT_PR" "$NPRT_CHANNEL" "$NPRT_OLD_STATUS" "$NPRT_NEW_STATUS" > ` + out,
+	}
+
+	if err := hook.Notify(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1 nixos-unstable not_present present"
+	if string(data) != want {
+		t.Errorf("hook saw %q, want %q", data, want)
+	}
+}
+
+func TestCommandHook_Failure(t *testing.T) {
+	t.Parallel()
+
+	hook := &notify.CommandHook{Command: "echo broken >&2; exit 3"}
+	err := hook.Notify(context.Background(), testEvent())
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Notify() error = %v, want error containing stderr", err)
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newHookServer(t, 0)
+	hooks := []notify.Hook{
+		notify.NewWebhook(server.URL, notify.FormatNtfy),
+		&notify.CommandHook{Command: "exit 1"},
+	}
+	prev := statusWith(core.StatusNotPresent, core.StatusNotPresent)
+	cur := statusWith(core.StatusPresent, core.StatusPresent)
+
+	errs := notify.Dispatch(context.Background(), hooks, cur, core.DiffChannels(prev, cur), zap.NewNop())
+
+	if len(errs) != 2 {
+		t.Errorf("errors = %d, want 2 (one failing command per change)", len(errs))
+	}
+	if n := len(requests()); n != 2 {
+		t.Errorf("webhook requests = %d, want 2", n)
+	}
+}
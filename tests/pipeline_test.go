@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+func TestCheckPR_SelectsChannelsFromBase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pulls/500"):
+			w.Write([]byte(`{
+				"number": 500,
+				"title": "mass rebuild",
+				"state": "closed",
+				"merged": true,
+				"merge_commit_sha": "5555555555555555",
+				"base": {"ref": "staging"}
+			}`))
+		case strings.Contains(r.URL.Path, "/compare/"):
+			branch := r.URL.Path[strings.LastIndex(r.URL.Path, "...")+3:]
+			switch branch {
+			case "staging", "staging-next", "master", "nixpkgs-unstable":
+				w.Write([]byte(`{"status": "ahead", "ahead_by": 1, "behind_by": 0}`))
+			default:
+				w.Write([]byte(`{"status": "behind", "ahead_by": 0, "behind_by": 1}`))
+			}
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+
+	status, err := checker.CheckPR(context.Background(), 500, nil)
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+
+	if status.BaseBranch != "staging" {
+		t.Errorf("BaseBranch = %q, want staging", status.BaseBranch)
+	}
+	if len(status.Channels) != 6 {
+		t.Errorf("expected 6 channels for a staging PR, got %d", len(status.Channels))
+	}
+
+	wantPipeline := [][]string{
+		{"staging"},
+		{"staging-next"},
+		{"master"},
+		{"nixos-unstable-small", "nixpkgs-unstable"},
+		{"nixos-unstable"},
+	}
+	if !reflect.DeepEqual(status.Pipeline, wantPipeline) {
+		t.Errorf("Pipeline = %v, want %v", status.Pipeline, wantPipeline)
+	}
+	if !reflect.DeepEqual(status.WaitingOn, []string{"nixos-unstable-small"}) {
+		t.Errorf("WaitingOn = %v, want [nixos-unstable-small]", status.WaitingOn)
+	}
+}
+
+func TestCheckPR_UnmergedWaitsOnBase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"number": 600,
+			"title": "open PR",
+			"state": "open",
+			"merged": false,
+			"base": {"ref": "master"}
+		}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+
+	status, err := checker.CheckPR(context.Background(), 600, nil)
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(status.WaitingOn, []string{"master"}) {
+		t.Errorf("WaitingOn = %v, want [master]", status.WaitingOn)
+	}
+}
+
+func TestCheckPR_UnknownBaseUsesDefaults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"number": 700,
+			"title": "feature branch PR",
+			"state": "open",
+			"merged": false,
+			"base": {"ref": "gnome-49"}
+		}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = server.URL
+	checker := core.NewChecker(client, zap.NewNop())
+
+	status, err := checker.CheckPR(context.Background(), 700, nil)
+	if err != nil {
+		t.Fatalf("CheckPR returned error: %v", err)
+	}
+
+	if len(status.Channels) != 5 {
+		t.Errorf("expected the 5 default channels, got %d", len(status.Channels))
+	}
+	if status.Pipeline != nil {
+		t.Errorf("Pipeline = %v, want nil for unknown base", status.Pipeline)
+	}
+}
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+)
+
+var discoveredBranches = []string{
+	"nixos-24.05",
+	"nixos-24.05-small",
+	"nixos-24.11",
+	"nixos-24.11-small",
+	"nixpkgs-24.11-darwin",
+	"release-24.11",
+	"nixos-25.05",
+	"nixos-25.05-small",
+	"nixpkgs-25.05-darwin",
+	"release-25.05",
+	"release-25.11",
+	"nixos-unstable",
+	"nixpkgs-unstable",
+	"release-25.05-backup",
+}
+
+func TestReleaseChannelFromBranch(t *testing.T) {
+	tests := []struct {
+		branch  string
+		release string
+		ok      bool
+	}{
+		{"nixos-25.05", "25.05", true},
+		{"nixos-25.05-small", "25.05", true},
+		{"nixpkgs-25.05-darwin", "25.05", true},
+		{"release-25.05", "25.05", true},
+		{"nixos-unstable", "", false},
+		{"nixpkgs-unstable", "", false},
+		{"release-25.05-backup", "", false},
+		{"staging-25.05", "", false},
+	}
+
+	for _, tc := range tests {
+		ch, ok := config.ReleaseChannelFromBranch(tc.branch)
+		if ok != tc.ok {
+			t.Errorf("ReleaseChannelFromBranch(%q) ok = %v, want %v", tc.branch, ok, tc.ok)
+			continue
+		}
+		if ok && ch.Release != tc.release {
+			t.Errorf("ReleaseChannelFromBranch(%q).Release = %q, want %q", tc.branch, ch.Release, tc.release)
+		}
+	}
+}
+
+func TestReleaseChannels_NewestFirst(t *testing.T) {
+	channels := config.ReleaseChannels(discoveredBranches)
+
+	if len(channels) != 11 {
+		t.Fatalf("ReleaseChannels returned %d channels, want 11", len(channels))
+	}
+	if channels[0].Name != "release-25.11" {
+		t.Errorf("first channel = %q, want release-25.11", channels[0].Name)
+	}
+	if channels[len(channels)-1].Release != "24.05" {
+		t.Errorf("last channel release = %q, want 24.05", channels[len(channels)-1].Release)
+	}
+}
+
+func TestCatalog_StablePreset(t *testing.T) {
+	catalog := config.NewCatalog(config.ReleaseChannels(discoveredBranches))
+
+	// 25.11 has no nixos-25.11 channel yet, so it is not a supported release.
+	releases := catalog.SupportedReleases()
+	if len(releases) != 2 || releases[0] != "25.05" || releases[1] != "24.11" {
+		t.Fatalf("SupportedReleases = %v, want [25.05 24.11]", releases)
+	}
+
+	channels, err := catalog.Parse("stable")
+	if err != nil {
+		t.Fatalf("Parse(stable) returned error: %v", err)
+	}
+	if len(channels) != 8 {
+		t.Errorf("Parse(stable) returned %d channels, want 8", len(channels))
+	}
+	for _, ch := range channels {
+		if ch.Release != "25.05" && ch.Release != "24.11" {
+			t.Errorf("Parse(stable) returned channel %q from release %q", ch.Name, ch.Release)
+		}
+	}
+}
+
+func TestCatalog_CurrentAndPreviousPresets(t *testing.T) {
+	catalog := config.NewCatalog(config.ReleaseChannels(discoveredBranches))
+
+	current, err := catalog.Parse("current")
+	if err != nil {
+		t.Fatalf("Parse(current) returned error: %v", err)
+	}
+	for _, ch := range current {
+		if ch.Release != "25.05" {
+			t.Errorf("Parse(current) returned channel %q from release %q", ch.Name, ch.Release)
+		}
+	}
+
+	previous, err := catalog.Parse("previous")
+	if err != nil {
+		t.Fatalf("Parse(previous) returned error: %v", err)
+	}
+	for _, ch := range previous {
+		if ch.Release != "24.11" {
+			t.Errorf("Parse(previous) returned channel %q from release %q", ch.Name, ch.Release)
+		}
+	}
+}
+
+func TestCatalog_MixedNamesAndPresets(t *testing.T) {
+	catalog := config.NewCatalog(config.ReleaseChannels(discoveredBranches))
+
+	channels, err := catalog.Parse("unstable,nixos-24.05")
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(channels) != len(config.GetDefaultChannels())+1 {
+		t.Errorf("Parse returned %d channels, want %d", len(channels), len(config.GetDefaultChannels())+1)
+	}
+}
+
+func TestCatalog_UnknownChannel(t *testing.T) {
+	catalog := config.NewCatalog(config.ReleaseChannels(discoveredBranches))
+
+	_, err := catalog.Parse("nixos-99.99")
+	if err == nil {
+		t.Fatal("Parse should return error for undiscovered release")
+	}
+	if !strings.Contains(err.Error(), "nixos-25.05") {
+		t.Errorf("error should list supported release channels: %v", err)
+	}
+}
+
+func TestCatalog_PresetWithoutDiscovery(t *testing.T) {
+	_, err := config.ParseChannels("stable", nil)
+	if err == nil {
+		t.Error("ParseChannels(stable) should return error without discovered releases")
+	}
+}
+
+func TestNeedsDiscovery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"", false},
+		{"master,nixos-unstable", false},
+		{"unstable", false},
+		{"stable", true},
+		{"master,nixos-25.05", true},
+	}
+
+	for _, tc := range tests {
+		if got := config.NeedsDiscovery(tc.input, nil); got != tc.want {
+			t.Errorf("NeedsDiscovery(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/thatsneat-dev/nprt/internal/core"
 	"github.com/thatsneat-dev/nprt/internal/github"
@@ -121,6 +122,7 @@ func TestRenderTable_WithColor(t *testing.T) {
 func TestRenderTable_WithHyperlinks(t *testing.T) {
 	status := &core.PRStatus{
 		Number:      476497,
+		URL:         "https://github.com/NixOS/nixpkgs/pull/476497",
 		State:       core.PRStateMerged,
 		MergeCommit: "abc123",
 		Channels: []core.ChannelResult{
@@ -340,3 +342,231 @@ func TestRenderIssueWarning_IssueStates(t *testing.T) {
 		}
 	}
 }
+
+func TestRenderTable_ViaBackport(t *testing.T) {
+	status := &core.PRStatus{
+		Number:      100,
+		State:       core.PRStateMerged,
+		MergeCommit: "abc",
+		Channels: []core.ChannelResult{
+			{Name: "nixos-25.05", Branch: "nixos-25.05", Status: core.StatusPresent, ViaBackport: 200},
+		},
+	}
+
+	var buf bytes.Buffer
+	renderer := render.NewRenderer(&buf, false, false)
+	if err := renderer.RenderTable(status); err != nil {
+		t.Fatalf("RenderTable returned error: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "via backport #200") {
+		t.Errorf("Output should mention the backport PR, got: %s", buf.String())
+	}
+}
+
+func TestRenderTable_Commit(t *testing.T) {
+	const sha = "3f2a9c1e5b7d0000000000000000000000000000"
+	channels := []core.ChannelResult{{Name: "master", Branch: "master", Status: core.StatusPresent}}
+
+	var buf bytes.Buffer
+	renderer := render.NewRenderer(&buf, false, false)
+	withPR := &core.PRStatus{Number: 100, Title: "hello", State: core.PRStateMerged, Commit: sha, Channels: channels}
+	if err := renderer.RenderTable(withPR); err != nil {
+		t.Fatalf("RenderTable returned error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "PR #100 (hello)") || !strings.Contains(out, "commit: 3f2a9c1e5b7d\n") {
+		t.Errorf("Output should show the PR and the checked commit, got: %s", out)
+	}
+
+	buf.Reset()
+	withoutPR := &core.PRStatus{Commit: sha, Channels: channels}
+	if err := renderer.RenderTable(withoutPR); err != nil {
+		t.Fatalf("RenderTable returned error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Commit 3f2a9c1e5b7d\n") || strings.Contains(out, "PR #") {
+		t.Errorf("Output should name the commit instead of a PR, got: %s", out)
+	}
+}
+
+func TestRenderJSON_ViaBackport(t *testing.T) {
+	status := &core.PRStatus{
+		Number: 100,
+		State:  core.PRStateMerged,
+		Channels: []core.ChannelResult{
+			{Name: "nixos-25.05", Branch: "nixos-25.05", Status: core.StatusPresent, ViaBackport: 200},
+			{Name: "master", Branch: "master", Status: core.StatusPresent},
+		},
+	}
+
+	var buf bytes.Buffer
+	renderer := render.NewRenderer(&buf, false, false)
+	if err := renderer.RenderJSON(status); err != nil {
+		t.Fatalf("RenderJSON returned error: %v", err)
+	}
+
+	var result struct {
+		Channels []map[string]any `json:"channels"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if result.Channels[0]["via_backport"] != float64(200) {
+		t.Errorf("via_backport = %v, want 200", result.Channels[0]["via_backport"])
+	}
+	if _, ok := result.Channels[1]["via_backport"]; ok {
+		t.Error("via_backport should be omitted when the original commit is present")
+	}
+}
+
+func TestRenderTable_Pipeline(t *testing.T) {
+	status := &core.PRStatus{
+		Number:     100,
+		State:      core.PRStateMerged,
+		BaseBranch: "master",
+		Channels: []core.ChannelResult{
+			{Name: "master", Branch: "master", Status: core.StatusPresent},
+			{Name: "nixpkgs-unstable", Branch: "nixpkgs-unstable", Status: core.StatusPresent},
+			{Name: "nixos-unstable", Branch: "nixos-unstable", Status: core.StatusNotPresent},
+			{Name: "nixos-unstable-small", Branch: "nixos-unstable-small", Status: core.StatusNotPresent},
+		},
+		Pipeline: [][]string{
+			{"master"},
+			{"nixos-unstable-small", "nixpkgs-unstable"},
+			{"nixos-unstable"},
+		},
+		WaitingOn: []string{"nixos-unstable-small"},
+	}
+
+	var buf bytes.Buffer
+	renderer := render.NewRenderer(&buf, false, false)
+	if err := renderer.RenderTable(status); err != nil {
+		t.Fatalf("RenderTable returned error: %v", err)
+	}
+
+	output := buf.String()
+	want := "master ✓ → nixos-unstable-small ✗ | nixpkgs-unstable ✓ → nixos-unstable ✗"
+	if !strings.Contains(output, want) {
+		t.Errorf("Output should contain pipeline %q, got:\n%s", want, output)
+	}
+	if !strings.Contains(output, "next hop: nixos-unstable-small") {
+		t.Errorf("Output should contain next hop, got:\n%s", output)
+	}
+}
+
+func TestLiveView_Redraw(t *testing.T) {
+	var buf bytes.Buffer
+	view := render.NewLiveView(&buf)
+
+	if err := view.Redraw([]byte("one\ntwo\n")); err != nil {
+		t.Fatalf("Redraw returned error: %v", err)
+	}
+	if buf.String() != "one\ntwo\n" {
+		t.Errorf("first Redraw wrote %q, want content only", buf.String())
+	}
+
+	buf.Reset()
+	if err := view.Redraw([]byte("three\n")); err != nil {
+		t.Fatalf("Redraw returned error: %v", err)
+	}
+	if buf.String() != "\033[2F\033[Jthree\n" {
+		t.Errorf("second Redraw wrote %q, want cursor reset over 2 lines", buf.String())
+	}
+}
+
+func TestRenderChanges(t *testing.T) {
+	var buf bytes.Buffer
+	renderer := render.NewRenderer(&buf, false, false)
+	at := time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	err := renderer.RenderChanges(at, []core.ChannelChange{
+		{Name: "nixos-unstable", From: core.StatusNotPresent, To: core.StatusPresent},
+	})
+	if err != nil {
+		t.Fatalf("RenderChanges returned error: %v", err)
+	}
+
+	want := "12:30:00 nixos-unstable: not_present → present ✓\n"
+	if buf.String() != want {
+		t.Errorf("RenderChanges = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRenderWideTable(t *testing.T) {
+	t.Setenv("NO_NERD_FONTS", "1")
+	status := &core.PRStatus{
+		Number: 100,
+		Title:  "hello",
+		State:  core.PRStateMerged,
+		Channels: []core.ChannelResult{
+			{Name: "master", Branch: "master", Status: core.StatusPresent, Head: "0123456789abcdef", HeadAgeSeconds: 40 * 60},
+			{Name: "nixos-25.05", Branch: "nixos-25.05", Status: core.StatusPresent, ViaBackport: 200, Head: "fedcba9876543210", HeadAgeSeconds: 5 * 3600},
+			{Name: "nixos-unstable", Branch: "nixos-unstable", Status: core.StatusNotPresent, CommitsBehind: 12, Head: "abcdef0123456789", HeadAgeSeconds: 4 * 86400},
+			{Name: "nixos-24.11", Branch: "nixos-24.11", Status: core.StatusUnknown},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := render.NewRenderer(&buf, false, false).RenderWideTable(status); err != nil {
+		t.Fatalf("RenderWideTable returned error: %v", err)
+	}
+	want := strings.Join([]string{
+		"CHANNEL         STATUS  BEHIND  HEAD          AGE",
+		"-------------------------------------------------",
+		"master            ✓          -  0123456789ab  40m",
+		"nixos-25.05       ✓          -  fedcba987654  5h   via backport #200",
+		"nixos-unstable    ✗         12  abcdef012345  4d",
+		"nixos-24.11       ?          -  -             -",
+		"",
+	}, "\n")
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("RenderWideTable() =\n%s\nwant it to end with:\n%s", got, want)
+	}
+}
+
+func TestRenderTable_Landing(t *testing.T) {
+	t.Setenv("NO_NERD_FONTS", "1")
+	status := &core.PRStatus{
+		Number: 100,
+		Title:  "hello",
+		State:  core.PRStateMerged,
+		Channels: []core.ChannelResult{
+			{Name: "master", Branch: "master", Status: core.StatusPresent,
+				LandedRevision: "0123456789abcdef", LandedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+			{Name: "nixos-25.05", Branch: "nixos-25.05", Status: core.StatusPresent, ViaBackport: 200,
+				LandedRevision: "fedcba9876543210", LandedAt: time.Date(2026, 1, 3, 4, 5, 6, 0, time.FixedZone("CET", 3600))},
+			{Name: "nixos-unstable", Branch: "nixos-unstable", Status: core.StatusNotPresent},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := render.NewRenderer(&buf, false, false).RenderTable(status); err != nil {
+		t.Fatalf("RenderTable returned error: %v", err)
+	}
+	want := strings.Join([]string{
+		"master            ✓  landed 2026-01-02 03:04 UTC in 0123456789ab",
+		"nixos-25.05       ✓  via backport #200, landed 2026-01-03 03:05 UTC in fedcba987654",
+		"nixos-unstable    ✗  ",
+		"",
+	}, "\n")
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("RenderTable() =\n%s\nwant it to end with:\n%s", got, want)
+	}
+}
+
+func TestRenderTable_MissingBackports(t *testing.T) {
+	t.Setenv("NO_NERD_FONTS", "1")
+	status := &core.PRStatus{
+		Number:           100,
+		State:            core.PRStateMerged,
+		Channels:         []core.ChannelResult{{Name: "master", Branch: "master", Status: core.StatusPresent}},
+		MissingBackports: []string{"25.05", "24.11"},
+	}
+
+	var buf bytes.Buffer
+	if err := render.NewRenderer(&buf, false, false).RenderTable(status); err != nil {
+		t.Fatalf("RenderTable returned error: %v", err)
+	}
+	if want := "master     ✓  \n\nno backport PR yet for: 25.05, 24.11\n"; !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("RenderTable() =\n%s\nwant it to end with:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+)
+
+func TestParseRepo(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    config.Repo
+		wantErr bool
+	}{
+		{"NixOS/nixpkgs", config.DefaultRepo, false},
+		{"nix-community/home-manager", config.Repo{Owner: "nix-community", Name: "home-manager"}, false},
+		{"LnL7/nix-darwin.git", config.Repo{Owner: "LnL7", Name: "nix-darwin.git"}, false},
+		{"nixpkgs", config.Repo{}, true},
+		{"-owner/repo", config.Repo{}, true},
+		{"owner/repo/extra", config.Repo{}, true},
+		{"owner/..", config.Repo{}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := config.ParseRepo(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseRepo(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseRepo(%q) = %v, want %v", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRepo_IsNixpkgs(t *testing.T) {
+	if !(config.Repo{Owner: "nixos", Name: "NIXPKGS"}).IsNixpkgs() {
+		t.Error("repository names should compare case-insensitively")
+	}
+	if (config.Repo{Owner: "nix-community", Name: "home-manager"}).IsNixpkgs() {
+		t.Error("home-manager is not nixpkgs")
+	}
+}
+
+const repoConfig = `
+[repos."nix-community/home-manager"]
+channels = ["master", "release-25.05"]
+
+[repos."nix-community/home-manager".presets]
+stable = ["release-25.05"]
+all = ["master", "stable"]
+`
+
+func TestParseFile_Repos(t *testing.T) {
+	f, err := config.ParseFile(repoConfig)
+	if err != nil {
+		t.Fatalf("ParseFile returned error: %v", err)
+	}
+
+	repo := config.Repo{Owner: "Nix-Community", Name: "Home-Manager"}
+	rc := f.ForRepo(repo)
+	if rc == nil {
+		t.Fatal("ForRepo should find the repository case-insensitively")
+	}
+	wantChannels := []config.Channel{
+		{Name: "master", Branch: "master"},
+		{Name: "release-25.05", Branch: "release-25.05"},
+	}
+	if !reflect.DeepEqual(rc.Channels, wantChannels) {
+		t.Errorf("Channels = %v, want %v", rc.Channels, wantChannels)
+	}
+	if got := f.RepoNames(); len(got) != 1 || got[0].String() != "nix-community/home-manager" {
+		t.Errorf("RepoNames() = %v, want [nix-community/home-manager]", got)
+	}
+	if f.ForRepo(config.DefaultRepo) != nil {
+		t.Error("ForRepo(NixOS/nixpkgs) should be nil")
+	}
+}
+
+func TestParseFile_RepoErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"nixpkgs", "[repos.\"NixOS/nixpkgs\"]\nchannels = [\"master\"]\n", "built in"},
+		{"invalid repo", "[repos.nixpkgs]\nchannels = [\"master\"]\n", "must be owner/name"},
+		{"unknown key", "[repos.\"a/b\"]\nbranches = [\"main\"]\n", "only channels is allowed"},
+		{"unknown subtable", "[repos.\"a/b\".groups]\nx = [\"main\"]\n", "unknown table"},
+		{"preset cycle", "[repos.\"a/b\".presets]\nx = \"y\"\ny = \"x\"\n", "a/b preset"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := config.ParseFile(tc.data)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("ParseFile error = %v, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestRepoCatalog(t *testing.T) {
+	f, err := config.ParseFile(repoConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := config.Repo{Owner: "nix-community", Name: "home-manager"}
+	catalog := config.NewRepoCatalog(repo, f.ForRepo(repo))
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", []string{"master", "release-25.05"}},
+		{"all", []string{"master", "release-25.05"}},
+		{"release-24.11,stable", []string{"release-24.11", "release-25.05"}},
+	}
+	for _, tc := range tests {
+		channels, err := catalog.Parse(tc.input)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", tc.input, err)
+		}
+		var names []string
+		for _, ch := range channels {
+			if ch.Branch != ch.Name {
+				t.Errorf("channel %s branch = %q, want the channel name", ch.Name, ch.Branch)
+			}
+			names = append(names, ch.Name)
+		}
+		if !reflect.DeepEqual(names, tc.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tc.input, names, tc.want)
+		}
+	}
+
+	unconfigured := config.NewRepoCatalog(config.Repo{Owner: "a", Name: "b"}, nil)
+	if channels, err := unconfigured.Parse(""); err != nil || len(channels) != 0 {
+		t.Errorf("Parse(\"\") without config = %v, %v; want no channels so the base branch is checked", channels, err)
+	}
+}
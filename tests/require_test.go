@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+)
+
+func requireStatus(statuses ...core.ChannelStatus) *core.PRStatus {
+	names := []string{"master", "nixos-unstable", "nixos-25.05"}
+	status := &core.PRStatus{Number: 1, State: core.PRStateMerged}
+	for i, s := range statuses {
+		status.Channels = append(status.Channels, core.ChannelResult{Name: names[i], Branch: names[i], Status: s})
+	}
+	return status
+}
+
+func TestParseRequirement(t *testing.T) {
+	tests := []struct {
+		spec      string
+		wantMode  core.RequireMode
+		wantNames string
+	}{
+		{"all", core.RequireAll, ""},
+		{"any", core.RequireAny, ""},
+		{"nixos-unstable,stable", core.RequireChannels, "nixos-unstable,stable"},
+	}
+	for _, tt := range tests {
+		req, names := core.ParseRequirement(tt.spec)
+		if req.Mode != tt.wantMode || names != tt.wantNames {
+			t.Errorf("ParseRequirement(%q) = %v, %q, want %v, %q", tt.spec, req.Mode, names, tt.wantMode, tt.wantNames)
+		}
+	}
+}
+
+func TestRequirement_Evaluate(t *testing.T) {
+	present, absent, unknown := core.StatusPresent, core.StatusNotPresent, core.StatusUnknown
+	named := func(names ...string) core.Requirement {
+		return core.Requirement{Mode: core.RequireChannels, Channels: names}
+	}
+	all := core.Requirement{Mode: core.RequireAll}
+	anyOf := core.Requirement{Mode: core.RequireAny}
+
+	tests := []struct {
+		name   string
+		req    core.Requirement
+		status *core.PRStatus
+		want   core.ChannelStatus
+	}{
+		{"named present", named("master"), requireStatus(present, absent), present},
+		{"named absent", named("master", "nixos-unstable"), requireStatus(present, absent), absent},
+		{"named unknown", named("nixos-unstable"), requireStatus(present, unknown), unknown},
+		{"absent beats unknown", named("nixos-unstable", "nixos-25.05"), requireStatus(present, unknown, absent), absent},
+		{"named unchecked", named("nixos-25.05"), requireStatus(present, present), unknown},
+		{"all present", all, requireStatus(present, present), present},
+		{"all with one absent", all, requireStatus(present, absent, unknown), absent},
+		{"all with one unknown", all, requireStatus(present, unknown), unknown},
+		{"any present", anyOf, requireStatus(absent, present, unknown), present},
+		{"any unknown", anyOf, requireStatus(absent, unknown), unknown},
+		{"any absent", anyOf, requireStatus(absent, absent), absent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.req.Evaluate(tt.status); got != tt.want {
+				t.Errorf("Evaluate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequirement_EvaluateResults(t *testing.T) {
+	req := core.Requirement{Mode: core.RequireChannels, Channels: []string{"master"}}
+	present := core.BatchResult{Number: 1, Status: requireStatus(core.StatusPresent)}
+	unknown := core.BatchResult{Number: 2, Status: requireStatus(core.StatusUnknown)}
+	absent := core.BatchResult{Number: 3, Status: requireStatus(core.StatusNotPresent)}
+	failed := core.BatchResult{Number: 4, Err: errors.New("boom")}
+
+	tests := []struct {
+		name    string
+		results []core.BatchResult
+		want    core.ChannelStatus
+	}{
+		{"all present", []core.BatchResult{present, failed}, core.StatusPresent},
+		{"one unknown", []core.BatchResult{present, unknown}, core.StatusUnknown},
+		{"one absent", []core.BatchResult{unknown, absent, present}, core.StatusNotPresent},
+		{"none checked", []core.BatchResult{failed}, core.StatusNotPresent},
+		{"no results", nil, core.StatusNotPresent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := req.EvaluateResults(tt.results); got != tt.want {
+				t.Errorf("EvaluateResults() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// buildNprt builds the nprt binary into a temporary directory.
+func buildNprt(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+	bin := filepath.Join(t.TempDir(), "nprt")
+	if out, err := exec.Command("go", "build", "-o", bin, "../cmd/nprt").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	return bin
+}
+
+func TestCLI_RequireExitCodes(t *testing.T) {
+	bin := buildNprt(t)
+
+	// PR 100 is in master and nixos-25.05, not in nixos-unstable, and the
+	// comparisons with the other channels fail.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pulls/100"):
+			w.Write([]byte(`{"number": 100, "state": "closed", "merged": true,
+				"merge_commit_sha": "abc123", "base": {"ref": "master"}}`))
+		case strings.HasSuffix(r.URL.Path, "/git/matching-refs/heads/nixos-"):
+			w.Write([]byte(`[{"ref": "refs/heads/nixos-25.05"}]`))
+		case strings.Contains(r.URL.Path, "/git/matching-refs/"):
+			w.Write([]byte(`[]`))
+		case strings.HasSuffix(r.URL.Path, "...master"), strings.HasSuffix(r.URL.Path, "...nixos-25.05"):
+			w.Write([]byte(`{"status": "ahead", "behind_by": 0}`))
+		case strings.HasSuffix(r.URL.Path, "...nixos-unstable"):
+			w.Write([]byte(`{"status": "diverged", "behind_by": 3}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	home := t.TempDir()
+	env := []string{"PATH=" + os.Getenv("PATH"), "HOME=" + home, "XDG_CONFIG_HOME=" + home, "XDG_CACHE_HOME=" + home}
+
+	tests := []struct {
+		channels string
+		require  string
+		want     int
+	}{
+		{"master,nixos-unstable-small,nixos-unstable", "", 0},
+		{"master,nixos-unstable-small,nixos-unstable", "master", 0},
+		{"master,nixos-unstable-small,nixos-unstable", "any", 0},
+		{"master,nixos-unstable-small,nixos-unstable", "nixos-unstable", 4},
+		{"master,nixos-unstable-small,nixos-unstable", "master,nixos-unstable", 4},
+		{"master,nixos-unstable-small,nixos-unstable", "all", 4},
+		{"master,nixos-unstable-small,nixos-unstable", "nixos-unstable-small", 5},
+		{"master,nixos-unstable-small,nixos-unstable", "master,nixos-unstable-small", 5},
+		{"master", "nixos-25.05", 0},
+		// Without --channels, the required channels are checked along
+		// with the pipeline of the base branch.
+		{"", "nixos-25.05", 0},
+		{"", "master,nixos-25.05", 0},
+		{"", "nixos-unstable,nixos-25.05", 4},
+		{"", "nixos-unstable-small", 5},
+	}
+	for _, tt := range tests {
+		t.Run("channels="+tt.channels+",require="+tt.require, func(t *testing.T) {
+			args := []string{"--no-cache", "--retries=0", "--api-url", server.URL}
+			if tt.channels != "" {
+				args = append(args, "--channels", tt.channels)
+			}
+			if tt.require != "" {
+				args = append(args, "--require="+tt.require)
+			}
+			for _, extra := range [][]string{{"100"}, {"--json", "100"}, {"100", "100"}} {
+				cmd := exec.Command(bin, append(args, extra...)...)
+				cmd.Env = env
+				out, _ := cmd.CombinedOutput()
+				if got := cmd.ProcessState.ExitCode(); got != tt.want {
+					t.Errorf("nprt %s exited %d, want %d\n%s", strings.Join(extra, " "), got, tt.want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestCLI_RequireIssueWithoutMergedPRs(t *testing.T) {
+	bin := buildNprt(t)
+
+	// Issue 200 has no related PRs, so nothing can contain its fix.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/issues/200/timeline"):
+			w.Write([]byte(`[]`))
+		case strings.HasSuffix(r.URL.Path, "/issues/200"):
+			w.Write([]byte(`{"number": 200, "title": "hello crashes", "state": "open",
+				"html_url": "https://github.com/NixOS/nixpkgs/issues/200"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	home := t.TempDir()
+	cmd := exec.Command(bin, "--no-cache", "--retries=0", "--api-url", server.URL,
+		"--issues=check", "--channels=nixos-unstable", "--require=nixos-unstable", "200")
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + home, "XDG_CONFIG_HOME=" + home, "XDG_CACHE_HOME=" + home}
+	out, _ := cmd.CombinedOutput()
+	if got := cmd.ProcessState.ExitCode(); got != 4 {
+		t.Errorf("nprt exited %d, want 4\n%s", got, out)
+	}
+}
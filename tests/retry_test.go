@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/github"
+)
+
+// fastRetryClient returns a client whose backoff delays are short enough
+// for tests.
+func fastRetryClient(serverURL string) *github.Client {
+	client := github.NewClient("", "", zap.NewNop())
+	client.BaseURL = serverURL
+	client.Retry = github.RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  time.Millisecond,
+		MaxDelay:   5 * time.Millisecond,
+		MaxWait:    2 * time.Second,
+	}
+	return client
+}
+
+func TestRetry_ServerErrorThenSuccess(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"status": "ahead", "ahead_by": 1, "behind_by": 0}`))
+	}))
+	defer server.Close()
+
+	result, err := fastRetryClient(server.URL).CompareCommitWithBranch(context.Background(), "abc123", "master")
+	if err != nil {
+		t.Fatalf("CompareCommitWithBranch returned error: %v", err)
+	}
+	if result.BehindBy != 0 {
+		t.Errorf("BehindBy = %d, want 0", result.BehindBy)
+	}
+	if requests != 3 {
+		t.Errorf("server saw %d requests, want 3", requests)
+	}
+}
+
+func TestRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := fastRetryClient(server.URL).CompareCommitWithBranch(context.Background(), "abc123", "master")
+
+	var apiErr *github.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("error should be a 503 APIError, got: %T (%v)", err, err)
+	}
+	if requests != 4 {
+		t.Errorf("server saw %d requests, want 4", requests)
+	}
+}
+
+func TestRetry_DoesNotRetryClientErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "Bad credentials"}`))
+	}))
+	defer server.Close()
+
+	_, err := fastRetryClient(server.URL).CompareCommitWithBranch(context.Background(), "abc123", "master")
+	if err == nil {
+		t.Fatal("CompareCommitWithBranch should have returned error")
+	}
+	if requests != 1 {
+		t.Errorf("server saw %d requests, want 1", requests)
+	}
+}
+
+func TestRetry_HonorsRetryAfter(t *testing.T) {
+	var first time.Time
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "You have exceeded a secondary rate limit."}`))
+			return
+		}
+		if waited := time.Since(first); waited < 900*time.Millisecond {
+			t.Errorf("retried after %s, want at least 1s", waited)
+		}
+		w.Write([]byte(`{"status": "ahead", "ahead_by": 1, "behind_by": 0}`))
+	}))
+	defer server.Close()
+
+	if _, err := fastRetryClient(server.URL).CompareCommitWithBranch(context.Background(), "abc123", "master"); err != nil {
+		t.Fatalf("CompareCommitWithBranch returned error: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("server saw %d requests, want 2", requests)
+	}
+}
+
+func TestRetry_RateLimitResetBeyondMaxWait(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+	}))
+	defer server.Close()
+
+	_, err := fastRetryClient(server.URL).CompareCommitWithBranch(context.Background(), "abc123", "master")
+
+	var apiErr *github.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("error should be a 403 APIError, got: %T (%v)", err, err)
+	}
+	if requests != 1 {
+		t.Errorf("server saw %d requests, want 1", requests)
+	}
+}
+
+func TestRetry_RespectsContextCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := fastRetryClient(server.URL)
+	client.Retry.BaseDelay = time.Hour
+	client.Retry.MaxDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.CompareCommitWithBranch(ctx, "abc123", "master")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("CompareCommitWithBranch took %s after cancellation", elapsed)
+	}
+}
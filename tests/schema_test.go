@@ -0,0 +1,230 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+	"github.com/thatsneat-dev/nprt/internal/render"
+)
+
+// loadSchema reads docs/nprt.schema.json.
+func loadSchema(t *testing.T) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile("../docs/nprt.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	return schema
+}
+
+// schemaValidator checks JSON values against the subset of JSON Schema that
+// docs/nprt.schema.json uses: $ref to $defs, type, const, enum, minimum,
+// properties, required, additionalProperties, items and oneOf.
+type schemaValidator struct {
+	root map[string]any
+}
+
+func (v schemaValidator) validate(schema map[string]any, value any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name, ok := strings.CutPrefix(ref, "#/$defs/")
+		if !ok {
+			return fmt.Errorf("%s: unsupported $ref %q", path, ref)
+		}
+		def, ok := v.root["$defs"].(map[string]any)[name].(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: undefined $ref %q", path, ref)
+		}
+		return v.validate(def, value, path)
+	}
+
+	if options, ok := schema["oneOf"].([]any); ok {
+		matches := 0
+		var errs []error
+		for _, option := range options {
+			if err := v.validate(option.(map[string]any), value, path); err != nil {
+				errs = append(errs, err)
+			} else {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: matches %d of oneOf: %v", path, matches, errors.Join(errs...))
+		}
+	}
+	if want, ok := schema["const"]; ok && !reflect.DeepEqual(value, want) {
+		return fmt.Errorf("%s: %v is not %v", path, value, want)
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			found = found || reflect.DeepEqual(value, e)
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+		}
+	}
+
+	switch schema["type"] {
+	case nil:
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: %v is not an object", path, value)
+		}
+		return v.validateObject(schema, obj, path)
+	case "array":
+		list, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: %v is not an array", path, value)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range list {
+				if err := v.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: %v is not a string", path, value)
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: %v is not an integer", path, value)
+		}
+		if minimum, ok := schema["minimum"].(float64); ok && n < minimum {
+			return fmt.Errorf("%s: %v is less than %v", path, n, minimum)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported type %v", path, schema["type"])
+	}
+	return nil
+}
+
+func (v schemaValidator) validateObject(schema, obj map[string]any, path string) error {
+	props, _ := schema["properties"].(map[string]any)
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, ok := obj[name.(string)]; !ok {
+			return fmt.Errorf("%s: missing required %q", path, name)
+		}
+	}
+	for name, value := range obj {
+		prop, ok := props[name].(map[string]any)
+		if !ok {
+			if schema["additionalProperties"] == false {
+				return fmt.Errorf("%s: unexpected property %q", path, name)
+			}
+			continue
+		}
+		if err := v.validate(prop, value, path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateJSON decodes data and validates it against the schema.
+func validateJSON(t *testing.T, schema map[string]any, data []byte) {
+	t.Helper()
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	if err := (schemaValidator{root: schema}).validate(schema, value, "$"); err != nil {
+		t.Errorf("JSON does not match schema: %v\n%s", err, data)
+	}
+}
+
+func TestSchema_MatchesOutput(t *testing.T) {
+	schema := loadSchema(t)
+
+	detailed := formatStatus()
+	detailed.Pipeline = [][]string{{"master"}, {"nixos-unstable"}}
+	detailed.WaitingOn = []string{"nixos-unstable"}
+	detailed.MissingBackports = []string{"24.11"}
+	detailed.Channels[1].CommitsBehind = 12
+	detailed.Channels[1].Head = "0123456789abcdef"
+	detailed.Channels[1].HeadDate = time.Unix(1700000000, 0)
+	detailed.Channels[1].HeadAgeSeconds = 3600
+	detailed.Channels[0].LandedRevision = "fedcba9876543210"
+	detailed.Channels[0].LandedAt = time.Unix(1690000000, 0)
+	detailed.RateLimit = &github.RateLimit{Limit: 5000, Remaining: 4999, Used: 1, Reset: time.Unix(1700000000, 0), Resource: "core"}
+
+	repo := config.DefaultRepo
+	failures := []core.BatchResult{
+		{Repo: "NixOS/nixpkgs", Number: 1, Err: &github.NotFoundError{Repo: repo, Number: 1}},
+		{Repo: "NixOS/nixpkgs", Commit: "3f2a9c1", Err: &github.CommitNotFoundError{Repo: repo, SHA: "3f2a9c1"}},
+		{Repo: "NixOS/nixpkgs", Number: 2, Err: &github.NotPullRequestError{Number: 2, Title: "bug", State: "open",
+			RelatedPRs: []github.RelatedPR{{Number: 3, Title: "fix", URL: "https://github.com/NixOS/nixpkgs/pull/3", State: "merged"}}}},
+		{Repo: "NixOS/nixpkgs", Number: 4, Err: &github.APIError{StatusCode: 403, Message: "rate limit", RateLimited: true}},
+		{Repo: "NixOS/nixpkgs", Number: 5, Err: fmt.Errorf("boom")},
+	}
+
+	tests := []struct {
+		name   string
+		render func(r *render.Renderer) error
+	}{
+		{"status", func(r *render.Renderer) error { return r.RenderJSON(formatStatus()) }},
+		{"status with details", func(r *render.Renderer) error { return r.RenderJSON(detailed) }},
+		{"batch", func(r *render.Renderer) error { return r.RenderBatchJSON(formatBatch()) }},
+		{"failures", func(r *render.Renderer) error { return r.RenderBatchJSON(failures) }},
+		{"failure", func(r *render.Renderer) error { return r.RenderResultJSON(failures[2]) }},
+		{"issue", func(r *render.Renderer) error { return r.RenderIssueJSON(formatIssue()) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.render(render.NewRenderer(&buf, false, false)); err != nil {
+				t.Fatal(err)
+			}
+			validateJSON(t, schema, buf.Bytes())
+		})
+	}
+
+	t.Run("ndjson", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := render.NewRenderer(&buf, false, false)
+		for _, res := range append(formatBatch(), failures...) {
+			if err := r.RenderNDJSON(res); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+			validateJSON(t, schema, line)
+		}
+	})
+}
+
+func TestSchema_RejectsUnknownFields(t *testing.T) {
+	schema := loadSchema(t)
+	err := (schemaValidator{root: schema}).validate(schema,
+		map[string]any{"schema_version": float64(1), "pr": float64(1), "state": "merged", "channels": []any{}, "bogus": true}, "$")
+	if err == nil {
+		t.Error("validate() accepted an unknown field")
+	}
+}
+
+func TestSchema_VersionMatchesCode(t *testing.T) {
+	schema := loadSchema(t)
+	version := schema["$defs"].(map[string]any)["schemaVersion"].(map[string]any)["const"]
+	if version != float64(core.SchemaVersion) {
+		t.Errorf("schema version = %v, want core.SchemaVersion = %d", version, core.SchemaVersion)
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/thatsneat-dev/nprt/internal/render"
+)
+
+func renderTemplate(t *testing.T, text string, useColor, useHyperlinks bool) (string, error) {
+	t.Helper()
+	tmpl, err := render.ParseTemplate("--template", text)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	var buf bytes.Buffer
+	err = render.NewRenderer(&buf, useColor, useHyperlinks).RenderTemplate(tmpl, formatStatus())
+	return buf.String(), err
+}
+
+func TestRenderTemplate_Helpers(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"fields", `{{.Number}} {{.Title}}`, "475593 golang: 1.23.5 -> 1.23.6 | security"},
+		{"channel", `{{(channel "nixos-25.05").ViaBackport}}`, "475700"},
+		{"present by name", `{{present "master"}} {{present "nixos-unstable"}} {{present "nixos-99.99"}}`, "true false false"},
+		{"present channel", `{{range .Channels}}{{if present .}}{{.Name}} {{end}}{{end}}`, "master nixos-25.05 "},
+		{"icon", `{{range .Channels}}{{icon .}}{{end}}`, "✓✗✓?"},
+		{"join", `{{join ", " .Channels}}`, "master, nixos-unstable, nixos-25.05, nixos-24.11"},
+		{"json", `{{json (channel "master")}}`, `{"name":"master","branch":"master","status":"present"}`},
+		{"short", `{{short "0123456789abcdef0123"}}`, "0123456789ab"},
+		{"color disabled", `{{color "green" .Number}}`, "475593"},
+		{"hyperlink disabled", `{{hyperlink .URL "PR"}}`, "PR"},
+		{"no newline added", "{{.Number}}\n", "475593\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTemplate(t, tt.text, false, false)
+			if err != nil {
+				t.Fatalf("RenderTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate_ColorAndHyperlink(t *testing.T) {
+	got, err := renderTemplate(t, `{{color "red" "x"}} {{hyperlink .URL "PR"}}`, true, true)
+	if err != nil {
+		t.Fatalf("RenderTemplate() error = %v", err)
+	}
+	if !strings.HasPrefix(got, "\033[") || !strings.Contains(got, "x\033[0m") {
+		t.Errorf("expected red text, got %q", got)
+	}
+	if !strings.Contains(got, "\033]8;;https://github.com/NixOS/nixpkgs/pull/475593\033\\PR") {
+		t.Errorf("expected hyperlink, got %q", got)
+	}
+}
+
+func TestParseTemplate_ErrorLocation(t *testing.T) {
+	_, err := render.ParseTemplate("status.tmpl", "PR {{.Number}}\n  {{ nope .Title }}")
+	var te *render.TemplateError
+	if !errors.As(err, &te) {
+		t.Fatalf("ParseTemplate() error = %v, want *TemplateError", err)
+	}
+	if te.Line != 2 || te.Column != 6 {
+		t.Errorf("location = %d:%d, want 2:6", te.Line, te.Column)
+	}
+	if want := `status.tmpl:2:6: function "nope" not defined`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestRenderTemplate_ErrorLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"unknown field", "{{.Number}}\n{{.Nope}}", `--template:2:3: <.Nope>: can't evaluate field Nope in type *core.PRStatus`},
+		{"unknown color", `{{color "pink" .Title}}`, `--template:1:3: <color "pink" .Title>: error calling color: unknown color "pink"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := renderTemplate(t, tt.text, false, false)
+			var te *render.TemplateError
+			if !errors.As(err, &te) {
+				t.Fatalf("RenderTemplate() error = %v, want *TemplateError", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+)
+
+// isolateTokenSources points every token source at an empty temporary
+// directory and returns it.
+func isolateTokenSources(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"GH_TOKEN", "GITHUB_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN", "NPRT_GITHUB_TOKEN_FILE", "CREDENTIALS_DIRECTORY", "GH_CONFIG_DIR"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("NETRC", filepath.Join(dir, "netrc"))
+	return dir
+}
+
+func writeTokenFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	// WriteFile is subject to the umask.
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindGitHubToken_None(t *testing.T) {
+	isolateTokenSources(t)
+
+	token, warnings := config.FindGitHubToken(config.DefaultHost)
+	if token.Value != "" || len(warnings) != 0 {
+		t.Errorf("FindGitHubToken = %+v, %v; want no token and no warnings", token, warnings)
+	}
+}
+
+func TestFindGitHubToken_Order(t *testing.T) {
+	dir := isolateTokenSources(t)
+
+	writeTokenFile(t, filepath.Join(dir, "netrc"), "machine api.github.com login x password from-netrc\n", 0o600)
+	assertToken(t, "from-netrc", filepath.Join(dir, "netrc"))
+
+	writeTokenFile(t, filepath.Join(dir, "config", "gh", "hosts.yml"), "github.com:\n    user: someone\n    oauth_token: from-gh\n    git_protocol: https\n", 0o600)
+	assertToken(t, "from-gh", filepath.Join(dir, "config", "gh", "hosts.yml"))
+
+	creds := filepath.Join(dir, "creds")
+	writeTokenFile(t, filepath.Join(creds, "github-token"), "from-systemd\n", 0o400)
+	t.Setenv("CREDENTIALS_DIRECTORY", creds)
+	assertToken(t, "from-systemd", filepath.Join(creds, "github-token"))
+
+	file := filepath.Join(dir, "token")
+	writeTokenFile(t, file, "  from-file\n", 0o600)
+	t.Setenv("NPRT_GITHUB_TOKEN_FILE", file)
+	assertToken(t, "from-file", file)
+
+	t.Setenv("GITHUB_TOKEN", "from-github-token")
+	assertToken(t, "from-github-token", "GITHUB_TOKEN")
+
+	t.Setenv("GH_TOKEN", "from-gh-token")
+	assertToken(t, "from-gh-token", "GH_TOKEN")
+}
+
+func assertToken(t *testing.T, value, source string) {
+	t.Helper()
+	token, warnings := config.FindGitHubToken(config.DefaultHost)
+	if token.Value != value || token.Source != source {
+		t.Errorf("FindGitHubToken = %+v, want %q from %q", token, value, source)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
+
+func TestFindGitHubToken_RefusesReadableFiles(t *testing.T) {
+	dir := isolateTokenSources(t)
+
+	file := filepath.Join(dir, "token")
+	writeTokenFile(t, file, "secret\n", 0o644)
+	t.Setenv("NPRT_GITHUB_TOKEN_FILE", file)
+	writeTokenFile(t, filepath.Join(dir, "netrc"), "machine api.github.com password from-netrc\n", 0o600)
+
+	token, warnings := config.FindGitHubToken(config.DefaultHost)
+	if token.Value != "from-netrc" {
+		t.Errorf("token = %q, want the next source's token %q", token.Value, "from-netrc")
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], file) {
+		t.Errorf("warnings = %v, want one warning about %s", warnings, file)
+	}
+}
+
+func TestFindGitHubToken_WarnsAboutMissingTokenFile(t *testing.T) {
+	dir := isolateTokenSources(t)
+
+	file := filepath.Join(dir, "missing")
+	t.Setenv("NPRT_GITHUB_TOKEN_FILE", file)
+	// The implicit systemd credential is silently skipped when missing.
+	t.Setenv("CREDENTIALS_DIRECTORY", filepath.Join(dir, "creds"))
+	writeTokenFile(t, filepath.Join(dir, "netrc"), "machine api.github.com password from-netrc\n", 0o600)
+
+	token, warnings := config.FindGitHubToken(config.DefaultHost)
+	if token.Value != "from-netrc" {
+		t.Errorf("token = %q, want the next source's token %q", token.Value, "from-netrc")
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "NPRT_GITHUB_TOKEN_FILE "+file) {
+		t.Errorf("warnings = %v, want one warning about NPRT_GITHUB_TOKEN_FILE %s", warnings, file)
+	}
+}
+
+func TestFindGitHubToken_WarnsAboutUnreadableTokenFile(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read every file")
+	}
+	dir := isolateTokenSources(t)
+
+	// The credential cannot be looked up in a directory without search
+	// permission.
+	creds := filepath.Join(dir, "creds")
+	writeTokenFile(t, filepath.Join(creds, "github-token"), "from-systemd\n", 0o600)
+	if err := os.Chmod(creds, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(creds, 0o700) })
+	t.Setenv("CREDENTIALS_DIRECTORY", creds)
+
+	token, warnings := config.FindGitHubToken(config.DefaultHost)
+	if token.Value != "" {
+		t.Errorf("token = %q, want none", token.Value)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], creds) {
+		t.Errorf("warnings = %v, want one warning about %s", warnings, creds)
+	}
+}
+
+func TestFindGitHubToken_GHHostsWithUsers(t *testing.T) {
+	dir := isolateTokenSources(t)
+
+	hosts := `github.example.com:
+    oauth_token: enterprise
+github.com:
+    git_protocol: ssh
+    users:
+        someone:
+            oauth_token: "nested"
+    user: someone
+`
+	writeTokenFile(t, filepath.Join(dir, "config", "gh", "hosts.yml"), hosts, 0o600)
+
+	token, _ := config.FindGitHubToken(config.DefaultHost)
+	if token.Value != "nested" {
+		t.Errorf("token = %q, want %q", token.Value, "nested")
+	}
+}
+
+func TestFindGitHubToken_NetrcOtherMachines(t *testing.T) {
+	dir := isolateTokenSources(t)
+
+	netrc := "machine github.com login a password wrong\n" +
+		"machine api.github.com\n  login b\n  password right\n" +
+		"default login c password fallback\n"
+	writeTokenFile(t, filepath.Join(dir, "netrc"), netrc, 0o600)
+
+	token, _ := config.FindGitHubToken(config.DefaultHost)
+	if token.Value != "right" {
+		t.Errorf("token = %q, want %q", token.Value, "right")
+	}
+}
+
+func TestFindGitHubToken_Enterprise(t *testing.T) {
+	dir := isolateTokenSources(t)
+	host, err := config.ParseHost("", "https://github.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GH_TOKEN", "github-com")
+	writeTokenFile(t, filepath.Join(dir, "netrc"), "machine api.github.com password wrong\nmachine github.example.com password from-netrc\n", 0o600)
+	if token, _ := config.FindGitHubToken(host); token.Value != "from-netrc" {
+		t.Errorf("token = %q, want the netrc entry of the enterprise host", token.Value)
+	}
+
+	writeTokenFile(t, filepath.Join(dir, "config", "gh", "hosts.yml"), "github.com:\n    oauth_token: wrong\ngithub.example.com:\n    oauth_token: from-gh\n", 0o600)
+	if token, _ := config.FindGitHubToken(host); token.Value != "from-gh" {
+		t.Errorf("token = %q, want the gh token of the enterprise host", token.Value)
+	}
+
+	t.Setenv("GH_ENTERPRISE_TOKEN", "enterprise")
+	if token, _ := config.FindGitHubToken(host); token.Value != "enterprise" || token.Source != "GH_ENTERPRISE_TOKEN" {
+		t.Errorf("token = %+v, want GH_ENTERPRISE_TOKEN", token)
+	}
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thatsneat-dev/nprt/internal/config"
+)
+
+func stageNames(p *config.Pipeline) [][]string {
+	var out [][]string
+	for _, stage := range p.Stages {
+		var names []string
+		for _, ch := range stage {
+			names = append(names, ch.Name)
+		}
+		out = append(out, names)
+	}
+	return out
+}
+
+func TestDownstream(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   []string
+	}{
+		{"staging", []string{"staging-next"}},
+		{"master", []string{"nixos-unstable-small", "nixpkgs-unstable"}},
+		{"nixos-unstable", nil},
+		{"haskell-updates", []string{"staging"}},
+		{"staging-25.05", []string{"staging-next-25.05"}},
+		{"staging-next-25.05", []string{"release-25.05"}},
+		{"release-25.05", []string{"nixos-25.05-small", "nixpkgs-25.05-darwin"}},
+		{"nixos-25.05-small", []string{"nixos-25.05"}},
+		{"nixos-25.05", nil},
+		{"some-feature", nil},
+	}
+
+	for _, tc := range tests {
+		if got := config.Downstream(tc.branch); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Downstream(%q) = %v, want %v", tc.branch, got, tc.want)
+		}
+	}
+}
+
+func TestPipelineFor_Staging(t *testing.T) {
+	p := config.PipelineFor("staging")
+	if p == nil {
+		t.Fatal("PipelineFor(staging) returned nil")
+	}
+
+	want := [][]string{
+		{"staging"},
+		{"staging-next"},
+		{"master"},
+		{"nixos-unstable-small", "nixpkgs-unstable"},
+		{"nixos-unstable"},
+	}
+	if got := stageNames(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("PipelineFor(staging) stages = %v, want %v", got, want)
+	}
+	if up := p.Upstream("nixos-unstable"); !reflect.DeepEqual(up, []string{"nixos-unstable-small"}) {
+		t.Errorf("Upstream(nixos-unstable) = %v, want [nixos-unstable-small]", up)
+	}
+}
+
+func TestPipelineFor_Release(t *testing.T) {
+	p := config.PipelineFor("release-25.05")
+	if p == nil {
+		t.Fatal("PipelineFor(release-25.05) returned nil")
+	}
+
+	want := [][]string{
+		{"release-25.05"},
+		{"nixos-25.05-small", "nixpkgs-25.05-darwin"},
+		{"nixos-25.05"},
+	}
+	if got := stageNames(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("PipelineFor(release-25.05) stages = %v, want %v", got, want)
+	}
+	for _, ch := range p.Channels() {
+		if ch.Release != "25.05" {
+			t.Errorf("channel %q Release = %q, want 25.05", ch.Name, ch.Release)
+		}
+	}
+}
+
+func TestPipelineFor_UnknownBase(t *testing.T) {
+	if p := config.PipelineFor("gnome-49"); p != nil {
+		t.Errorf("PipelineFor(gnome-49) = %v, want nil", stageNames(p))
+	}
+}
@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/thatsneat-dev/nprt/internal/core"
+	"github.com/thatsneat-dev/nprt/internal/github"
+	"github.com/thatsneat-dev/nprt/internal/watch"
+)
+
+func statusWith(statuses ...core.ChannelStatus) *core.PRStatus {
+	names := []string{"master", "nixos-unstable"}
+	status := &core.PRStatus{Number: 1, State: core.PRStateMerged}
+	for i, s := range statuses {
+		status.Channels = append(status.Channels, core.ChannelResult{Name: names[i], Branch: names[i], Status: s})
+	}
+	return status
+}
+
+func TestRun_StopsWhenUntilPresent(t *testing.T) {
+	sequence := []*core.PRStatus{
+		statusWith(core.StatusPresent, core.StatusNotPresent),
+		statusWith(core.StatusPresent, core.StatusNotPresent),
+		statusWith(core.StatusPresent, core.StatusPresent),
+	}
+	calls := 0
+	check := func(ctx context.Context) (*core.PRStatus, error) {
+		s := sequence[calls]
+		calls++
+		return s, nil
+	}
+
+	var updates int
+	var changes []core.ChannelChange
+	status, err := watch.Run(context.Background(), check, watch.Options{
+		Interval: time.Millisecond,
+		Until:    []string{"nixos-unstable"},
+		OnUpdate: func(prev, cur *core.PRStatus) {
+			updates++
+			changes = append(changes, core.DiffChannels(prev, cur)...)
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("expected 3 checks, got %d", calls)
+	}
+	if updates != 3 {
+		t.Errorf("expected 3 updates, got %d", updates)
+	}
+	if len(changes) != 1 || changes[0].Name != "nixos-unstable" || changes[0].To != core.StatusPresent {
+		t.Errorf("changes = %+v, want single nixos-unstable -> present", changes)
+	}
+	if !core.HasChannels(status, []string{"nixos-unstable"}) {
+		t.Error("final status should contain nixos-unstable")
+	}
+}
+
+func TestRun_DefaultsToAllChannels(t *testing.T) {
+	calls := 0
+	check := func(ctx context.Context) (*core.PRStatus, error) {
+		calls++
+		if calls == 1 {
+			return statusWith(core.StatusPresent, core.StatusNotPresent), nil
+		}
+		return statusWith(core.StatusPresent, core.StatusPresent), nil
+	}
+
+	if _, err := watch.Run(context.Background(), check, watch.Options{Interval: time.Millisecond}, zap.NewNop()); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 checks, got %d", calls)
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	check := func(ctx context.Context) (*core.PRStatus, error) {
+		return statusWith(core.StatusPresent, core.StatusNotPresent), nil
+	}
+
+	status, err := watch.Run(context.Background(), check, watch.Options{
+		Interval: 5 * time.Millisecond,
+		Timeout:  30 * time.Millisecond,
+		Until:    []string{"nixos-unstable"},
+	}, zap.NewNop())
+	if !errors.Is(err, watch.ErrTimeout) {
+		t.Fatalf("Run error = %v, want ErrTimeout", err)
+	}
+	if status == nil {
+		t.Error("Run should return the last status on timeout")
+	}
+}
+
+func TestRun_RetriesTransientErrors(t *testing.T) {
+	calls := 0
+	retries := 0
+	check := func(ctx context.Context) (*core.PRStatus, error) {
+		calls++
+		switch calls {
+		case 1:
+			return nil, fmt.Errorf("network error talking to GitHub: %w", &url.Error{Op: "Get", URL: "x", Err: errors.New("connection reset")})
+		case 2:
+			return nil, &github.APIError{StatusCode: 502, Message: "Bad Gateway"}
+		}
+		return statusWith(core.StatusPresent, core.StatusPresent), nil
+	}
+
+	_, err := watch.Run(context.Background(), check, watch.Options{
+		Interval: time.Millisecond,
+		OnRetry:  func(err error, wait time.Duration) { retries++ },
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if retries != 2 {
+		t.Errorf("expected 2 retries, got %d", retries)
+	}
+}
+
+func TestRun_PermanentErrorStops(t *testing.T) {
+	calls := 0
+	check := func(ctx context.Context) (*core.PRStatus, error) {
+		calls++
+		return nil, &github.NotFoundError{Number: 1}
+	}
+
+	_, err := watch.Run(context.Background(), check, watch.Options{Interval: time.Millisecond}, zap.NewNop())
+	var nf *github.NotFoundError
+	if !errors.As(err, &nf) {
+		t.Fatalf("Run error = %v, want NotFoundError", err)
+	}
+	if calls != 1 {
+		t.Errorf("permanent errors should not be retried, got %d calls", calls)
+	}
+}
+
+func TestRun_WaitsForRateLimitReset(t *testing.T) {
+	calls := 0
+	var times []time.Time
+	check := func(ctx context.Context) (*core.PRStatus, error) {
+		calls++
+		times = append(times, time.Now())
+		if calls == 1 {
+			return nil, &github.APIError{StatusCode: 403, Message: "rate limit exceeded"}
+		}
+		return statusWith(core.StatusPresent, core.StatusPresent), nil
+	}
+
+	// The reset time is truncated to whole seconds by the API, so a reset
+	// in the past must not cause any waiting beyond the interval.
+	reset := time.Now().Add(-time.Second)
+	_, err := watch.Run(context.Background(), check, watch.Options{
+		Interval: time.Millisecond,
+		RateLimit: func() (github.RateLimit, bool) {
+			return github.RateLimit{Remaining: 0, Reset: reset}, true
+		},
+	}, zap.NewNop())
+	if err == nil {
+		t.Fatal("rate limit with a past reset and no 429 should not be retried")
+	}
+
+	calls = 0
+	reset = time.Now().Add(50 * time.Millisecond)
+	start := time.Now()
+	_, err = watch.Run(context.Background(), check, watch.Options{
+		Interval: time.Millisecond,
+		RateLimit: func() (github.RateLimit, bool) {
+			return github.RateLimit{Remaining: 0, Reset: reset}, true
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Run should wait for the rate limit reset, only waited %s", elapsed)
+	}
+}
+
+func TestRun_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	check := func(ctx context.Context) (*core.PRStatus, error) {
+		cancel()
+		return statusWith(core.StatusPresent, core.StatusNotPresent), nil
+	}
+
+	_, err := watch.Run(ctx, check, watch.Options{Interval: time.Hour}, zap.NewNop())
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run error = %v, want context.Canceled", err)
+	}
+}
+
+func TestCLI_WatchUntilOutsidePipeline(t *testing.T) {
+	bin := buildNprt(t)
+
+	// PR 100 targets master and is in nixos-25.05, which is not downstream
+	// of master.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pulls/100"):
+			w.Write([]byte(`{"number": 100, "state": "closed", "merged": true,
+				"merge_commit_sha": "abc123", "base": {"ref": "master"}}`))
+		case strings.HasSuffix(r.URL.Path, "/git/matching-refs/heads/nixos-"):
+			w.Write([]byte(`[{"ref": "refs/heads/nixos-25.05"}]`))
+		case strings.Contains(r.URL.Path, "/git/matching-refs/"):
+			w.Write([]byte(`[]`))
+		case strings.Contains(r.URL.Path, "/compare/"):
+			w.Write([]byte(`{"status": "ahead", "behind_by": 0}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	home := t.TempDir()
+	cmd := exec.Command(bin, "watch", "--no-cache", "--retries=0", "--api-url", server.URL,
+		"--json", "--timeout=1s", "--until=nixos-25.05", "100")
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + home, "XDG_CONFIG_HOME=" + home, "XDG_CACHE_HOME=" + home}
+	out, _ := cmd.CombinedOutput()
+	if got := cmd.ProcessState.ExitCode(); got != 0 {
+		t.Errorf("nprt watch exited %d, want 0\n%s", got, out)
+	}
+	if !strings.Contains(string(out), `"name":"nixos-25.05","branch":"nixos-25.05","status":"present"`) {
+		t.Errorf("output lacks the present nixos-25.05 channel:\n%s", out)
+	}
+}